@@ -0,0 +1,101 @@
+package paylike
+
+import (
+	"sort"
+	"time"
+)
+
+// TransactionReport aggregates the transactions in a currency created within
+// a time window, e.g. for fraud and conversion dashboards
+type TransactionReport struct {
+	Start      time.Time          // start of the window, zero for transactions with an unparsable creation date
+	Currency   string             // currency of the transactions
+	Count      int                // number of transactions
+	Successful int                // number of successful authorizations
+	Declined   int                // number of authorizations declined by the issuer
+	Errors     int                // number of authorizations that failed with an error
+	Amount     int64              // authorized amount of the successful transactions in minor units
+	Schemes    map[CardScheme]int // number of transactions per card scheme
+}
+
+// DeclineRate returns the share of declined transactions, between 0 and 1
+func (r TransactionReport) DeclineRate() float64 {
+	return ratio(r.Declined, r.Count)
+}
+
+// ErrorRate returns the share of transactions that failed with an error,
+// between 0 and 1
+func (r TransactionReport) ErrorRate() float64 {
+	return ratio(r.Errors, r.Count)
+}
+
+// SchemeShare returns the share of transactions made with cards of the
+// given scheme, between 0 and 1
+func (r TransactionReport) SchemeShare(scheme CardScheme) float64 {
+	return ratio(r.Schemes[scheme], r.Count)
+}
+
+// AverageTicket returns the average amount of the successful transactions
+// in minor units
+func (r TransactionReport) AverageTicket() float64 {
+	if r.Successful == 0 {
+		return 0
+	}
+	return float64(r.Amount) / float64(r.Successful)
+}
+
+// ratio returns n/total, or 0 if total is 0
+func ratio(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+// AnalyzeTransactions aggregates the given transactions per time window of
+// the given size and currency, ordered by window and currency
+// Windows are aligned to the zero time in UTC (see time.Time.Truncate),
+// a window of 0 aggregates all transactions in a single window
+func AnalyzeTransactions(transactions []*Transaction, window time.Duration) []TransactionReport {
+	type key struct {
+		start    time.Time
+		currency string
+	}
+	groups := map[key]*TransactionReport{}
+	for _, transaction := range transactions {
+		var start time.Time
+		if created, err := time.Parse(time.RFC3339, transaction.Created); err == nil && window > 0 {
+			start = created.UTC().Truncate(window)
+		}
+		k := key{start, transaction.Currency}
+		report, ok := groups[k]
+		if !ok {
+			report = &TransactionReport{Start: start, Currency: transaction.Currency, Schemes: map[CardScheme]int{}}
+			groups[k] = report
+		}
+		report.Count++
+		if transaction.Card.Scheme != "" {
+			report.Schemes[transaction.Card.Scheme]++
+		}
+		switch {
+		case transaction.Error:
+			report.Errors++
+		case transaction.Successful:
+			report.Successful++
+			report.Amount += transaction.Amount
+		default:
+			report.Declined++
+		}
+	}
+	result := make([]TransactionReport, 0, len(groups))
+	for _, report := range groups {
+		result = append(result, *report)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].Start.Equal(result[j].Start) {
+			return result[i].Start.Before(result[j].Start)
+		}
+		return result[i].Currency < result[j].Currency
+	})
+	return result
+}
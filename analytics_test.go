@@ -0,0 +1,48 @@
+package paylike
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzeTransactions(t *testing.T) {
+	visa := TransactionCard{Scheme: SchemeVisa}
+	mastercard := TransactionCard{Scheme: SchemeMasterCard}
+	transactions := []*Transaction{
+		{Created: "2024-01-01T10:00:00.000Z", Currency: "EUR", Amount: 1000, Successful: true, Card: visa},
+		{Created: "2024-01-01T11:00:00.000Z", Currency: "EUR", Amount: 3000, Successful: true, Card: mastercard},
+		{Created: "2024-01-01T12:00:00.000Z", Currency: "EUR", Amount: 500, Card: visa},
+		{Created: "2024-01-01T13:00:00.000Z", Currency: "EUR", Amount: 700, Error: true, Card: visa},
+		{Created: "2024-01-01T14:00:00.000Z", Currency: "DKK", Amount: 2000, Successful: true, Card: visa},
+		{Created: "2024-01-02T09:00:00.000Z", Currency: "EUR", Amount: 800, Successful: true, Card: visa},
+	}
+	reports := AnalyzeTransactions(transactions, 24*time.Hour)
+	assert.Len(t, reports, 3)
+
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, day, reports[0].Start)
+	assert.Equal(t, "DKK", reports[0].Currency)
+	eur := reports[1]
+	assert.Equal(t, day, eur.Start)
+	assert.Equal(t, "EUR", eur.Currency)
+	assert.Equal(t, 4, eur.Count)
+	assert.Equal(t, 2, eur.Successful)
+	assert.Equal(t, 1, eur.Declined)
+	assert.Equal(t, 1, eur.Errors)
+	assert.Equal(t, 0.25, eur.DeclineRate())
+	assert.Equal(t, 0.25, eur.ErrorRate())
+	assert.Equal(t, 0.75, eur.SchemeShare(SchemeVisa))
+	assert.Equal(t, 2000.0, eur.AverageTicket())
+	assert.Equal(t, day.Add(24*time.Hour), reports[2].Start)
+	assert.Equal(t, 1, reports[2].Count)
+
+	all := AnalyzeTransactions(transactions, 0)
+	assert.Len(t, all, 2)
+	assert.True(t, all[0].Start.IsZero())
+	assert.Equal(t, 5, all[1].Count)
+
+	assert.Empty(t, AnalyzeTransactions(nil, time.Hour))
+	assert.Equal(t, 0.0, TransactionReport{}.AverageTicket())
+}
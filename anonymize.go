@@ -0,0 +1,137 @@
+package paylike
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// anonymizedFields are the fields scrubbed regardless of where they appear
+var anonymizedFields = map[string]string{
+	"bin":        "000000",
+	"last4":      "0000",
+	"expiry":     "2000-01-31T00:00:00.000Z",
+	"iban":       "XX0000000000",
+	"key":        "00000000-0000-0000-0000-000000000000",
+	"number":     "00000000",
+	"name":       "Anonymized",
+	"descriptor": "Anonymized",
+	"notes":      "Anonymized",
+	"website":    "https://example.com",
+}
+
+// Anonymize scrubs IDs, emails, keys, card data and custom fields from a
+// recorded API payload while preserving its structure, so it can be safely
+// attached to bug reports
+// Equal IDs are replaced by equal placeholders to keep the relations intact
+// Payloads that are not valid JSON are scrubbed entirely and nil is returned
+func Anonymize(payload []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil
+	}
+	a := &anonymizer{ids: map[string]string{}, emails: map[string]string{}}
+	b, err := json.Marshal(a.value("", value))
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// anonymizer keeps track of the placeholders handed out for IDs and emails
+type anonymizer struct {
+	ids    map[string]string
+	emails map[string]string
+}
+
+// value scrubs the given value found under the given field name
+func (a *anonymizer) value(field string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		// placeholders are handed out in key order to be deterministic
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if k == "custom" {
+				v[k] = a.custom(v[k])
+				continue
+			}
+			v[k] = a.value(k, v[k])
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = a.value(field, item)
+		}
+		return v
+	case string:
+		return a.string(field, v)
+	}
+	return value
+}
+
+// string scrubs the given string found under the given field name
+func (a *anonymizer) string(field string, s string) string {
+	switch {
+	case s == "":
+		return s
+	case field == "id" || strings.HasSuffix(field, "Id"):
+		return a.id(s)
+	case field == "email" || strings.Contains(s, "@"):
+		return a.email(s)
+	}
+	if placeholder, ok := anonymizedFields[field]; ok {
+		return placeholder
+	}
+	return s
+}
+
+// custom scrubs all leaf values of custom data, keeping its keys
+func (a *anonymizer) custom(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = a.custom(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = a.custom(item)
+		}
+		return v
+	case string:
+		return "redacted"
+	case json.Number:
+		return json.Number("0")
+	case bool:
+		return false
+	}
+	return value
+}
+
+// id returns the placeholder of the given ID, padded to the same length
+func (a *anonymizer) id(id string) string {
+	if placeholder, ok := a.ids[id]; ok {
+		return placeholder
+	}
+	placeholder := fmt.Sprintf("%0*x", len(id), len(a.ids)+1)
+	a.ids[id] = placeholder
+	return placeholder
+}
+
+// email returns the placeholder of the given email
+func (a *anonymizer) email(email string) string {
+	if placeholder, ok := a.emails[email]; ok {
+		return placeholder
+	}
+	placeholder := fmt.Sprintf("user%d@example.com", len(a.emails)+1)
+	a.emails[email] = placeholder
+	return placeholder
+}
@@ -0,0 +1,48 @@
+package paylike
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnonymize(t *testing.T) {
+	payload := []byte(`{"transaction":{"id":"5da8272132aad2256xa2d2e6","merchantId":"55006bdfe0308c4cbfdbd0e1","amount":200,
+		"card":{"bin":"410000","last4":"0000","expiry":"2022-11-30T23:59:59.999Z","scheme":"visa"},
+		"custom":{"orderId":"1234","email":"john@example.com","items":[{"qty":2}]},
+		"trail":[{"amount":200,"lineId":"5da8272132aad2256xa2d2e6"}],"successful":true}}`)
+	var anonymized map[string]map[string]interface{}
+	assert.Nil(t, json.Unmarshal(Anonymize(payload), &anonymized))
+	transaction := anonymized["transaction"]
+
+	assert.Equal(t, "000000000000000000000001", transaction["id"])
+	assert.Equal(t, "000000000000000000000002", transaction["merchantId"])
+	assert.Equal(t, float64(200), transaction["amount"])
+	assert.Equal(t, true, transaction["successful"])
+	assert.Equal(t, map[string]interface{}{
+		"bin":    "000000",
+		"last4":  "0000",
+		"expiry": "2000-01-31T00:00:00.000Z",
+		"scheme": "visa",
+	}, transaction["card"])
+	assert.Equal(t, map[string]interface{}{
+		"orderId": "redacted",
+		"email":   "redacted",
+		"items":   []interface{}{map[string]interface{}{"qty": float64(0)}},
+	}, transaction["custom"])
+	trail := transaction["trail"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, transaction["id"], trail["lineId"])
+}
+
+func TestAnonymizeEmailsAndLists(t *testing.T) {
+	payload := []byte(`[{"id":"u1","email":"one@example.org"},{"id":"u2","email":"two@example.org"},{"id":"u3","email":"one@example.org"}]`)
+	assert.Equal(t,
+		`[{"email":"user1@example.com","id":"01"},{"email":"user2@example.com","id":"02"},{"email":"user1@example.com","id":"03"}]`,
+		string(Anonymize(payload)),
+	)
+}
+
+func TestAnonymizeInvalid(t *testing.T) {
+	assert.Nil(t, Anonymize([]byte(`{"id":`)))
+}
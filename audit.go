@@ -0,0 +1,76 @@
+package paylike
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// AuditRecord describes a call that may have changed data, i.e. a POST, PUT
+// or DELETE request, as reported to the audit sink once the call finished
+type AuditRecord struct {
+	Time       time.Time     // when the call started, according to the clock of the client
+	Duration   time.Duration // how long the call took, retries included
+	Operation  Operation     // what was done
+	Path       string        // the path of the request, naming the resources written to
+	Key        string        // the API key the call was authenticated with, redacted (see RedactKey)
+	MerchantID MerchantID    // the merchant the call was made on behalf of, if known
+	RequestID  string        // ID of the originating request, see WithRequestIDFromContext
+	Payload    []byte        // the request body, anonymized (see Anonymize)
+	StatusCode int           // status of the last response, zero if none has been received
+	Attempts   int           // number of attempts made
+	Err        error         // why the call failed, if it did
+	DryRun     bool          // whether the call has only been simulated, see WithDryRun
+}
+
+// AuditSink receives a record of every call that may have changed data, e.g.
+// to keep the audit trails required by PCI DSS or SOC 2
+// Record is called synchronously after the call finished and should not block
+type AuditSink interface {
+	Record(ctx context.Context, record AuditRecord)
+}
+
+// AuditSinkFunc is an adapter to allow the use of ordinary functions as AuditSink
+type AuditSinkFunc func(ctx context.Context, record AuditRecord)
+
+// Record calls f(ctx, record)
+func (f AuditSinkFunc) Record(ctx context.Context, record AuditRecord) {
+	f(ctx, record)
+}
+
+// WithAuditSink reports every POST, PUT and DELETE request to the given sink
+func WithAuditSink(sink AuditSink) Option {
+	return func(c *Client) {
+		c.auditSink = sink
+	}
+}
+
+// newAuditRecord starts the audit record of the given request, or returns
+// nil if the request is not audited
+func (c Client) newAuditRecord(req *http.Request, op Operation) *AuditRecord {
+	if c.auditSink == nil || req.Method == http.MethodGet {
+		return nil
+	}
+	record := &AuditRecord{
+		Time:      c.now(),
+		Operation: op,
+		Path:      req.URL.Path,
+		Key:       RedactKey(c.Key),
+		RequestID: c.requestID(req.Context()),
+	}
+	record.MerchantID, _ = MerchantFromContext(req.Context())
+	if body, err := requestBody(req); err == nil && len(body) > 0 {
+		record.Payload = Anonymize(body)
+	}
+	return record
+}
+
+// audit completes the given audit record with the outcome of the call and
+// reports it to the audit sink
+func (c Client) audit(ctx context.Context, record *AuditRecord, statusCode, attempts int, err error) {
+	record.Duration = c.now().Sub(record.Time)
+	record.StatusCode = statusCode
+	record.Attempts = attempts
+	record.Err = err
+	c.auditSink.Record(ctx, *record)
+}
@@ -0,0 +1,41 @@
+package paylike
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditSink(t *testing.T) {
+	var bodies []string
+	var records []AuditRecord
+	client := newStatusSequenceClient(t, []int{503, 200}, &bodies, WithRetries(2),
+		WithAuditSink(AuditSinkFunc(func(ctx context.Context, record AuditRecord) {
+			records = append(records, record)
+		})))
+
+	_, err := client.CaptureTransaction("tx1", TransactionTrailDTO{Amount: 100, Descriptor: "Order 42"})
+	assert.Nil(t, err)
+	_, err = client.GetMerchant("m1")
+	assert.Nil(t, err)
+	err = client.UpdateMerchant("m1", MerchantUpdateDTO{Email: "jane@example.com"})
+	assert.Nil(t, err)
+
+	assert.Len(t, records, 2)
+	capture := records[0]
+	assert.Equal(t, OpCaptureTransaction, capture.Operation)
+	assert.Equal(t, "/transactions/tx1/captures", capture.Path)
+	assert.Equal(t, RedactKey(TestKey), capture.Key)
+	assert.Equal(t, 200, capture.StatusCode)
+	assert.Equal(t, 2, capture.Attempts)
+	assert.Nil(t, capture.Err)
+	assert.Equal(t, `{"amount":100,"descriptor":"Anonymized"}`, string(capture.Payload))
+	assert.False(t, capture.Time.IsZero())
+
+	update := records[1]
+	assert.Equal(t, OpUpdateMerchant, update.Operation)
+	assert.Equal(t, MerchantID("m1"), update.MerchantID)
+	assert.NotContains(t, string(update.Payload), "jane@example.com")
+	assert.Equal(t, 1, update.Attempts)
+}
@@ -0,0 +1,81 @@
+package paylike
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// AuthFailure describes the first authentication failure of a key, e.g.
+// after it has been revoked or rotated
+type AuthFailure struct {
+	Time       time.Time
+	Operation  Operation
+	Key        string // redacted, see RedactKey
+	MerchantID MerchantID
+	Err        error
+}
+
+// OnAuthFailure calls the given hook when requests authenticated with a key
+// start failing with 401 Unauthorized, so operators get paged about revoked or
+// rotated keys before payments start failing downstream
+// The hook is called once per key until a request authenticated with it
+// succeeds again or, if interval is positive, the interval has elapsed since
+// the previous call
+func OnAuthFailure(hook func(AuthFailure), interval time.Duration) Option {
+	return func(c *Client) {
+		c.authFailures = &authFailures{hook: hook, interval: interval, reported: map[string]time.Time{}}
+	}
+}
+
+// authFailures debounces the authentication failures reported per key
+type authFailures struct {
+	mu       sync.Mutex
+	hook     func(AuthFailure)
+	interval time.Duration
+	reported map[string]time.Time
+}
+
+// report records the given failure, reporting whether the hook is due
+func (f *authFailures) report(key string, at time.Time) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if last, ok := f.reported[key]; ok && (f.interval <= 0 || at.Sub(last) < f.interval) {
+		return false
+	}
+	f.reported[key] = at
+	return true
+}
+
+// reset forgets the failures of the given key
+func (f *authFailures) reset(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.reported, key)
+}
+
+// checkAuth calls the authentication failure hook, if any, when the given
+// response tells the key of the client has been refused
+func (c Client) checkAuth(req *http.Request, op Operation, resp *http.Response, err error) {
+	if c.authFailures == nil {
+		return
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		if err == nil {
+			c.authFailures.reset(c.Key)
+		}
+		return
+	}
+	now := c.now()
+	if !c.authFailures.report(c.Key, now) {
+		return
+	}
+	merchantID, _ := MerchantFromContext(req.Context())
+	c.authFailures.hook(AuthFailure{
+		Time:       now,
+		Operation:  op,
+		Key:        RedactKey(c.Key),
+		MerchantID: merchantID,
+		Err:        err,
+	})
+}
@@ -0,0 +1,61 @@
+package paylike
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnAuthFailure(t *testing.T) {
+	refused := true
+	var failures []AuthFailure
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if refused {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"code":"UNAUTHORIZED","message":"invalid key"}`))
+		}
+	}), OnAuthFailure(func(f AuthFailure) {
+		failures = append(failures, f)
+	}, time.Hour), WithClock(ClockFunc(func() time.Time { return now })))
+
+	_, err := client.GetMerchant("m1")
+	assert.NotNil(t, err)
+	_, err = client.FetchApp()
+	assert.NotNil(t, err)
+	assert.Len(t, failures, 1)
+	assert.Equal(t, "GetMerchant", failures[0].Operation.Name)
+	assert.Equal(t, MerchantID("m1"), failures[0].MerchantID)
+	assert.Equal(t, RedactKey(TestKey), failures[0].Key)
+	assert.Equal(t, http.StatusUnauthorized, failures[0].Err.(*APIError).StatusCode)
+
+	now = now.Add(time.Hour)
+	client.FetchApp()
+	assert.Len(t, failures, 2)
+
+	refused = false
+	_, err = client.FetchApp()
+	assert.Nil(t, err)
+	refused = true
+	client.FetchApp()
+	assert.Len(t, failures, 3)
+}
+
+func TestOnAuthFailureOnce(t *testing.T) {
+	calls := 0
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}), OnAuthFailure(func(f AuthFailure) {
+		calls++
+	}, 0), WithClock(ClockFunc(func() time.Time { return now })))
+
+	for i := 0; i < 3; i++ {
+		client.FetchApp()
+		now = now.Add(24 * time.Hour)
+	}
+	client.SetKey("other").FetchApp()
+	assert.Equal(t, 2, calls)
+}
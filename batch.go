@@ -0,0 +1,103 @@
+package paylike
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultBatchConcurrency is the number of requests a batch runs at a time
+const DefaultBatchConcurrency = 4
+
+// WithBatchConcurrency changes how many requests a batch runs at a time,
+// defaults to DefaultBatchConcurrency
+func WithBatchConcurrency(n int) Option {
+	return func(c *Client) {
+		c.batchConcurrency = n
+	}
+}
+
+// TrailRequest describes a single capture, refund or void of a batch
+type TrailRequest struct {
+	Key           string // optional, idempotency key, required with WithJournal
+	TransactionID TxID
+	TransactionTrailDTO
+}
+
+// BatchResult describes the outcome of a single request of a batch
+type BatchResult struct {
+	TransactionID TxID
+	Transaction   *Transaction // the updated transaction, if successful
+	Skipped       bool         // whether the journal tells the request was done by an earlier run
+	Err           error
+}
+
+// BatchResults describes the outcome of a batch, in the order of its requests
+type BatchResults []BatchResult
+
+// Failed returns the results of the failed requests
+func (r BatchResults) Failed() BatchResults {
+	var failed BatchResults
+	for _, result := range r {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// BatchCapture captures the given transactions with bounded concurrency,
+// carrying on past failed requests
+// Requests not yet started when the context is done fail with its error
+func (c Client) BatchCapture(ctx context.Context, requests []TrailRequest, opts ...CallOption) BatchResults {
+	return c.batch(ctx, OpCaptureTransaction, requests, c.CaptureTransaction, opts)
+}
+
+// BatchRefund refunds the given transactions like BatchCapture
+func (c Client) BatchRefund(ctx context.Context, requests []TrailRequest, opts ...CallOption) BatchResults {
+	return c.batch(ctx, OpRefundTransaction, requests, c.RefundTransaction, opts)
+}
+
+// BatchVoid voids the given transactions like BatchCapture
+func (c Client) BatchVoid(ctx context.Context, requests []TrailRequest, opts ...CallOption) BatchResults {
+	return c.batch(ctx, OpVoidTransaction, requests, c.VoidTransaction, opts)
+}
+
+// batch runs the given transaction trail method for every request, through
+// the journal of the client if any
+func (c Client) batch(ctx context.Context, op Operation, requests []TrailRequest, method func(TxID, TransactionTrailDTO, ...CallOption) (*Transaction, error), opts []CallOption) BatchResults {
+	concurrency := c.batchConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	opts = append(opts[:len(opts):len(opts)], WithContext(ctx))
+	results := make(BatchResults, len(requests))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, request := range requests {
+		results[i].TransactionID = request.TransactionID
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(i int, request TrailRequest) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			perform := func(opts ...CallOption) (*Transaction, error) {
+				if request.Key != "" && (c.journal == nil || c.dryRun) {
+					opts = append(opts[:len(opts):len(opts)], WithCallHeader(IdempotencyKeyHeader, request.Key))
+				}
+				return method(request.TransactionID, request.TransactionTrailDTO, opts...)
+			}
+			if c.journal == nil || c.dryRun {
+				results[i].Transaction, results[i].Err = perform(opts...)
+				return
+			}
+			results[i].Transaction, results[i].Skipped, results[i].Err = c.journaled(ctx, op, request, perform, opts)
+		}(i, request)
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,62 @@
+package paylike
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchCapture(t *testing.T) {
+	var mu sync.Mutex
+	running, peak := 0, 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		id := strings.Split(r.URL.Path, "/")[2]
+		if id == "tx3" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":"AMOUNT_TOO_HIGH","message":"amount too high"}`))
+			return
+		}
+		w.Write([]byte(`{"transaction":{"id":"` + id + `","capturedAmount":100}}`))
+	}), WithBatchConcurrency(2))
+
+	var requests []TrailRequest
+	for _, id := range []TxID{"tx1", "tx2", "tx3", "tx4", "tx5"} {
+		requests = append(requests, TrailRequest{TransactionID: id, TransactionTrailDTO: TransactionTrailDTO{Amount: 100}})
+	}
+	results := client.BatchCapture(context.Background(), requests)
+	assert.Len(t, results, 5)
+	assert.Equal(t, 2, peak)
+	for i, result := range results {
+		assert.Equal(t, requests[i].TransactionID, result.TransactionID)
+	}
+	assert.Equal(t, int64(100), results[0].Transaction.CapturedAmount)
+	failed := results.Failed()
+	assert.Len(t, failed, 1)
+	assert.Equal(t, TxID("tx3"), failed[0].TransactionID)
+	assert.True(t, IsClientError(failed[0].Err))
+}
+
+func TestBatchCanceled(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unexpected request")
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results := client.BatchVoid(ctx, []TrailRequest{{TransactionID: "tx1"}})
+	assert.Len(t, results.Failed(), 1)
+}
@@ -0,0 +1,125 @@
+package paylike
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without performing the request when the
+// circuit breaker of the client does not allow requests
+var ErrCircuitOpen = errors.New("paylike: circuit breaker is open")
+
+// CircuitBreaker is consulted before each request attempt and informed about
+// its outcome, letting a degraded API fail requests fast
+type CircuitBreaker interface {
+	Allow() bool
+	RecordSuccess()
+	RecordFailure()
+}
+
+// WithCircuitBreaker makes the client consult the given breaker before each request attempt
+func WithCircuitBreaker(breaker CircuitBreaker) Option {
+	return func(c *Client) {
+		c.breaker = breaker
+	}
+}
+
+// CanceledRecorder is implemented by circuit breakers that need to know about
+// allowed requests cancelled before completing, e.g. to let another trial
+// request through
+type CanceledRecorder interface {
+	RecordCanceled()
+}
+
+// recordOutcome informs the circuit breaker about the outcome of an attempt
+// Client errors mean the API is healthy, while cancelled calls say nothing about it
+func (c Client) recordOutcome(err error) {
+	switch {
+	case c.breaker == nil:
+	case errors.Is(err, context.Canceled):
+		if recorder, ok := c.breaker.(CanceledRecorder); ok {
+			recorder.RecordCanceled()
+		}
+	case IsRetryable(err) || IsServerError(err):
+		c.breaker.RecordFailure()
+	default:
+		c.breaker.RecordSuccess()
+	}
+}
+
+// ConsecutiveBreaker is a CircuitBreaker opening after a number of
+// consecutive failures, letting a single trial request through once the
+// cooldown has passed
+type ConsecutiveBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	trial     bool
+	clock     Clock
+}
+
+// NewCircuitBreaker creates a breaker opening after the given number of
+// consecutive failures for the given cooldown
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *ConsecutiveBreaker {
+	return &ConsecutiveBreaker{threshold: threshold, cooldown: cooldown, clock: SystemClock}
+}
+
+// WithClock makes the breaker tell the time with the given clock, e.g. to
+// pass the cooldown in tests without waiting
+func (b *ConsecutiveBreaker) WithClock(clock Clock) *ConsecutiveBreaker {
+	b.clock = clock
+	return b
+}
+
+// Allow returns whether a request may be performed
+func (b *ConsecutiveBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.trial || b.clock.Now().Sub(b.openedAt) < b.cooldown {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+// RecordSuccess closes the breaker
+func (b *ConsecutiveBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.trial = false
+}
+
+// RecordFailure counts a failure, opening the breaker once the threshold is
+// reached or when the trial request failed
+func (b *ConsecutiveBreaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = b.clock.Now()
+		b.trial = false
+	}
+}
+
+// RecordCanceled releases the trial request, if any, so the next request
+// after a cancelled trial is let through
+func (b *ConsecutiveBreaker) RecordCanceled() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+// Open returns whether the breaker currently rejects requests
+func (b *ConsecutiveBreaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures >= b.threshold && (b.trial || b.clock.Now().Sub(b.openedAt) < b.cooldown)
+}
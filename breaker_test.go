@@ -0,0 +1,110 @@
+package paylike
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var bodies []string
+	breaker := NewCircuitBreaker(2, 20*time.Millisecond)
+	client := newStatusSequenceClient(t, []int{503, 503, 200}, &bodies, WithCircuitBreaker(breaker))
+
+	for i := 0; i < 2; i++ {
+		_, err := client.FindTransaction("tx1")
+		assert.True(t, IsServerError(err))
+	}
+	assert.True(t, breaker.Open())
+	_, err := client.FindTransaction("tx1")
+	assert.Equal(t, ErrCircuitOpen, err)
+	assert.Len(t, bodies, 2)
+
+	time.Sleep(25 * time.Millisecond)
+	_, err = client.FindTransaction("tx1")
+	assert.Nil(t, err)
+	assert.False(t, breaker.Open())
+	assert.Len(t, bodies, 3)
+}
+
+func TestCircuitBreakerIgnoresClientErrors(t *testing.T) {
+	var bodies []string
+	breaker := NewCircuitBreaker(1, time.Minute)
+	client := newStatusSequenceClient(t, []int{400}, &bodies, WithCircuitBreaker(breaker))
+	for i := 0; i < 3; i++ {
+		_, err := client.FindTransaction("tx1")
+		assert.True(t, IsClientError(err))
+	}
+	assert.False(t, breaker.Open())
+}
+
+func TestCircuitBreakerTrial(t *testing.T) {
+	breaker := NewCircuitBreaker(1, 0)
+	breaker.RecordFailure()
+	assert.True(t, breaker.Allow())
+	assert.False(t, breaker.Allow())
+	breaker.RecordFailure()
+	assert.True(t, breaker.Allow())
+	breaker.RecordSuccess()
+	assert.True(t, breaker.Allow())
+	assert.True(t, breaker.Allow())
+}
+
+func TestCircuitBreakerOpenNotRetried(t *testing.T) {
+	breaker := NewCircuitBreaker(1, time.Minute)
+	breaker.RecordFailure()
+	client := newTestClient(t, http.NotFoundHandler(), WithCircuitBreaker(breaker), WithRetryPolicy(&countingPolicy{max: 3}))
+	_, err := client.GetMerchant("m1")
+	assert.Equal(t, ErrCircuitOpen, err)
+	assert.False(t, IsRetryable(err))
+}
+
+func TestCircuitBreakerCanceledTrial(t *testing.T) {
+	breaker := NewCircuitBreaker(1, 0)
+	breaker.RecordFailure()
+	started := make(chan struct{})
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/transactions/slow" {
+			close(started)
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte(`{"transaction":{"id":"tx1"}}`))
+	}), WithCircuitBreaker(breaker))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	_, err := client.FindTransaction("slow", WithContext(ctx))
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	_, err = client.FindTransaction("tx1")
+	assert.Nil(t, err)
+	assert.False(t, breaker.Open())
+}
+
+func TestCircuitBreakerTrialAfterRateLimit(t *testing.T) {
+	breaker := NewCircuitBreaker(1, 0)
+	breaker.RecordFailure()
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"transaction":{"id":"tx1"}}`))
+	}), WithCircuitBreaker(breaker), WithRateLimiter(cancelingLimiter{}))
+
+	_, err := client.FindTransaction("tx1")
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.True(t, breaker.Allow(), "the trial has not been taken by the request stuck in the limiter")
+}
+
+// cancelingLimiter is a RateLimiter failing as if the call had been
+// cancelled while waiting
+type cancelingLimiter struct{}
+
+func (cancelingLimiter) Wait(ctx context.Context, key string) error {
+	return context.Canceled
+}
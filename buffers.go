@@ -0,0 +1,109 @@
+package paylike
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// maxPooledBufferSize keeps unusually large request bodies out of the pool
+const maxPooledBufferSize = 64 << 10
+
+// bufferPool pools the buffers request bodies are encoded into
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// requestBuffer holds an encoded request body in a pooled buffer, returned
+// to the pool once the call and every reader of the body are done with it
+type requestBuffer struct {
+	buf  *bytes.Buffer
+	refs int32
+}
+
+// newRequestBuffer encodes the given body into a pooled buffer, referenced
+// by the caller until it calls release
+func newRequestBuffer(body interface{}) (*requestBuffer, error) {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	encoder := json.NewEncoder(buf)
+	if err := encoder.Encode(body); err != nil {
+		putBuffer(buf)
+		return nil, err
+	}
+	// drop the newline terminating every encoded value, as json.Marshal does
+	buf.Truncate(buf.Len() - 1)
+	return &requestBuffer{buf: buf, refs: 1}, nil
+}
+
+// reader returns a new reader of the body, releasing its reference when closed
+func (b *requestBuffer) reader() io.ReadCloser {
+	atomic.AddInt32(&b.refs, 1)
+	return &bufferReader{Reader: bytes.NewReader(b.buf.Bytes()), buf: b}
+}
+
+// getBody returns a new reader of the body, as http.Request.GetBody does
+func (b *requestBuffer) getBody() (io.ReadCloser, error) {
+	return b.reader(), nil
+}
+
+// release drops a reference, returning the buffer to the pool once unused
+// Buffers of bodies whose readers are never closed are garbage collected
+func (b *requestBuffer) release() {
+	if atomic.AddInt32(&b.refs, -1) == 0 {
+		putBuffer(b.buf)
+	}
+}
+
+// putBuffer returns the given buffer to the pool
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// bufferReader reads a pooled request body
+type bufferReader struct {
+	*bytes.Reader
+	buf  *requestBuffer
+	once sync.Once
+}
+
+// Close releases the reader's reference to the buffer
+func (r *bufferReader) Close() error {
+	r.once.Do(r.buf.release)
+	return nil
+}
+
+// setBody encodes the given body as the body of the given request, with the
+// codec of the client if any or into a pooled buffer otherwise, returning the
+// function to call once the call is done
+func (c Client) setBody(req *http.Request, body interface{}) (func(), error) {
+	if c.codec != nil {
+		b, err := c.codec.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		req.ContentLength = int64(len(b))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(b)), nil
+		}
+		req.Body, _ = req.GetBody()
+		return func() {}, nil
+	}
+	buf, err := newRequestBuffer(body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(buf.buf.Len())
+	req.GetBody = buf.getBody
+	req.Body = buf.reader()
+	return buf.release, nil
+}
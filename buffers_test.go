@@ -0,0 +1,35 @@
+package paylike
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPooledRequestBody(t *testing.T) {
+	var bodies []string
+	client := newStatusSequenceClient(t, []int{429, 200}, &bodies, WithRetries(2))
+	dto := TransactionTrailDTO{Amount: 100, Descriptor: "<Order & Co>"}
+	for i := 0; i < 2; i++ {
+		_, err := client.CaptureTransaction("tx1", dto)
+		assert.Nil(t, err)
+	}
+	expected, _ := json.Marshal(dto)
+	assert.Equal(t, []string{string(expected), string(expected), string(expected)}, bodies)
+}
+
+func BenchmarkCaptureTransaction(b *testing.B) {
+	client := newTestClient(b, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"transaction":{"id":"tx1","capturedAmount":100}}`))
+	}))
+	dto := TransactionTrailDTO{Amount: 100, Currency: "EUR", Descriptor: "Order 1234"}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.CaptureTransaction("tx1", dto); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package paylike
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheableOperations are the reads served from the cache, if any
+var cacheableOperations = map[string]bool{
+	OpGetMerchant.Name:     true,
+	OpFetchCard.Name:       true,
+	OpFindTransaction.Name: true,
+}
+
+// Cache stores response bodies for the read cache of the client
+// Implementations backed by a shared store (e.g. RedisCache) let multiple
+// processes share cached reads and their invalidations
+type Cache interface {
+	// Get returns the unexpired value of the given key, if any
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores the given value for the given time to live
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes the value of the given key
+	Delete(ctx context.Context, key string) error
+}
+
+// WithCache serves GetMerchant, FetchCard and FindTransaction from the given
+// cache for the given time to live
+// Successful writes invalidate the cached reads of the same resource, e.g.
+// capturing a transaction invalidates the transaction
+// Failures of the cache are treated as misses and never fail a call
+func WithCache(cache Cache, ttl time.Duration) Option {
+	return func(c *Client) {
+		c.cache = cache
+		c.cacheTTL = ttl
+	}
+}
+
+// WithRevalidation keeps cached reads carrying an ETag for the given duration
+// after they went stale, revalidating them with conditional requests
+// (If-None-Match) so unchanged payloads are not transferred again
+// Has no effect unless the client has been created with WithCache
+func WithRevalidation(keep time.Duration) Option {
+	return func(c *Client) {
+		c.revalidateFor = keep
+	}
+}
+
+// SkipCache bypasses the cache for a single call, e.g. to confirm a
+// transaction's state before shipping an order
+// The fresh response still updates the cache
+func SkipCache() CallOption {
+	return func(o *callOptions) {
+		o.skipCache = true
+	}
+}
+
+// MemoryCache is a bounded in-process Cache
+type MemoryCache struct {
+	mu      sync.Mutex
+	max     int
+	clock   Clock
+	entries map[string]memoryCacheEntry
+}
+
+// memoryCacheEntry describes a cached value along with its expiration
+type memoryCacheEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryCache creates a cache holding up to the given number of entries
+func NewMemoryCache(entries int) *MemoryCache {
+	return &MemoryCache{max: entries, entries: map[string]memoryCacheEntry{}}
+}
+
+// SetClock changes how the cache tells the time to expire its entries,
+// defaults to the clock of the client it is given to (see WithClock), if any,
+// or the system clock
+func (m *MemoryCache) SetClock(clock Clock) *MemoryCache {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clock = clock
+	return m
+}
+
+// adoptClock makes the cache tell the time with the given clock unless it
+// has one already
+func (m *MemoryCache) adoptClock(clock Clock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.clock == nil {
+		m.clock = clock
+	}
+}
+
+// now returns the current time according to the clock of the cache
+// The lock of the cache must be held
+func (m *MemoryCache) now() time.Time {
+	if m.clock == nil {
+		return time.Now()
+	}
+	return m.clock.Now()
+}
+
+// Get returns the unexpired value of the given key
+func (m *MemoryCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[key]
+	if !ok || m.now().After(entry.expires) {
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+// Set stores the given value for the given time to live, evicting expired
+// entries, or the one expiring first, if the cache is full
+func (m *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.entries[key]; !ok && len(m.entries) >= m.max {
+		now := m.now()
+		var first string
+		for k, entry := range m.entries {
+			if now.After(entry.expires) {
+				delete(m.entries, k)
+			} else if first == "" || entry.expires.Before(m.entries[first].expires) {
+				first = k
+			}
+		}
+		if len(m.entries) >= m.max {
+			delete(m.entries, first)
+		}
+	}
+	m.entries[key] = memoryCacheEntry{value, m.now().Add(ttl)}
+	return nil
+}
+
+// Delete removes the value of the given key
+func (m *MemoryCache) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+	return nil
+}
+
+// redisCacheGetScript returns the value of the key wrapped in a table, or
+// an empty table if missing, as some clients report nil replies as errors
+const redisCacheGetScript = `
+local value = redis.call("GET", KEYS[1])
+if value then
+	return {value}
+end
+return {}
+`
+
+// redisCacheSetScript stores the value with an expiration in milliseconds
+const redisCacheSetScript = `return redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])`
+
+// redisCacheDeleteScript removes the key
+const redisCacheDeleteScript = `return redis.call("DEL", KEYS[1])`
+
+// RedisCache is a Cache shared between all processes connected to the same Redis
+type RedisCache struct {
+	redis  RedisEvaler
+	prefix string
+}
+
+// NewRedisCache creates a cache storing its values in Redis
+func NewRedisCache(redis RedisEvaler) *RedisCache {
+	return &RedisCache{redis: redis, prefix: "paylike:cache"}
+}
+
+// SetPrefix changes the prefix of the Redis keys, defaults to "paylike:cache"
+func (r *RedisCache) SetPrefix(prefix string) *RedisCache {
+	r.prefix = prefix
+	return r
+}
+
+// Get returns the value of the given key, if any
+func (r *RedisCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	result, err := r.redis.Eval(ctx, redisCacheGetScript, []string{r.prefix + ":" + key})
+	if err != nil {
+		return nil, false, err
+	}
+	values, ok := result.([]interface{})
+	if !ok || len(values) == 0 {
+		return nil, false, nil
+	}
+	switch value := values[0].(type) {
+	case string:
+		return []byte(value), true, nil
+	case []byte:
+		return value, true, nil
+	}
+	return nil, false, fmt.Errorf("paylike: unexpected result %T from redis", values[0])
+}
+
+// Set stores the given value for the given time to live
+func (r *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	_, err := r.redis.Eval(ctx, redisCacheSetScript, []string{r.prefix + ":" + key}, string(value), ttl.Milliseconds())
+	return err
+}
+
+// Delete removes the value of the given key
+func (r *RedisCache) Delete(ctx context.Context, key string) error {
+	_, err := r.redis.Eval(ctx, redisCacheDeleteScript, []string{r.prefix + ":" + key})
+	return err
+}
+
+// cacheKey returns the cache key of the given path of the given request,
+// scoped to the API key of the client, which is hashed so no secrets end up
+// in the cache, and to the base URL the request is sent to
+func (c Client) cacheKey(req *http.Request, path string) string {
+	sum := sha256.Sum256([]byte(c.Key))
+	return hex.EncodeToString(sum[:8]) + req.URL.Scheme + "://" + req.URL.Host + path
+}
+
+// cacheEntry describes a cached response body along with its validator
+type cacheEntry struct {
+	ETag  string          `json:"etag,omitempty"`
+	Fresh time.Time       `json:"fresh"` // served without revalidation until then
+	Body  json.RawMessage `json:"body"`
+}
+
+// loadCacheEntry returns the cached entry of the given request, if any
+func (c Client) loadCacheEntry(req *http.Request) (*cacheEntry, bool) {
+	b, ok, err := c.cache.Get(req.Context(), c.cacheKey(req, req.URL.Path))
+	if err != nil || !ok {
+		return nil, false
+	}
+	var entry cacheEntry
+	if json.Unmarshal(b, &entry) != nil {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// storeCacheEntry caches the given response body of the given request,
+// keeping it for revalidation after it went stale if it has an ETag
+func (c Client) storeCacheEntry(req *http.Request, etag string, body []byte) {
+	entry := cacheEntry{ETag: etag, Fresh: c.now().Add(c.cacheTTL), Body: body}
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	ttl := c.cacheTTL
+	if etag != "" {
+		ttl += c.revalidateFor
+	}
+	c.cache.Set(req.Context(), c.cacheKey(req, req.URL.Path), b, ttl)
+}
+
+// fromCache decodes the fresh cached response of the given request into the
+// given value, reporting whether the cache held one
+// Stale responses with an ETag make the request conditional instead
+func (c Client) fromCache(req *http.Request, op Operation, value interface{}) (bool, error) {
+	if c.cache == nil || c.call.skipCache || !cacheableOperations[op.Name] {
+		return false, nil
+	}
+	entry, ok := c.loadCacheEntry(req)
+	if !ok {
+		return false, nil
+	}
+	if c.now().Before(entry.Fresh) {
+		return true, c.decode(op, bytes.NewReader(entry.Body), value)
+	}
+	if entry.ETag != "" {
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+	return false, nil
+}
+
+// updateCache caches the successful response body of a cacheable read, or
+// invalidates the reads of the resource written to by any other request
+// Not modified responses to conditional requests refresh the cached body
+// It returns a reader of the response body
+func (c Client) updateCache(req *http.Request, op Operation, resp *http.Response) (io.Reader, error) {
+	if c.cache == nil {
+		return resp.Body, nil
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		entry, ok := c.loadCacheEntry(req)
+		if !ok {
+			return nil, errors.New("paylike: cached response evicted during revalidation")
+		}
+		c.storeCacheEntry(req, entry.ETag, entry.Body)
+		return bytes.NewReader(entry.Body), nil
+	}
+	if cacheableOperations[op.Name] {
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		c.storeCacheEntry(req, resp.Header.Get("ETag"), b)
+		return bytes.NewReader(b), nil
+	}
+	if req.Method != http.MethodGet {
+		for path := req.URL.Path; path != ""; path = path[:strings.LastIndex(path, "/")] {
+			c.cache.Delete(req.Context(), c.cacheKey(req, path))
+		}
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,155 @@
+package paylike
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	var requests []string
+	captured := 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == "POST" {
+			captured = 100
+		}
+		fmt.Fprintf(w, `{"transaction":{"id":"tx1","capturedAmount":%d}}`, captured)
+	}), WithCache(NewMemoryCache(10), time.Minute))
+
+	transaction, err := client.FindTransaction("tx1")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), transaction.CapturedAmount)
+	transaction, err = client.FindTransaction("tx1")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), transaction.CapturedAmount)
+	assert.Len(t, requests, 1)
+
+	_, err = client.FindTransaction("tx1", SkipCache())
+	assert.Nil(t, err)
+	assert.Len(t, requests, 2)
+
+	_, err = client.CaptureTransaction("tx1", TransactionTrailDTO{Amount: 100})
+	assert.Nil(t, err)
+	transaction, err = client.FindTransaction("tx1")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(100), transaction.CapturedAmount)
+	assert.Equal(t, []string{
+		"GET /transactions/tx1",
+		"GET /transactions/tx1",
+		"POST /transactions/tx1/captures",
+		"GET /transactions/tx1",
+	}, requests)
+
+	other := *client
+	other.Key = "other"
+	_, err = other.FindTransaction("tx1")
+	assert.Nil(t, err)
+	assert.Len(t, requests, 5)
+}
+
+func TestMemoryCache(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryCache(2)
+	cache.Set(ctx, "a", []byte("1"), time.Minute)
+	cache.Set(ctx, "b", []byte("2"), time.Hour)
+	cache.Set(ctx, "c", []byte("3"), -time.Second)
+	_, ok, _ := cache.Get(ctx, "a")
+	assert.False(t, ok)
+	_, ok, _ = cache.Get(ctx, "c")
+	assert.False(t, ok)
+	value, ok, _ := cache.Get(ctx, "b")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("2"), value)
+	cache.Delete(ctx, "b")
+	_, ok, _ = cache.Get(ctx, "b")
+	assert.False(t, ok)
+}
+
+// fakeRedisCache mimics the cache scripts on top of an in-memory map
+type fakeRedisCache struct {
+	values map[string]string
+}
+
+func (f *fakeRedisCache) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	switch script {
+	case redisCacheGetScript:
+		if value, ok := f.values[keys[0]]; ok {
+			return []interface{}{value}, nil
+		}
+		return []interface{}{}, nil
+	case redisCacheSetScript:
+		f.values[keys[0]] = args[0].(string)
+		return "OK", nil
+	case redisCacheDeleteScript:
+		delete(f.values, keys[0])
+		return int64(1), nil
+	}
+	return nil, errors.New("unknown script")
+}
+
+func TestRedisCache(t *testing.T) {
+	redis := &fakeRedisCache{values: map[string]string{}}
+	requests := 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"card":{"id":"c1"}}`))
+	}), WithCache(NewRedisCache(redis), time.Minute))
+
+	for i := 0; i < 2; i++ {
+		card, err := client.FetchCard("c1")
+		assert.Nil(t, err)
+		assert.Equal(t, CardToken("c1"), card.ID)
+	}
+	assert.Equal(t, 1, requests)
+	assert.Len(t, redis.values, 1)
+	for key := range redis.values {
+		assert.True(t, strings.HasPrefix(key, "paylike:cache:"))
+		assert.True(t, strings.HasSuffix(key, "/cards/c1"))
+		assert.NotContains(t, key, TestKey)
+	}
+}
+
+func TestCacheFailures(t *testing.T) {
+	redis := RedisEvalFunc(func(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+		return nil, errors.New("redis down")
+	})
+	requests := 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"card":{"id":"c1"}}`))
+	}), WithCache(NewRedisCache(redis), time.Minute))
+	_, err := client.FetchCard("c1")
+	assert.Nil(t, err)
+	_, err = client.FetchCard("c1")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, requests)
+}
+
+func TestCacheRevalidation(t *testing.T) {
+	var conditions []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditions = append(conditions, r.Header.Get("If-None-Match"))
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"merchant":{"id":"m1","name":"Shop"}}`))
+	}), WithCache(NewMemoryCache(10), time.Millisecond), WithRevalidation(time.Minute))
+
+	merchant, err := client.GetMerchant("m1")
+	assert.Nil(t, err)
+	assert.Equal(t, "Shop", merchant.Name)
+	time.Sleep(5 * time.Millisecond)
+	merchant, err = client.GetMerchant("m1")
+	assert.Nil(t, err)
+	assert.Equal(t, "Shop", merchant.Name)
+	assert.Equal(t, []string{"", `"v1"`}, conditions)
+}
@@ -0,0 +1,77 @@
+package paylike
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// DefaultTimeout is the timeout applied to requests unless configured otherwise
+const DefaultTimeout = 30 * time.Second
+
+// CallOption configures a single call of a client method
+type CallOption func(*callOptions)
+
+// callOptions describes the configuration of a single call
+type callOptions struct {
+	ctx       context.Context
+	timeout   time.Duration
+	staleness *Staleness
+	header    http.Header
+	skipCache bool
+	list      *listInfo
+}
+
+// WithContext performs the call within the given context, cancelling the
+// request when the context is done
+func WithContext(ctx context.Context) CallOption {
+	return func(o *callOptions) {
+		o.ctx = ctx
+	}
+}
+
+// WithTimeout overrides the client's default timeout for a single call,
+// e.g. to give captures a tighter deadline than bulk listings
+// The timeout applies in addition to any deadline of the call's context
+func WithTimeout(timeout time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithCallHeader sets the given header on the request of a single call,
+// overriding the headers set with WithHeader
+func WithCallHeader(name, value string) CallOption {
+	return func(o *callOptions) {
+		header := o.header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		header.Set(name, value)
+		o.header = header
+	}
+}
+
+// WithDefaultTimeout changes the timeout applied to every request of the
+// client, zero disables it
+func WithDefaultTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// with returns a copy of the client configured with the given call options
+func (c Client) with(opts []CallOption) Client {
+	for _, opt := range opts {
+		opt(&c.call)
+	}
+	return c
+}
+
+// requestTimeout returns the timeout of the current call
+func (c Client) requestTimeout() time.Duration {
+	if c.call.timeout > 0 {
+		return c.call.timeout
+	}
+	return c.timeout
+}
@@ -0,0 +1,69 @@
+package paylike
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newSlowTestClient(t *testing.T, delay time.Duration, opts ...Option) *Client {
+	return newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+		}
+	}), opts...)
+}
+
+func TestCallTimeout(t *testing.T) {
+	client := newSlowTestClient(t, 100*time.Millisecond)
+	assert.Equal(t, DefaultTimeout, client.timeout)
+
+	_, err := client.CaptureTransaction("tx1", TransactionTrailDTO{Amount: 1}, WithTimeout(10*time.Millisecond))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	_, err = client.FindTransaction("tx1")
+	assert.Nil(t, err)
+}
+
+func TestDefaultTimeout(t *testing.T) {
+	client := newSlowTestClient(t, 100*time.Millisecond, WithDefaultTimeout(10*time.Millisecond))
+	_, err := client.ListTransactions(TestMerchant, 10)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	_, err = client.ListTransactions(TestMerchant, 10, WithTimeout(time.Second))
+	assert.Nil(t, err)
+}
+
+func TestCallContext(t *testing.T) {
+	client := newSlowTestClient(t, time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, err := client.FindTransaction("tx1", WithContext(ctx))
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestHeaders(t *testing.T) {
+	var headers []http.Header
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header)
+	}), WithHeader("X-Tenant", "t1"), WithHeader("X-Gateway", "g1"))
+
+	_, err := client.FindTransaction("tx1", WithCallHeader("X-Correlation-Id", "c1"), WithCallHeader("X-Tenant", "t2"))
+	assert.Nil(t, err)
+	_, err = client.FindTransaction("tx1")
+	assert.Nil(t, err)
+
+	assert.Equal(t, "t2", headers[0].Get("X-Tenant"))
+	assert.Equal(t, "g1", headers[0].Get("X-Gateway"))
+	assert.Equal(t, "c1", headers[0].Get("X-Correlation-Id"))
+	assert.Equal(t, "t1", headers[1].Get("X-Tenant"))
+	assert.Equal(t, "", headers[1].Get("X-Correlation-Id"))
+}
@@ -0,0 +1,107 @@
+package paylike
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidExpiry is returned when a card expiry cannot be parsed
+var ErrInvalidExpiry = errors.New("paylike: invalid card expiry")
+
+// CardExpiry describes the last month a card is valid in
+type CardExpiry struct {
+	Month time.Month
+	Year  int
+}
+
+// ParseCardExpiry parses a card expiry as returned by the API
+// (e.g. 2016-12-31T23:59:59.999Z) or as printed on cards (12/16 or 12/2016)
+func ParseCardExpiry(s string) (CardExpiry, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		t = t.UTC()
+		return CardExpiry{Month: t.Month(), Year: t.Year()}, nil
+	}
+	var month, year int
+	var rest string
+	if n, _ := fmt.Sscanf(s, "%d/%d%s", &month, &year, &rest); n != 2 || month < 1 || month > 12 {
+		return CardExpiry{}, ErrInvalidExpiry
+	}
+	switch {
+	case year >= 0 && year < 100:
+		year += 2000
+	case year < 1000 || year > 9999:
+		return CardExpiry{}, ErrInvalidExpiry
+	}
+	return CardExpiry{Month: time.Month(month), Year: year}, nil
+}
+
+// End returns the first instant (UTC) the card is no longer valid at
+func (e CardExpiry) End() time.Time {
+	return time.Date(e.Year, e.Month+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// String returns the expiry as printed on cards (MM/YY)
+func (e CardExpiry) String() string {
+	return fmt.Sprintf("%02d/%02d", int(e.Month), e.Year%100)
+}
+
+// ExpiryDate parses the expiry of the card
+func (c TransactionCard) ExpiryDate() (CardExpiry, error) {
+	return ParseCardExpiry(c.Expiry)
+}
+
+// IsExpired reports whether the card has expired at the given time
+// Cards with an unknown expiry are not considered expired
+func (c TransactionCard) IsExpired(at time.Time) bool {
+	expiry, err := c.ExpiryDate()
+	return err == nil && !at.Before(expiry.End())
+}
+
+// ExpiresWithin reports whether the card expires within the given duration
+// from the current time of the given clock (e.g. SystemClock), including
+// cards that have already expired
+func (c TransactionCard) ExpiresWithin(clock Clock, d time.Duration) bool {
+	return c.IsExpired(clock.Now().Add(d))
+}
+
+// Fingerprint identifies the card by its BIN, last four digits and expiry
+// month, as cards with equal fingerprints are most likely the same card
+// Cards with an unknown expiry are fingerprinted by the reported expiry
+func (c TransactionCard) Fingerprint() string {
+	expiry := c.Expiry
+	if e, err := c.ExpiryDate(); err == nil {
+		expiry = e.String()
+	}
+	return c.Bin + "/" + c.Last4 + "/" + expiry
+}
+
+// DuplicateCards groups the given saved cards having equal fingerprints
+// (see TransactionCard.Fingerprint) per merchant, e.g. to clean up cards
+// customers saved again, returning only the groups of more than one card
+// Groups are ordered by their first card and cards keep their given order
+func DuplicateCards(cards []*Card) [][]*Card {
+	type key struct {
+		merchantID  MerchantID
+		fingerprint string
+	}
+	index := map[key]int{}
+	var groups [][]*Card
+	for _, card := range cards {
+		k := key{card.MerchantID, card.Fingerprint()}
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], card)
+	}
+	var duplicates [][]*Card
+	for _, group := range groups {
+		if len(group) > 1 {
+			duplicates = append(duplicates, group)
+		}
+	}
+	return duplicates
+}
@@ -0,0 +1,95 @@
+package paylike
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCardExpiry(t *testing.T) {
+	for s, expected := range map[string]CardExpiry{
+		"2016-12-31T23:59:59.999Z": {Month: time.December, Year: 2016},
+		"2025-03-31T23:59:59Z":     {Month: time.March, Year: 2025},
+		"03/25":                    {Month: time.March, Year: 2025},
+		"3/2031":                   {Month: time.March, Year: 2031},
+	} {
+		expiry, err := ParseCardExpiry(s)
+		assert.Nil(t, err, s)
+		assert.Equal(t, expected, expiry, s)
+	}
+	for _, s := range []string{"", "13/25", "00/25", "12/25x", "12/123", "tomorrow"} {
+		_, err := ParseCardExpiry(s)
+		assert.Equal(t, ErrInvalidExpiry, err, s)
+	}
+	assert.Equal(t, "03/25", CardExpiry{Month: time.March, Year: 2025}.String())
+}
+
+func TestCardIsExpired(t *testing.T) {
+	var card Card
+	assert.Nil(t, json.Unmarshal([]byte(`{"id":"c1","expiry":"2024-02-29T23:59:59.999Z"}`), &card))
+	assert.False(t, card.IsExpired(time.Date(2024, 2, 29, 23, 59, 59, 0, time.UTC)))
+	assert.True(t, card.IsExpired(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)))
+
+	clock := ClockFunc(func() time.Time { return time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC) })
+	card.Expiry = "02/24"
+	assert.False(t, card.ExpiresWithin(clock, 0))
+	assert.True(t, card.ExpiresWithin(clock, 62*24*time.Hour))
+
+	card.Expiry = ""
+	assert.False(t, card.IsExpired(time.Now()))
+}
+
+func TestUpdateCard(t *testing.T) {
+	notes := "first card"
+	var card map[string]interface{}
+	fetches := 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			assert.Equal(t, "/cards/c1", r.URL.Path)
+			json.NewDecoder(r.Body).Decode(&card)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		fetches++
+		json.NewEncoder(w).Encode(map[string]interface{}{"card": map[string]interface{}{"id": "c1", "notes": notes}})
+	}), WithCache(NewMemoryCache(10), time.Minute))
+
+	fetched, err := client.FetchCard("c1")
+	assert.Nil(t, err)
+	assert.Equal(t, "first card", fetched.Notes)
+
+	notes = "customer 42"
+	err = client.UpdateCard("c1", CardUpdateDTO{Notes: &notes, Custom: map[string]interface{}{"customer": "42"}})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"notes": "customer 42", "custom": map[string]interface{}{"customer": "42"}}, card)
+
+	fetched, err = client.FetchCard("c1")
+	assert.Nil(t, err)
+	assert.Equal(t, "customer 42", fetched.Notes)
+	assert.Equal(t, 2, fetches)
+}
+
+func TestDuplicateCards(t *testing.T) {
+	card := func(id CardToken, merchantID MerchantID, last4, expiry string) *Card {
+		c := &Card{MerchantID: merchantID}
+		c.ID = id
+		c.Bin = "410000"
+		c.Last4 = last4
+		c.Expiry = expiry
+		return c
+	}
+	cards := []*Card{
+		card("c1", "m1", "0000", "2030-12-31T23:59:59.999Z"),
+		card("c2", "m1", "1111", "2030-12-31T23:59:59.999Z"),
+		card("c3", "m1", "0000", "2030-12-31T23:59:59.999Z"),
+		card("c4", "m2", "0000", "2030-12-31T23:59:59.999Z"),
+		card("c5", "m1", "0000", "2031-12-31T23:59:59.999Z"),
+		card("c6", "m1", "0000", "12/30"),
+	}
+	assert.Equal(t, "410000/0000/12/30", cards[0].Fingerprint())
+	assert.Equal(t, [][]*Card{{cards[0], cards[2], cards[5]}}, DuplicateCards(cards))
+	assert.Empty(t, DuplicateCards(cards[:2]))
+}
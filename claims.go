@@ -0,0 +1,86 @@
+package paylike
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Claim describes a permission granted to a merchant
+type Claim string
+
+// Possible merchant claims
+const (
+	ClaimChargeCard     Claim = "canChargeCard"
+	ClaimSaveCard       Claim = "canSaveCard"
+	ClaimTransferToCard Claim = "canTransferToCard"
+	ClaimCapture        Claim = "canCapture"
+	ClaimRefund         Claim = "canRefund"
+	ClaimVoid           Claim = "canVoid"
+)
+
+// Has reports whether the given claim is granted
+func (c MerchantClaim) Has(claim Claim) bool {
+	switch claim {
+	case ClaimChargeCard:
+		return c.CanChargeCard
+	case ClaimSaveCard:
+		return c.CanSaveCard
+	case ClaimTransferToCard:
+		return c.CanTransferToCard
+	case ClaimCapture:
+		return c.CanCapture
+	case ClaimRefund:
+		return c.CanRefund
+	case ClaimVoid:
+		return c.CanVoid
+	}
+	return false
+}
+
+// CanChargeCard reports whether the merchant may charge cards
+func (m Merchant) CanChargeCard() bool { return m.Claim.CanChargeCard }
+
+// CanSaveCard reports whether the merchant may save cards
+func (m Merchant) CanSaveCard() bool { return m.Claim.CanSaveCard }
+
+// CanTransferToCard reports whether the merchant may transfer money to cards
+func (m Merchant) CanTransferToCard() bool { return m.Claim.CanTransferToCard }
+
+// CanCapture reports whether the merchant may capture transactions
+func (m Merchant) CanCapture() bool { return m.Claim.CanCapture }
+
+// CanRefund reports whether the merchant may refund transactions
+func (m Merchant) CanRefund() bool { return m.Claim.CanRefund }
+
+// CanVoid reports whether the merchant may void transactions
+func (m Merchant) CanVoid() bool { return m.Claim.CanVoid }
+
+// MissingClaimsError describes the claims a merchant lacks
+type MissingClaimsError struct {
+	MerchantID MerchantID
+	Claims     []Claim
+}
+
+// Error lists the missing claims
+func (e *MissingClaimsError) Error() string {
+	names := make([]string, len(e.Claims))
+	for i, claim := range e.Claims {
+		names[i] = string(claim)
+	}
+	return fmt.Sprintf("paylike: merchant %s lacks claims %s", e.MerchantID, strings.Join(names, ", "))
+}
+
+// RequireClaims returns a *MissingClaimsError listing the given claims the
+// merchant lacks, if any
+func (m Merchant) RequireClaims(claims ...Claim) error {
+	var missing []Claim
+	for _, claim := range claims {
+		if !m.Claim.Has(claim) {
+			missing = append(missing, claim)
+		}
+	}
+	if len(missing) > 0 {
+		return &MissingClaimsError{MerchantID: m.ID, Claims: missing}
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package paylike
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerchantClaims(t *testing.T) {
+	var merchant Merchant
+	assert.Nil(t, json.Unmarshal([]byte(`{"id":"m1","claim":{"canCapture":true,"canRefund":true}}`), &merchant))
+	assert.True(t, merchant.CanCapture())
+	assert.True(t, merchant.CanRefund())
+	assert.False(t, merchant.CanVoid())
+	assert.False(t, merchant.Claim.Has("unknown"))
+
+	assert.Nil(t, merchant.RequireClaims(ClaimCapture, ClaimRefund))
+	err := merchant.RequireClaims(ClaimCapture, ClaimVoid, ClaimSaveCard)
+	var claimsErr *MissingClaimsError
+	assert.True(t, errors.As(err, &claimsErr))
+	assert.Equal(t, []Claim{ClaimVoid, ClaimSaveCard}, claimsErr.Claims)
+	assert.Equal(t, "paylike: merchant m1 lacks claims canVoid, canSaveCard", err.Error())
+}
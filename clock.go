@@ -0,0 +1,68 @@
+package paylike
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+)
+
+// Clock tells the current time, replaceable for deterministic tests
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc is an adapter to allow the use of ordinary functions as Clock
+type ClockFunc func() time.Time
+
+// Now calls f()
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock is the Clock telling the time of the system
+var SystemClock Clock = ClockFunc(time.Now)
+
+// IDGenerator generates unique IDs, e.g. idempotency keys, replaceable for
+// deterministic tests
+type IDGenerator interface {
+	NewID() string
+}
+
+// IDGeneratorFunc is an adapter to allow the use of ordinary functions as IDGenerator
+type IDGeneratorFunc func() string
+
+// NewID calls f()
+func (f IDGeneratorFunc) NewID() string {
+	return f()
+}
+
+// RandomIDs is the IDGenerator generating random (version 4) UUIDs
+var RandomIDs IDGenerator = IDGeneratorFunc(newUUID)
+
+// newUUID generates a random (version 4) UUID
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("paylike: reading random bytes: %v", err))
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// WithClock makes the client tell the time with the given clock, e.g. when
+// deciding whether cached or remembered values are fresh, including the
+// expiry of a MemoryCache given to WithCache without a clock of its own
+func WithClock(clock Clock) Option {
+	return func(c *Client) {
+		c.clock = clock
+	}
+}
+
+// now returns the current time according to the clock of the client
+func (c Client) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clock.Now()
+}
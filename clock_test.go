@@ -0,0 +1,99 @@
+package paylike
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock is a Clock only moving when told to
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestRandomIDs(t *testing.T) {
+	id := RandomIDs.NewID()
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), id)
+	assert.NotEqual(t, id, RandomIDs.NewID())
+}
+
+func TestBreakerWithClock(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	breaker := NewCircuitBreaker(1, time.Minute).WithClock(clock)
+	breaker.RecordFailure()
+	assert.True(t, breaker.Open())
+	clock.now = clock.now.Add(time.Minute)
+	assert.False(t, breaker.Open())
+	assert.True(t, breaker.Allow())
+}
+
+func TestCacheWithClock(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	requests := 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(`{"transaction":{"id":"tx1"}}`))
+	}), WithCache(NewMemoryCache(10), time.Minute), WithRevalidation(time.Hour), WithClock(clock))
+
+	for i := 0; i < 2; i++ {
+		_, err := client.FindTransaction("tx1")
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 1, requests)
+	clock.now = clock.now.Add(time.Minute)
+	_, err := client.FindTransaction("tx1")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, requests)
+
+	// the memory cache expires the entry by the clock of the client too
+	conditional := 0
+	client = newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			conditional++
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"transaction":{"id":"tx1"}}`))
+	}), WithCache(NewMemoryCache(10), time.Minute), WithRevalidation(time.Hour), WithClock(clock))
+	_, err = client.FindTransaction("tx1")
+	assert.Nil(t, err)
+	clock.now = clock.now.Add(2 * time.Hour)
+	_, err = client.FindTransaction("tx1")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, conditional)
+}
+
+func TestMemoryCacheClock(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	cache := NewMemoryCache(2).SetClock(clock)
+	cache.Set(ctx, "a", []byte("1"), time.Minute)
+	_, ok, _ := cache.Get(ctx, "a")
+	assert.True(t, ok)
+	clock.now = clock.now.Add(time.Minute + time.Second)
+	_, ok, _ = cache.Get(ctx, "a")
+	assert.False(t, ok)
+}
+
+func TestPingWithClock(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clock.now = clock.now.Add(250 * time.Millisecond)
+		w.Write([]byte(`{"identity":{"id":"app1"}}`))
+	}), WithClock(clock))
+	health, err := client.Ping(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 250*time.Millisecond, health.Latency)
+}
@@ -0,0 +1,297 @@
+// Command paylike performs common operations against the Paylike API, e.g.
+// listing merchants and transactions, capturing, refunding and voiding
+// transactions and exporting the balance lines of a merchant
+//
+// The API key is read from the PAYLIKE_KEY environment variable
+//
+//	paylike merchants list
+//	paylike tx list -merchant <merchantId>
+//	paylike tx capture -amount 1000 <transactionId>
+//	paylike lines export -merchant <merchantId> -csv > lines.csv
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	paylike "github.com/paylike/go-api"
+)
+
+const usage = `usage: paylike <command> <subcommand> [flags]
+
+commands:
+  merchants list          list the merchants of the app
+  tx list                 list the transactions of a merchant
+  tx capture|refund|void  capture, refund or void a transaction
+  lines export            export the balance lines of a merchant
+
+environment:
+  PAYLIKE_KEY  API key of the app (required)
+  PAYLIKE_URL  URL of the API (optional)
+
+Run "paylike <command> <subcommand> -h" for the flags of a subcommand
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Getenv, os.Stdout, os.Stderr))
+}
+
+// errUsage is returned when the command line cannot be understood
+var errUsage = errors.New("invalid usage")
+
+// cli runs the commands with a client, writing their results to stdout
+type cli struct {
+	client *paylike.Client
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// run runs the command described by the given arguments, returning the
+// exit code of the process
+func run(args []string, getenv func(string) string, stdout, stderr io.Writer) int {
+	if len(args) < 2 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	key := getenv("PAYLIKE_KEY")
+	if key == "" {
+		fmt.Fprintln(stderr, "paylike: PAYLIKE_KEY is not set")
+		return 2
+	}
+	var opts []paylike.Option
+	if url := getenv("PAYLIKE_URL"); url != "" {
+		opts = append(opts, paylike.WithBaseURL(url))
+	}
+	c := cli{client: paylike.NewClient(key, opts...), stdout: stdout, stderr: stderr}
+
+	var err error
+	switch command := args[0] + " " + args[1]; command {
+	case "merchants list":
+		err = c.listMerchants(args[2:])
+	case "tx list":
+		err = c.listTransactions(args[2:])
+	case "tx capture", "tx refund", "tx void":
+		err = c.trail(args[1], args[2:])
+	case "lines export":
+		err = c.exportLines(args[2:])
+	default:
+		fmt.Fprintf(stderr, "paylike: unknown command %q\n\n%s", command, usage)
+		return 2
+	}
+	switch {
+	case errors.Is(err, flag.ErrHelp), errors.Is(err, errUsage):
+		return 2
+	case err != nil:
+		fmt.Fprintln(stderr, "paylike:", err)
+		return 1
+	}
+	return 0
+}
+
+// flags creates the flag set of a subcommand, along with its output flag
+func (c cli) flags(name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet("paylike "+name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	output := fs.String("output", "table", "output format, table or json")
+	return fs, output
+}
+
+// parse parses the arguments of a subcommand, expecting the given number of
+// positional arguments, and checks the output flag once parsed
+func (c cli) parse(fs *flag.FlagSet, args []string, output *string, positional int) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != positional {
+		fmt.Fprintf(c.stderr, "paylike: %s expects %d argument(s)\n", fs.Name(), positional)
+		fs.Usage()
+		return errUsage
+	}
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(c.stderr, "paylike: unknown output format %q\n", *output)
+		return errUsage
+	}
+	return nil
+}
+
+// warnTruncated tells on stderr when a listing stopped at its limit
+func (c cli) warnTruncated(err error, limit int) {
+	if errors.Is(err, paylike.ErrPaginationLimit) {
+		fmt.Fprintf(c.stderr, "paylike: output truncated at %d items, raise -limit to see more\n", limit)
+	}
+}
+
+// listMerchants lists the merchants of the app of the key
+func (c cli) listMerchants(args []string) error {
+	fs, output := c.flags("merchants list")
+	limit := fs.Int("limit", paylike.DefaultMaxItems, "maximum number of merchants")
+	if err := c.parse(fs, args, output, 0); err != nil {
+		return err
+	}
+	app, err := c.client.FetchApp()
+	if err != nil {
+		return err
+	}
+	merchants, err := c.client.FetchAllMerchants(app.ID, paylike.Pagination{MaxItems: *limit})
+	if err != nil && !errors.Is(err, paylike.ErrPaginationLimit) {
+		return err
+	}
+	c.warnTruncated(err, *limit)
+	if *output == "json" {
+		return c.writeJSON(merchants)
+	}
+	rows := [][]string{{"ID", "NAME", "CURRENCY", "TEST", "CREATED"}}
+	for _, m := range merchants {
+		rows = append(rows, []string{string(m.ID), m.Name, m.Currency, strconv.FormatBool(m.Test), m.Created})
+	}
+	return c.writeTable(rows)
+}
+
+// listTransactions lists the transactions of a merchant
+func (c cli) listTransactions(args []string) error {
+	fs, output := c.flags("tx list")
+	merchantID := fs.String("merchant", "", "ID of the merchant (required)")
+	limit := fs.Int("limit", paylike.DefaultMaxItems, "maximum number of transactions")
+	if err := c.parse(fs, args, output, 0); err != nil {
+		return err
+	}
+	if *merchantID == "" {
+		fmt.Fprintln(c.stderr, "paylike: -merchant is required")
+		return errUsage
+	}
+	transactions, err := c.client.ListAllTransactions(paylike.MerchantID(*merchantID), paylike.Pagination{MaxItems: *limit})
+	if err != nil && !errors.Is(err, paylike.ErrPaginationLimit) {
+		return err
+	}
+	c.warnTruncated(err, *limit)
+	if *output == "json" {
+		return c.writeJSON(transactions)
+	}
+	rows := [][]string{transactionHeader}
+	for _, t := range transactions {
+		rows = append(rows, transactionRow(t))
+	}
+	return c.writeTable(rows)
+}
+
+// trail captures, refunds or voids a transaction
+func (c cli) trail(kind string, args []string) error {
+	fs, output := c.flags("tx " + kind)
+	amount := fs.Int64("amount", 0, "amount in minor units (required)")
+	currency := fs.String("currency", "", "expected currency of the transaction")
+	descriptor := fs.String("descriptor", "", "text on the bank statement")
+	if err := c.parse(fs, args, output, 1); err != nil {
+		return err
+	}
+	if *amount <= 0 {
+		fmt.Fprintln(c.stderr, "paylike: -amount must be positive")
+		return errUsage
+	}
+	method := c.client.CaptureTransaction
+	switch kind {
+	case "refund":
+		method = c.client.RefundTransaction
+	case "void":
+		method = c.client.VoidTransaction
+	}
+	transaction, err := method(paylike.TxID(fs.Arg(0)), paylike.TransactionTrailDTO{
+		Amount:     *amount,
+		Currency:   *currency,
+		Descriptor: *descriptor,
+	})
+	if err != nil {
+		return err
+	}
+	if *output == "json" {
+		return c.writeJSON(transaction)
+	}
+	return c.writeTable([][]string{transactionHeader, transactionRow(transaction)})
+}
+
+// exportLines exports the balance lines of a merchant
+func (c cli) exportLines(args []string) error {
+	fs, output := c.flags("lines export")
+	merchantID := fs.String("merchant", "", "ID of the merchant (required)")
+	limit := fs.Int("limit", paylike.DefaultMaxItems, "maximum number of lines")
+	asCSV := fs.Bool("csv", false, "write the lines as CSV, overriding -output")
+	if err := c.parse(fs, args, output, 0); err != nil {
+		return err
+	}
+	if *merchantID == "" {
+		fmt.Fprintln(c.stderr, "paylike: -merchant is required")
+		return errUsage
+	}
+	header := []string{"ID", "CREATED", "TRANSACTION", "AMOUNT", "CURRENCY", "BALANCE", "FEE", "REFUND", "TEST"}
+	row := func(l *paylike.Line) []string {
+		return []string{
+			l.ID, l.Created, string(l.TransactionID),
+			strconv.FormatInt(l.Amount.Amount, 10), l.Amount.Currency,
+			strconv.FormatInt(l.Balance, 10), strconv.FormatInt(l.Fee, 10),
+			strconv.FormatBool(l.Refund), strconv.FormatBool(l.Test),
+		}
+	}
+	p := paylike.Pagination{MaxItems: *limit}
+	if *asCSV {
+		w := paylike.NewLinesCSVWriter(c.stdout)
+		err := c.client.EachLineToMerchant(paylike.MerchantID(*merchantID), p, w.Write)
+		if err != nil && !errors.Is(err, paylike.ErrPaginationLimit) {
+			w.Flush()
+			return err
+		}
+		c.warnTruncated(err, *limit)
+		return w.Flush()
+	}
+	lines, err := c.client.FetchAllLinesToMerchant(paylike.MerchantID(*merchantID), p)
+	if err != nil && !errors.Is(err, paylike.ErrPaginationLimit) {
+		return err
+	}
+	c.warnTruncated(err, *limit)
+	if *output == "json" {
+		return c.writeJSON(lines)
+	}
+	rows := [][]string{header}
+	for _, l := range lines {
+		rows = append(rows, row(l))
+	}
+	return c.writeTable(rows)
+}
+
+// transactionHeader is the header of the table of transactions
+var transactionHeader = []string{"ID", "CREATED", "AMOUNT", "CURRENCY", "CAPTURED", "REFUNDED", "VOIDED", "STATUS"}
+
+// transactionRow returns the row of the given transaction in the table of transactions
+func transactionRow(t *paylike.Transaction) []string {
+	return []string{
+		string(t.ID), t.Created, strconv.FormatInt(t.Amount, 10), t.Currency,
+		strconv.FormatInt(t.CapturedAmount, 10), strconv.FormatInt(t.RefundedAmount, 10), strconv.FormatInt(t.VoidedAmount, 10),
+		string(t.Status()),
+	}
+}
+
+// writeJSON writes the given value as indented JSON
+func (c cli) writeJSON(value interface{}) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(value)
+}
+
+// writeTable writes the given rows as aligned columns
+func (c cli) writeTable(rows [][]string) error {
+	w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	for _, row := range rows {
+		for i, cell := range row {
+			if i > 0 {
+				fmt.Fprint(w, "\t")
+			}
+			fmt.Fprint(w, cell)
+		}
+		fmt.Fprintln(w)
+	}
+	return w.Flush()
+}
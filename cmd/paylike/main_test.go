@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// runCLI runs the command line against a fake API, returning its exit code
+// and output
+func runCLI(t *testing.T, args ...string) (int, string, string) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /me":
+			w.Write([]byte(`{"identity":{"id":"app1"}}`))
+		case "GET /identities/app1/merchants":
+			w.Write([]byte(`[{"id":"m1","name":"Shop","currency":"EUR","test":true,"created":"2020-01-01"}]`))
+		case "GET /merchants/m1/transactions":
+			w.Write([]byte(`[{"id":"tx1","created":"2020-01-02","amount":1000,"currency":"EUR","pendingAmount":1000,"successful":true}]`))
+		case "GET /merchants/m2/transactions":
+			w.Write([]byte(`[{"id":"tx3","amount":1000,"currency":"EUR"},{"id":"tx2","amount":1000,"currency":"EUR"}]`))
+		case "POST /transactions/tx1/captures":
+			var dto map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&dto)
+			assert.Equal(t, map[string]interface{}{"amount": float64(400), "currency": "EUR"}, dto)
+			w.Write([]byte(`{"transaction":{"id":"tx1","amount":1000,"currency":"EUR","capturedAmount":400,"pendingAmount":600,"successful":true}}`))
+		case "GET /merchants/m1/lines":
+			w.Write([]byte(`[{"id":"l1","created":"2020-01-03","transactionId":"tx1","amount":{"currency":"EUR","amount":4},"balance":400,"fee":10}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"NOT_FOUND","message":"not found"}`))
+		}
+	}))
+	defer server.Close()
+	env := map[string]string{"PAYLIKE_KEY": "key", "PAYLIKE_URL": server.URL}
+	var stdout, stderr bytes.Buffer
+	code := run(args, func(name string) string { return env[name] }, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestMerchantsList(t *testing.T) {
+	code, stdout, _ := runCLI(t, "merchants", "list")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "ID  NAME  CURRENCY  TEST  CREATED\nm1  Shop  EUR       true  2020-01-01\n", stdout)
+
+	code, stdout, _ = runCLI(t, "merchants", "list", "-output", "json")
+	assert.Equal(t, 0, code)
+	var merchants []map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(stdout), &merchants))
+	assert.Equal(t, "m1", merchants[0]["id"])
+}
+
+func TestTransactions(t *testing.T) {
+	code, stdout, _ := runCLI(t, "tx", "list", "-merchant", "m1")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "tx1  2020-01-02  1000    EUR       0         0         0       authorized")
+
+	code, stdout, stderr := runCLI(t, "tx", "list", "-merchant", "m2")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "tx2")
+	assert.Empty(t, stderr)
+	code, stdout, stderr = runCLI(t, "tx", "list", "-merchant", "m2", "-limit", "1")
+	assert.Equal(t, 0, code)
+	assert.NotContains(t, stdout, "tx2")
+	assert.Equal(t, "paylike: output truncated at 1 items, raise -limit to see more\n", stderr)
+
+	code, stdout, _ = runCLI(t, "tx", "capture", "-amount", "400", "-currency", "EUR", "tx1")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "partially_captured")
+
+	code, _, stderr = runCLI(t, "tx", "refund", "-amount", "400", "tx2")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "404")
+}
+
+func TestLinesExport(t *testing.T) {
+	code, stdout, _ := runCLI(t, "lines", "export", "-merchant", "m1", "-csv")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "id,created,transactionId,amount,currency,balance,fee,refund,test\nl1,2020-01-03,tx1,4,EUR,400,10,false,false\n", stdout)
+}
+
+func TestUsage(t *testing.T) {
+	code, _, stderr := runCLI(t)
+	assert.Equal(t, 2, code)
+	assert.True(t, strings.HasPrefix(stderr, "usage:"))
+
+	code, _, stderr = runCLI(t, "tx", "list")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "-merchant is required")
+
+	code, _, _ = runCLI(t, "tx", "capture", "tx1")
+	assert.Equal(t, 2, code)
+
+	code, _, stderr = runCLI(t, "merchants", "list", "-output", "xml")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, `unknown output format "xml"`)
+
+	code, _, stderr = runCLI(t, "apps", "list")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, `unknown command "apps list"`)
+
+	var stderrBuf bytes.Buffer
+	code = run([]string{"merchants", "list"}, func(string) string { return "" }, &bytes.Buffer{}, &stderrBuf)
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderrBuf.String(), "PAYLIKE_KEY")
+}
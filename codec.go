@@ -0,0 +1,18 @@
+package paylike
+
+// Codec encodes request bodies and decodes response bodies, e.g.
+// jsoniter.ConfigCompatibleWithStandardLibrary or sonic.ConfigStd
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// WithCodec encodes and decodes the bodies of the client's requests with the
+// given codec instead of encoding/json, e.g. to speed up large listings
+// Models keeping the raw JSON of fields they don't capture (see Raw) still
+// decode their own fields with encoding/json
+func WithCodec(codec Codec) Option {
+	return func(c *Client) {
+		c.codec = codec
+	}
+}
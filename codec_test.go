@@ -0,0 +1,47 @@
+package paylike
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingCodec encodes with encoding/json, counting its calls
+type countingCodec struct {
+	marshals, unmarshals int
+}
+
+func (c *countingCodec) Marshal(v interface{}) ([]byte, error) {
+	c.marshals++
+	return json.Marshal(v)
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v interface{}) error {
+	c.unmarshals++
+	return json.Unmarshal(data, v)
+}
+
+func TestWithCodec(t *testing.T) {
+	codec := &countingCodec{}
+	var body string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+		if r.Method == http.MethodPost {
+			w.Write([]byte(`{"transaction":{"id":"tx1","capturedAmount":100}}`))
+		}
+	}), WithCodec(codec))
+
+	transaction, err := client.CaptureTransaction("tx1", TransactionTrailDTO{Amount: 100})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(100), transaction.CapturedAmount)
+	assert.Equal(t, `{"amount":100}`, body)
+	assert.Equal(t, 1, codec.marshals)
+	assert.Equal(t, 1, codec.unmarshals)
+
+	assert.Nil(t, client.RevokeUserFromMerchant(TestMerchant, "u1"))
+	assert.Equal(t, 1, codec.unmarshals)
+}
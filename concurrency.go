@@ -0,0 +1,50 @@
+package paylike
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrConcurrencyLimit is returned without performing the request when the
+// client runs its maximum number of concurrent requests and has been
+// configured with FailFastWhenBusy
+var ErrConcurrencyLimit = errors.New("paylike: too many concurrent requests")
+
+// WithMaxConcurrentRequests limits the number of requests the client and all
+// its copies run at the same time, queueing further requests until a slot
+// frees up or their context is done
+func WithMaxConcurrentRequests(n int) Option {
+	return func(c *Client) {
+		c.slots = make(chan struct{}, n)
+	}
+}
+
+// FailFastWhenBusy makes requests beyond the limit set with
+// WithMaxConcurrentRequests fail with ErrConcurrencyLimit instead of queueing
+func FailFastWhenBusy() Option {
+	return func(c *Client) {
+		c.failFastWhenBusy = true
+	}
+}
+
+// acquireSlot waits for a free request slot, returning the function releasing it
+func (c Client) acquireSlot(ctx context.Context) (func(), error) {
+	if c.slots == nil {
+		return func() {}, nil
+	}
+	release := func() { <-c.slots }
+	if c.failFastWhenBusy {
+		select {
+		case c.slots <- struct{}{}:
+			return release, nil
+		default:
+			return nil, ErrConcurrencyLimit
+		}
+	}
+	select {
+	case c.slots <- struct{}{}:
+		return release, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
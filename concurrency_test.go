@@ -0,0 +1,58 @@
+package paylike
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxConcurrentRequests(t *testing.T) {
+	var mu sync.Mutex
+	running, peak := 0, 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+	}), WithMaxConcurrentRequests(2))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.FetchApp()
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 2, peak)
+}
+
+func TestMaxConcurrentRequestsQueueContext(t *testing.T) {
+	client := newSlowTestClient(t, time.Second, WithMaxConcurrentRequests(1))
+	go client.FetchApp()
+	time.Sleep(10 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := client.FetchApp(WithContext(ctx))
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestFailFastWhenBusy(t *testing.T) {
+	client := newSlowTestClient(t, time.Second, WithMaxConcurrentRequests(1), FailFastWhenBusy())
+	go client.FetchApp()
+	time.Sleep(10 * time.Millisecond)
+	_, err := client.FetchApp()
+	assert.Equal(t, ErrConcurrencyLimit, err)
+}
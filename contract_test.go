@@ -0,0 +1,224 @@
+package paylike
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// contractFixture is a canonical response of an endpoint, recorded in
+// testdata/contract/<operation>.json
+type contractFixture struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body"`
+}
+
+// contractCalls performs every operation the way users of the client do,
+// returning the decoded response (if any) along with the field of the
+// response body it has been decoded from (if wrapped)
+var contractCalls = map[string]func(c *Client) (value interface{}, key string, err error){
+	"CreateApp": func(c *Client) (interface{}, string, error) {
+		app, err := c.CreateApp()
+		return app, "app", err
+	},
+	"FetchApp": func(c *Client) (interface{}, string, error) {
+		identity, err := c.FetchApp()
+		return identity, "identity", err
+	},
+	"CreateMerchant": func(c *Client) (interface{}, string, error) {
+		merchant, err := c.CreateMerchant(MerchantCreateDTO{
+			Name:       "Shop",
+			Currency:   "EUR",
+			Test:       true,
+			Email:      "shop@example.com",
+			Website:    "https://example.com",
+			Descriptor: "SHOP",
+			Company:    &MerchantCompany{Country: "DK", Number: "12345678"},
+		})
+		return merchant, "merchant", err
+	},
+	"GetMerchant": func(c *Client) (interface{}, string, error) {
+		merchant, err := c.GetMerchant("5f0c0b7c2a1e4b0012345678")
+		return merchant, "merchant", err
+	},
+	"FetchMerchants": func(c *Client) (interface{}, string, error) {
+		merchants, err := c.FetchMerchants("5f0c0b7c2a1e4b0012345679", 10)
+		return merchants, "", err
+	},
+	"UpdateMerchant": func(c *Client) (interface{}, string, error) {
+		return nil, "", c.UpdateMerchant("5f0c0b7c2a1e4b0012345678", MerchantUpdateDTO{Name: "Shop"})
+	},
+	"InviteUserToMerchant": func(c *Client) (interface{}, string, error) {
+		response, err := c.InviteUserToMerchant("5f0c0b7c2a1e4b0012345678", "owner@example.com")
+		return response, "", err
+	},
+	"FetchUsersToMerchant": func(c *Client) (interface{}, string, error) {
+		users, err := c.FetchUsersToMerchant("5f0c0b7c2a1e4b0012345678", 10)
+		return users, "", err
+	},
+	"RevokeUserFromMerchant": func(c *Client) (interface{}, string, error) {
+		return nil, "", c.RevokeUserFromMerchant("5f0c0b7c2a1e4b0012345678", "5f0c0b7c2a1e4b001234567d")
+	},
+	"AddAppToMerchant": func(c *Client) (interface{}, string, error) {
+		return nil, "", c.AddAppToMerchant("5f0c0b7c2a1e4b0012345678", "5f0c0b7c2a1e4b0012345679")
+	},
+	"FetchAppsToMerchant": func(c *Client) (interface{}, string, error) {
+		apps, err := c.FetchAppsToMerchant("5f0c0b7c2a1e4b0012345678", 10)
+		return apps, "", err
+	},
+	"RevokeAppFromMerchant": func(c *Client) (interface{}, string, error) {
+		return nil, "", c.RevokeAppFromMerchant("5f0c0b7c2a1e4b0012345678", "5f0c0b7c2a1e4b0012345679")
+	},
+	"FetchLinesToMerchant": func(c *Client) (interface{}, string, error) {
+		lines, err := c.FetchLinesToMerchant("5f0c0b7c2a1e4b0012345678", 10)
+		return lines, "", err
+	},
+	"CreateTransaction": func(c *Client) (interface{}, string, error) {
+		id, err := c.CreateTransaction("5f0c0b7c2a1e4b0012345678", TransactionDTO{CardID: "5f0c0d102a1e4b001234567e", Currency: "EUR", Amount: 1000})
+		return id, "transaction", err
+	},
+	"ListTransactions": func(c *Client) (interface{}, string, error) {
+		transactions, err := c.ListTransactions("5f0c0b7c2a1e4b0012345678", 10)
+		return transactions, "", err
+	},
+	"CaptureTransaction": func(c *Client) (interface{}, string, error) {
+		transaction, err := c.CaptureTransaction("5f0c0c4e2a1e4b001234567a", TransactionTrailDTO{Amount: 400})
+		return transaction, "transaction", err
+	},
+	"RefundTransaction": func(c *Client) (interface{}, string, error) {
+		transaction, err := c.RefundTransaction("5f0c0c4e2a1e4b001234567a", TransactionTrailDTO{Amount: 100})
+		return transaction, "transaction", err
+	},
+	"VoidTransaction": func(c *Client) (interface{}, string, error) {
+		transaction, err := c.VoidTransaction("5f0c0c4e2a1e4b001234567a", TransactionTrailDTO{Amount: 600})
+		return transaction, "transaction", err
+	},
+	"FindTransaction": func(c *Client) (interface{}, string, error) {
+		transaction, err := c.FindTransaction("5f0c0c4e2a1e4b001234567a")
+		return transaction, "transaction", err
+	},
+	"FetchCard": func(c *Client) (interface{}, string, error) {
+		card, err := c.FetchCard("5f0c0d102a1e4b001234567e")
+		return card, "card", err
+	},
+	"CreateCard": func(c *Client) (interface{}, string, error) {
+		id, err := c.CreateCard("5f0c0b7c2a1e4b0012345678", CardDTO{TransactionID: "5f0c0c4e2a1e4b001234567a"})
+		return id, "card", err
+	},
+	"UpdateCard": func(c *Client) (interface{}, string, error) {
+		notes := "customer 42"
+		return nil, "", c.UpdateCard("5f0c0d102a1e4b001234567e", CardUpdateDTO{Notes: &notes})
+	},
+	"FetchCountries": func(c *Client) (interface{}, string, error) {
+		countries, err := c.FetchCountries()
+		return countries, "", err
+	},
+	"FetchCurrencies": func(c *Client) (interface{}, string, error) {
+		currencies, err := c.FetchCurrencies()
+		return currencies, "", err
+	},
+}
+
+// loadContractFixture reads the canonical response of the given operation
+func loadContractFixture(t *testing.T, op Operation) contractFixture {
+	b, err := ioutil.ReadFile(filepath.Join("testdata", "contract", op.Name+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fixture contractFixture
+	if err := json.Unmarshal(b, &fixture); err != nil {
+		t.Fatalf("%s: %v", op.Name, err)
+	}
+	return fixture
+}
+
+func TestContractCorpusComplete(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "contract", "*.json"))
+	assert.Nil(t, err)
+	var names []string
+	for _, file := range files {
+		names = append(names, strings.TrimSuffix(filepath.Base(file), ".json"))
+	}
+	var expected []string
+	for _, op := range Operations() {
+		expected = append(expected, op.Name)
+		assert.Contains(t, contractCalls, op.Name)
+	}
+	assert.ElementsMatch(t, expected, names)
+	assert.Len(t, contractCalls, len(expected))
+}
+
+func TestContract(t *testing.T) {
+	for _, op := range Operations() {
+		op := op
+		t.Run(op.Name, func(t *testing.T) {
+			fixture := loadContractFixture(t, op)
+			call, ok := contractCalls[op.Name]
+			if !ok {
+				t.Fatalf("no call performing %s", op.Name)
+			}
+			client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				matched, _, ok := MatchOperation(r.Method, r.URL.Path)
+				assert.True(t, ok)
+				assert.Equal(t, op, matched)
+				w.WriteHeader(fixture.Status)
+				w.Write(fixture.Body)
+			}), WithStrictDecoding())
+
+			value, key, err := call(client)
+			if !assert.Nil(t, err) || value == nil {
+				return
+			}
+			var expected interface{}
+			assert.Nil(t, json.Unmarshal(fixture.Body, &expected))
+			if key != "" {
+				expected = expected.(map[string]interface{})[key]
+			}
+			encoded, err := json.Marshal(value)
+			assert.Nil(t, err)
+			var actual interface{}
+			assert.Nil(t, json.Unmarshal(encoded, &actual))
+			for _, mismatch := range contractMismatches(expected, actual, key) {
+				t.Error(mismatch)
+			}
+		})
+	}
+}
+
+// contractMismatches returns the paths of the values of the given canonical
+// response lost or altered by decoding it and encoding it again
+func contractMismatches(expected, actual interface{}, path string) []string {
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: got %v", path, actual)}
+		}
+		var mismatches []string
+		for k, v := range e {
+			mismatches = append(mismatches, contractMismatches(v, a[k], joinPath(path, k))...)
+		}
+		return mismatches
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok || len(a) != len(e) {
+			return []string{fmt.Sprintf("%s: got %v", path, actual)}
+		}
+		var mismatches []string
+		for i := range e {
+			mismatches = append(mismatches, contractMismatches(e[i], a[i], fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return mismatches
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		return []string{fmt.Sprintf("%s: expected %v, got %v", path, expected, actual)}
+	}
+	return nil
+}
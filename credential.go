@@ -0,0 +1,82 @@
+package paylike
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Initiators of a charge of a stored card
+const (
+	InitiatorCustomer = "customer" // the customer takes part in the charge, e.g. at checkout
+	InitiatorMerchant = "merchant" // the merchant charges without the customer, e.g. a subscription renewal
+)
+
+// Reasons for charging a stored card
+const (
+	CredentialRecurring   = "recurring"   // charges of a fixed amount at fixed intervals
+	CredentialInstallment = "installment" // a fixed number of charges paying off a single purchase
+	CredentialUnscheduled = "unscheduled" // charges at irregular times, e.g. account top-ups
+)
+
+// StoredCredential describes a charge of a saved card or a previous
+// transaction as required by the card schemes for stored credentials
+type StoredCredential struct {
+	Initiator       string `json:"initiator"`                 // required, InitiatorCustomer or InitiatorMerchant
+	Reason          string `json:"reason,omitempty"`          // required for merchant-initiated charges, e.g. CredentialRecurring
+	SchemeReference string `json:"schemeReference,omitempty"` // optional, scheme reference of the initial charge
+}
+
+// Reasons stored credential data is rejected for, wrapped by the errors
+// returned by StoredCredential.Validate with the details
+var (
+	ErrCredentialInitiator = errors.New("paylike: stored credential initiator must be customer or merchant")
+	ErrCredentialReason    = errors.New("paylike: stored credential reason is missing or unknown")
+	ErrCredentialRecurring = errors.New("paylike: recurring transaction requires the recurring stored credential reason")
+)
+
+// Validate checks the initiator and reason of the stored credential data
+func (s StoredCredential) Validate() error {
+	if s.Initiator != InitiatorCustomer && s.Initiator != InitiatorMerchant {
+		return fmt.Errorf("%w, got %q", ErrCredentialInitiator, s.Initiator)
+	}
+	switch s.Reason {
+	case CredentialRecurring, CredentialInstallment, CredentialUnscheduled:
+	case "":
+		if s.Initiator == InitiatorMerchant {
+			return fmt.Errorf("%w: required for merchant-initiated charges", ErrCredentialReason)
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrCredentialReason, s.Reason)
+	}
+	return nil
+}
+
+// MerchantInitiated reports whether the charge is initiated by the merchant
+func (s StoredCredential) MerchantInitiated() bool {
+	return s.Initiator == InitiatorMerchant
+}
+
+// Validate checks the descriptor, 3-D Secure and stored credential data of
+// the transaction, if any
+func (d TransactionDTO) Validate() error {
+	if d.Descriptor != "" {
+		if err := ValidateDescriptor(d.Descriptor); err != nil {
+			return err
+		}
+	}
+	if d.TDS != nil {
+		if err := d.TDS.Validate(); err != nil {
+			return err
+		}
+	}
+	if d.StoredCredential == nil {
+		return nil
+	}
+	if err := d.StoredCredential.Validate(); err != nil {
+		return err
+	}
+	if d.Recurring && d.StoredCredential.Reason != "" && d.StoredCredential.Reason != CredentialRecurring {
+		return fmt.Errorf("%w, got %q", ErrCredentialRecurring, d.StoredCredential.Reason)
+	}
+	return nil
+}
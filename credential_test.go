@@ -0,0 +1,55 @@
+package paylike
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoredCredentialValidate(t *testing.T) {
+	assert.Nil(t, StoredCredential{Initiator: InitiatorCustomer}.Validate())
+	assert.Nil(t, StoredCredential{Initiator: InitiatorMerchant, Reason: CredentialUnscheduled}.Validate())
+	assert.True(t, errors.Is(StoredCredential{}.Validate(), ErrCredentialInitiator))
+	assert.True(t, errors.Is(StoredCredential{Initiator: InitiatorMerchant}.Validate(), ErrCredentialReason))
+	err := StoredCredential{Initiator: InitiatorCustomer, Reason: "monthly"}.Validate()
+	assert.True(t, errors.Is(err, ErrCredentialReason))
+	assert.Equal(t, `paylike: stored credential reason is missing or unknown: "monthly"`, err.Error())
+
+	dto := TransactionDTO{Recurring: true, StoredCredential: &StoredCredential{Initiator: InitiatorMerchant, Reason: CredentialInstallment}}
+	assert.True(t, errors.Is(dto.Validate(), ErrCredentialRecurring))
+	dto.StoredCredential.Reason = CredentialRecurring
+	assert.Nil(t, dto.Validate())
+	assert.True(t, dto.StoredCredential.MerchantInitiated())
+	assert.Nil(t, TransactionDTO{}.Validate())
+}
+
+func TestCreateTransactionStoredCredential(t *testing.T) {
+	var body string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{"transaction":{"id":"tx2"}}`))
+	}))
+	_, err := client.CreateTransaction(TestMerchant, TransactionDTO{
+		CardID:           "c1",
+		Currency:         "EUR",
+		Amount:           999,
+		Recurring:        true,
+		StoredCredential: &StoredCredential{Initiator: InitiatorMerchant, Reason: CredentialRecurring, SchemeReference: "ref1"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"cardId":"c1","currency":"EUR","amount":999,"recurring":true,"storedCredential":{"initiator":"merchant","reason":"recurring","schemeReference":"ref1"}}`, body)
+
+	body = ""
+	_, err = client.CreateTransaction(TestMerchant, TransactionDTO{
+		CardID:           "c1",
+		Currency:         "EUR",
+		Amount:           999,
+		StoredCredential: &StoredCredential{Initiator: InitiatorMerchant},
+	})
+	assert.True(t, errors.Is(err, ErrCredentialReason))
+	assert.Empty(t, body)
+}
@@ -0,0 +1,92 @@
+package paylike
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+)
+
+// CustomString returns the custom field with the given key if it is a string
+func (t Transaction) CustomString(key string) (string, bool) {
+	s, ok := t.Custom[key].(string)
+	return s, ok
+}
+
+// CustomInt returns the custom field with the given key if it is an integer
+func (t Transaction) CustomInt(key string) (int, bool) {
+	switch v := t.Custom[key].(type) {
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) <= 1<<53 {
+			return int(v), true
+		}
+	case int:
+		return v, true
+	case json.Number:
+		if n, err := v.Int64(); err == nil && n == int64(int(n)) {
+			return int(n), true
+		}
+	}
+	return 0, false
+}
+
+// CustomBool returns the custom field with the given key if it is a boolean
+func (t Transaction) CustomBool(key string) (bool, bool) {
+	b, ok := t.Custom[key].(bool)
+	return b, ok
+}
+
+// DecodeCustom maps custom data (e.g. Transaction.Custom) into a value of
+// the given type using its JSON tags
+func DecodeCustom[T any](custom map[string]interface{}) (T, error) {
+	var value T
+	b, err := json.Marshal(custom)
+	if err != nil {
+		return value, err
+	}
+	err = json.Unmarshal(b, &value)
+	return value, err
+}
+
+// CustomSearch describes how FindTransactionsByCustom looks for transactions
+type CustomSearch struct {
+	Pagination      // optional, how pages of transactions are fetched
+	FirstOnly  bool // optional, stop at the first matching transaction
+}
+
+// FindTransactionsByCustom lists the transactions under the given merchantID
+// whose custom field with the given key equals the given value, e.g. to find
+// the transactions of an order by its ID
+// Values are compared by their JSON representation, so 3 equals 3.0
+func (c Client) FindTransactionsByCustom(merchantID MerchantID, key string, value interface{}, search CustomSearch, opts ...CallOption) ([]*Transaction, error) {
+	want, err := normalizeCustom(value)
+	if err != nil {
+		return nil, err
+	}
+	var matching []*Transaction
+	err = c.EachTransaction(merchantID, search.Pagination, func(transaction *Transaction) error {
+		got, ok := transaction.Custom[key]
+		if !ok {
+			return nil
+		}
+		if got, err := normalizeCustom(got); err != nil || !reflect.DeepEqual(got, want) {
+			return nil
+		}
+		matching = append(matching, transaction)
+		if search.FirstOnly {
+			return ErrStopPagination
+		}
+		return nil
+	}, opts...)
+	return matching, err
+}
+
+// normalizeCustom converts the given value to its generic JSON representation
+func normalizeCustom(value interface{}) (interface{}, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var normalized interface{}
+	err = json.Unmarshal(b, &normalized)
+	return normalized, err
+}
@@ -0,0 +1,70 @@
+package paylike
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomAccessors(t *testing.T) {
+	var transaction Transaction
+	assert.Nil(t, json.Unmarshal([]byte(`{"id":"tx1","custom":{"orderId":"o-1","items":3,"ratio":1.5,"gift":true}}`), &transaction))
+
+	orderID, ok := transaction.CustomString("orderId")
+	assert.True(t, ok)
+	assert.Equal(t, "o-1", orderID)
+	_, ok = transaction.CustomString("items")
+	assert.False(t, ok)
+
+	items, ok := transaction.CustomInt("items")
+	assert.True(t, ok)
+	assert.Equal(t, 3, items)
+	_, ok = transaction.CustomInt("ratio")
+	assert.False(t, ok)
+	_, ok = transaction.CustomInt("missing")
+	assert.False(t, ok)
+
+	gift, ok := transaction.CustomBool("gift")
+	assert.True(t, ok)
+	assert.True(t, gift)
+}
+
+func TestDecodeCustom(t *testing.T) {
+	type order struct {
+		OrderID string `json:"orderId"`
+		Items   int    `json:"items"`
+	}
+	o, err := DecodeCustom[order](map[string]interface{}{"orderId": "o-1", "items": 3.0, "other": true})
+	assert.Nil(t, err)
+	assert.Equal(t, order{OrderID: "o-1", Items: 3}, o)
+
+	_, err = DecodeCustom[order](map[string]interface{}{"items": "three"})
+	assert.NotNil(t, err)
+}
+
+func TestFindTransactionsByCustom(t *testing.T) {
+	requests := 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Query().Get("before") != "" {
+			w.Write([]byte(`[{"id":"tx1","custom":{"orderId":42}}]`))
+			return
+		}
+		w.Write([]byte(`[{"id":"tx3","custom":{"orderId":42}},{"id":"tx2","custom":{"orderId":"42"}}]`))
+	}))
+	transactions, err := client.FindTransactionsByCustom(TestMerchant, "orderId", 42, CustomSearch{Pagination: Pagination{PageSize: 2}})
+	assert.Nil(t, err)
+	assert.Len(t, transactions, 2)
+	assert.Equal(t, TxID("tx3"), transactions[0].ID)
+	assert.Equal(t, TxID("tx1"), transactions[1].ID)
+	assert.Equal(t, 2, requests)
+
+	requests = 0
+	transactions, err = client.FindTransactionsByCustom(TestMerchant, "orderId", "42", CustomSearch{Pagination: Pagination{PageSize: 2}, FirstOnly: true})
+	assert.Nil(t, err)
+	assert.Len(t, transactions, 1)
+	assert.Equal(t, TxID("tx2"), transactions[0].ID)
+	assert.Equal(t, 1, requests)
+}
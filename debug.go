@@ -0,0 +1,110 @@
+package paylike
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// maxDumpBodySize limits how much of a request body is dumped
+const maxDumpBodySize = 1 << 10
+
+// WithRequestDump includes a sanitized dump of the outgoing request in the
+// errors of failed calls, see RequestError
+func WithRequestDump() Option {
+	return func(c *Client) {
+		c.requestDump = true
+	}
+}
+
+// RequestDump describes an outgoing request with its credentials redacted,
+// i.e. the values of headers other than Content-Type, Accept, User-Agent and
+// X-Request-Id, and the API key wherever it appears in the URL or body
+type RequestDump struct {
+	Method    string
+	URL       string
+	Header    http.Header
+	Body      string
+	Truncated bool // whether the body has been truncated to 1 KiB
+}
+
+// String formats the dump like a raw HTTP request
+func (d RequestDump) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s", d.Method, d.URL)
+	names := make([]string, 0, len(d.Header))
+	for name := range d.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range d.Header[name] {
+			fmt.Fprintf(&b, "\n%s: %s", name, value)
+		}
+	}
+	if d.Body != "" {
+		b.WriteString("\n\n" + d.Body)
+		if d.Truncated {
+			b.WriteString("...")
+		}
+	}
+	return b.String()
+}
+
+// RequestError describes a failed call along with a dump of its request,
+// returned when the client has been created with WithRequestDump
+type RequestError struct {
+	Err     error
+	Request RequestDump
+}
+
+// Error returns the error of the call followed by the request dump
+func (e *RequestError) Error() string {
+	return e.Err.Error() + "\n" + e.Request.String()
+}
+
+// Unwrap returns the error of the call
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// dumpedHeaders are the request headers dumped as is, the values of the
+// others being redacted as they may carry credentials, e.g. tenant tokens or
+// request signatures
+var dumpedHeaders = map[string]bool{
+	"Content-Type": true,
+	"Accept":       true,
+	"User-Agent":   true,
+	"X-Request-Id": true,
+}
+
+// dumpRequest creates a sanitized dump of the given request
+func dumpRequest(req *http.Request) RequestDump {
+	_, key, _ := req.BasicAuth()
+	dump := RequestDump{
+		Method: req.Method,
+		URL:    redactKeys(req.URL.String(), key),
+		Header: req.Header.Clone(),
+	}
+	for name, values := range dump.Header {
+		if dumpedHeaders[http.CanonicalHeaderKey(name)] {
+			continue
+		}
+		for i := range values {
+			values[i] = "REDACTED"
+		}
+	}
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			b, _ := ioutil.ReadAll(body)
+			body.Close()
+			dump.Body = redactKeys(string(b), key)
+			if len(dump.Body) > maxDumpBodySize {
+				dump.Body, dump.Truncated = dump.Body[:maxDumpBodySize], true
+			}
+		}
+	}
+	return dump
+}
@@ -0,0 +1,43 @@
+package paylike
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestDump(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":"INVALID","message":"invalid descriptor"}`))
+	}), WithRequestDump(), WithHeader("X-Tenant-Token", "tenant-secret"))
+	_, err := client.CreateTransaction("m1", TransactionDTO{Currency: "EUR", Amount: 100, Custom: map[string]interface{}{"note": strings.Repeat("x", 2000)}},
+		WithCallHeader("X-Signature", "signed"), WithCallHeader("X-Request-Id", "r1"))
+
+	var reqErr *RequestError
+	assert.True(t, errors.As(err, &reqErr))
+	assert.True(t, IsClientError(err))
+	assert.Equal(t, "POST", reqErr.Request.Method)
+	assert.True(t, strings.HasSuffix(reqErr.Request.URL, "/merchants/m1/transactions"))
+	assert.Equal(t, "REDACTED", reqErr.Request.Header.Get("Authorization"))
+	assert.Equal(t, "REDACTED", reqErr.Request.Header.Get("X-Tenant-Token"))
+	assert.Equal(t, "REDACTED", reqErr.Request.Header.Get("X-Signature"))
+	assert.Equal(t, "r1", reqErr.Request.Header.Get("X-Request-Id"))
+	assert.Equal(t, "application/json", reqErr.Request.Header.Get("Content-Type"))
+	assert.True(t, reqErr.Request.Truncated)
+	assert.Len(t, reqErr.Request.Body, 1024)
+	assert.False(t, strings.Contains(err.Error(), TestKey))
+	assert.False(t, strings.Contains(err.Error(), "tenant-secret"))
+	assert.True(t, strings.Contains(err.Error(), "invalid descriptor\nPOST "))
+	assert.True(t, strings.Contains(err.Error(), "\nAuthorization: REDACTED\n"))
+}
+
+func TestRequestDumpDisabled(t *testing.T) {
+	client := newErrorTestClient(t, http.StatusBadRequest, `{}`)
+	_, err := client.FindTransaction("tx1")
+	var reqErr *RequestError
+	assert.False(t, errors.As(err, &reqErr))
+}
@@ -0,0 +1,248 @@
+package paylike
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// WithStrictDecoding makes calls fail when a response contains fields
+// the response models don't capture yet
+func WithStrictDecoding() Option {
+	return func(c *Client) {
+		c.strictDecoding = true
+	}
+}
+
+// WithUnknownFieldsHook reports the paths (e.g. "transaction.card.funding")
+// of response fields the response models don't capture yet to the given hook,
+// without failing the call
+func WithUnknownFieldsHook(hook func(op Operation, fields []string)) Option {
+	return func(c *Client) {
+		c.unknownFieldsHook = hook
+	}
+}
+
+// WithLenientLists makes list calls skip elements of the response failing to
+// decode instead of failing the call, reporting them to the given hook (if
+// any), so one unexpected element doesn't break e.g. a nightly export
+// Pagination carries on past the skipped elements
+func WithLenientLists(hook func(DecodeWarning)) Option {
+	return func(c *Client) {
+		c.lenientLists = true
+		c.decodeWarningHook = hook
+	}
+}
+
+// DecodeWarning describes an element of a list response skipped in lenient mode
+type DecodeWarning struct {
+	Operation Operation
+	Index     int             // position of the element in the response
+	Raw       json.RawMessage // the element as returned by the API
+	Err       error           // why decoding the element failed
+}
+
+// UnknownFieldsError is returned in strict decoding mode when a response
+// contains fields the response models don't capture
+type UnknownFieldsError struct {
+	Operation Operation
+	Fields    []string
+}
+
+// Error returns the paths of the unknown fields
+func (e *UnknownFieldsError) Error() string {
+	return fmt.Sprintf("paylike: %s returned unknown fields %s", e.Operation.Name, strings.Join(e.Fields, ", "))
+}
+
+// decode decodes the given response body into the given value, according to
+// the decoding options of the client
+func (c Client) decode(op Operation, body io.Reader, value interface{}) error {
+	if c.strictDecoding || c.unknownFieldsHook != nil {
+		b, err := ioutil.ReadAll(body)
+		if err != nil {
+			return err
+		}
+		if fields := unknownFields(b, reflect.TypeOf(value)); len(fields) > 0 {
+			if c.unknownFieldsHook != nil {
+				c.unknownFieldsHook(op, fields)
+			}
+			if c.strictDecoding {
+				return &UnknownFieldsError{Operation: op, Fields: fields}
+			}
+		}
+		body = bytes.NewReader(b)
+	}
+	if c.lenientLists && reflect.TypeOf(value).Elem().Kind() == reflect.Slice {
+		return c.decodeLenient(op, body, value)
+	}
+	if c.codec != nil {
+		b, err := ioutil.ReadAll(body)
+		if err != nil || len(b) == 0 {
+			return err
+		}
+		return c.codec.Unmarshal(b, value)
+	}
+	err := json.NewDecoder(body).Decode(value)
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+// decodeLenient decodes the given list response body into the slice the
+// given value points to, skipping and reporting the elements failing to decode
+func (c Client) decodeLenient(op Operation, body io.Reader, value interface{}) error {
+	unmarshal := json.Unmarshal
+	if c.codec != nil {
+		unmarshal = c.codec.Unmarshal
+	}
+	var elements []json.RawMessage
+	if err := json.NewDecoder(body).Decode(&elements); err != nil {
+		if err == io.EOF {
+			return nil
+		}
+		return err
+	}
+	slice := reflect.ValueOf(value).Elem()
+	decoded := reflect.MakeSlice(slice.Type(), 0, len(elements))
+	list := c.call.list
+	if list != nil {
+		list.lenient = true
+		list.ids = make([]string, len(elements))
+	}
+	for i, raw := range elements {
+		if list != nil {
+			var ref struct {
+				ID string `json:"id"`
+			}
+			if unmarshal(raw, &ref) == nil {
+				list.ids[i] = ref.ID
+			}
+		}
+		element := reflect.New(slice.Type().Elem())
+		if err := unmarshal(raw, element.Interface()); err != nil {
+			if c.decodeWarningHook != nil {
+				c.decodeWarningHook(DecodeWarning{Operation: op, Index: i, Raw: raw, Err: err})
+			}
+			continue
+		}
+		decoded = reflect.Append(decoded, element.Elem())
+		if list != nil {
+			list.indexes = append(list.indexes, i)
+		}
+	}
+	slice.Set(decoded)
+	return nil
+}
+
+// listInfo describes the elements of a list response as returned by the API,
+// including those skipped in lenient mode, so pagination is not cut short
+// by elements failing to decode
+type listInfo struct {
+	lenient bool
+	ids     []string // IDs of all elements of the response, empty if unknown
+	indexes []int    // positions in the response of the decoded elements
+}
+
+// unknownFields returns the sorted paths of all fields in the given JSON
+// that have no corresponding field in the given type
+func unknownFields(data []byte, t reflect.Type) []string {
+	var value interface{}
+	if json.Unmarshal(data, &value) != nil {
+		return nil
+	}
+	var fields []string
+	collectUnknownFields(value, t, "", &fields)
+	sort.Strings(fields)
+	return fields
+}
+
+// collectUnknownFields walks the given decoded JSON value along with the
+// given type, collecting the paths of fields without a counterpart
+func collectUnknownFields(value interface{}, t reflect.Type, path string, fields *[]string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		switch t.Kind() {
+		case reflect.Map:
+			for k, item := range v {
+				collectUnknownFields(item, t.Elem(), joinPath(path, k), fields)
+			}
+		case reflect.Struct:
+			known := jsonFields(t)
+			for k, item := range v {
+				field, ok := known[strings.ToLower(k)]
+				if !ok {
+					*fields = append(*fields, joinPath(path, k))
+					continue
+				}
+				collectUnknownFields(item, field.Type, joinPath(path, k), fields)
+			}
+		}
+	case []interface{}:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for _, item := range v {
+				collectUnknownFields(item, t.Elem(), path+"[]", fields)
+			}
+		}
+	}
+}
+
+// jsonFieldsCache caches the JSON fields of struct types by type
+var jsonFieldsCache sync.Map
+
+// jsonFields returns the fields of the given struct type, including promoted
+// ones, keyed by their lowercased JSON name as encoding/json matches them
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	if cached, ok := jsonFieldsCache.Load(t); ok {
+		return cached.(map[string]reflect.StructField)
+	}
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for k, f := range jsonFields(embedded) {
+					if _, ok := fields[k]; !ok {
+						fields[k] = f
+					}
+				}
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[strings.ToLower(name)] = field
+	}
+	jsonFieldsCache.Store(t, fields)
+	return fields
+}
+
+// joinPath appends the given key to the given path
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
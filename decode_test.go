@@ -0,0 +1,84 @@
+package paylike
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const unknownFieldsPayload = `{"transaction":{"id":"tx1","amount":100,"card":{"bin":"410000","funding":"debit"},
+	"trail":[{"amount":100,"lineId":"l1","network":"visa"}],"custom":{"anything":true},"fraud":{}}}`
+
+func TestUnknownFields(t *testing.T) {
+	var value map[string]*Transaction
+	fields := unknownFields([]byte(unknownFieldsPayload), reflect.TypeOf(&value))
+	assert.Equal(t, []string{"transaction.card.funding", "transaction.fraud", "transaction.trail[].network"}, fields)
+
+	var merchants []*Merchant
+	fields = unknownFields([]byte(`[{"ID":"m1","balance":1,"tds":{"mode":"full","extra":1}}]`), reflect.TypeOf(&merchants))
+	assert.Equal(t, []string{"[].tds.extra"}, fields)
+}
+
+func TestUnknownFieldsHook(t *testing.T) {
+	var reported []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(unknownFieldsPayload))
+	}), WithUnknownFieldsHook(func(op Operation, fields []string) {
+		assert.Equal(t, OpFindTransaction, op)
+		reported = fields
+	}))
+	transaction, err := client.FindTransaction("tx1")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(100), transaction.Amount)
+	assert.Len(t, reported, 3)
+}
+
+func TestStrictDecoding(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(unknownFieldsPayload))
+	}), WithStrictDecoding())
+	_, err := client.FindTransaction("tx1")
+	var unknownErr *UnknownFieldsError
+	assert.True(t, errors.As(err, &unknownErr))
+	assert.Equal(t, []string{"transaction.card.funding", "transaction.fraud", "transaction.trail[].network"}, unknownErr.Fields)
+
+	client = newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"transaction":{"id":"tx1","amount":100}}`))
+	}), WithStrictDecoding())
+	transaction, err := client.FindTransaction("tx1")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(100), transaction.Amount)
+}
+
+func TestLenientLists(t *testing.T) {
+	payload := `[{"id":"tx1","amount":100},{"id":"tx2","amount":"100"},{"id":"tx3","amount":300}]`
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(payload))
+	})
+	_, err := newTestClient(t, handler).ListTransactions("m1", 3)
+	assert.NotNil(t, err)
+
+	var warnings []DecodeWarning
+	client := newTestClient(t, handler, WithLenientLists(func(warning DecodeWarning) {
+		warnings = append(warnings, warning)
+	}))
+	transactions, err := client.ListTransactions("m1", 3)
+	assert.Nil(t, err)
+	assert.Len(t, transactions, 2)
+	assert.Equal(t, TxID("tx1"), transactions[0].ID)
+	assert.Equal(t, TxID("tx3"), transactions[1].ID)
+	assert.Len(t, warnings, 1)
+	assert.Equal(t, OpListTransactions, warnings[0].Operation)
+	assert.Equal(t, 1, warnings[0].Index)
+	assert.JSONEq(t, `{"id":"tx2","amount":"100"}`, string(warnings[0].Raw))
+	assert.NotNil(t, warnings[0].Err)
+
+	transaction, err := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"transaction":{"id":"tx1","amount":"100"}}`))
+	}), WithLenientLists(nil)).FindTransaction("tx1")
+	assert.NotNil(t, err)
+	assert.Nil(t, transaction)
+}
@@ -0,0 +1,118 @@
+package paylike
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Staleness describes whether a returned value is a last-known-good copy
+// served because the live call failed
+type Staleness struct {
+	Stale bool
+	Age   time.Duration // time since the value was fetched successfully
+	Err   error         // error of the failed live call
+}
+
+// WithLastKnownGood makes the client remember the last successfully fetched
+// merchants and transactions (up to the given number of entries), so calls
+// made with AllowStale can fall back to them when the live call fails
+// A size of zero or less disables it
+func WithLastKnownGood(entries int) Option {
+	return func(c *Client) {
+		c.lastKnownGood = nil
+		if entries > 0 {
+			c.lastKnownGood = &lastKnownGoodStore{max: entries, values: map[string]lastKnownGoodValue{}}
+		}
+	}
+}
+
+// AllowStale lets GetMerchant and FindTransaction return the last known good
+// value instead of an error when the live call fails because the API is
+// unavailable (retryable and server errors, or an open circuit breaker);
+// the given Staleness is filled to indicate whether that happened, and
+// reset when the live call succeeds
+// Has no effect unless the client has been created with WithLastKnownGood
+func AllowStale(staleness *Staleness) CallOption {
+	return func(o *callOptions) {
+		o.staleness = staleness
+	}
+}
+
+// lastKnownGoodValue describes a remembered value along with when it was fetched
+type lastKnownGoodValue struct {
+	value   interface{}
+	fetched time.Time
+}
+
+// lastKnownGoodStore remembers a bounded number of successfully fetched values
+type lastKnownGoodStore struct {
+	mu     sync.Mutex
+	max    int
+	values map[string]lastKnownGoodValue
+}
+
+// set remembers the given value fetched at the given time, evicting the
+// oldest one if the store is full
+func (s *lastKnownGoodStore) set(key string, value interface{}, fetched time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.values[key]; !ok && len(s.values) >= s.max {
+		var oldest string
+		for k, v := range s.values {
+			if oldest == "" || v.fetched.Before(s.values[oldest].fetched) {
+				oldest = k
+			}
+		}
+		delete(s.values, oldest)
+	}
+	s.values[key] = lastKnownGoodValue{value, fetched}
+}
+
+// get returns the remembered value for the given key
+func (s *lastKnownGoodStore) get(key string) (lastKnownGoodValue, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.values[key]
+	return v, ok
+}
+
+// withLastKnownGood remembers a copy of the value of a successful call, or
+// falls back to a copy of the remembered value of the given key if the call
+// failed because the API is unavailable and the caller allows it
+// Values are remembered per API key the call within the given context is
+// authenticated with, so tenants never see each other's values
+func withLastKnownGood[T any](c Client, ctx context.Context, key string, value *T, err error) (*T, error) {
+	if c.call.staleness != nil {
+		*c.call.staleness = Staleness{}
+	}
+	if c.lastKnownGood == nil {
+		return value, err
+	}
+	if err != nil && !IsRetryable(err) && !IsServerError(err) && !errors.Is(err, ErrCircuitOpen) {
+		return value, err
+	}
+	apiKey, keyErr := c.resolveKey(ctx)
+	if keyErr != nil {
+		return value, err
+	}
+	key = apiKey + "/" + key
+	if err == nil {
+		if value != nil {
+			remembered := *value
+			c.lastKnownGood.set(key, &remembered, c.now())
+		}
+		return value, nil
+	}
+	if c.call.staleness == nil {
+		return value, err
+	}
+	remembered, ok := c.lastKnownGood.get(key)
+	if !ok {
+		return value, err
+	}
+	*c.call.staleness = Staleness{Stale: true, Age: c.now().Sub(remembered.fetched), Err: err}
+	served := *remembered.value.(*T)
+	return &served, nil
+}
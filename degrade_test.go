@@ -0,0 +1,154 @@
+package paylike
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newFlakyTestClient(t *testing.T, down *bool, opts ...Option) *Client {
+	return newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if *down {
+			hj, _ := w.(http.Hijacker)
+			conn, _, _ := hj.Hijack()
+			conn.Close()
+			return
+		}
+		w.Write([]byte(`{"merchant":{"id":"m1","name":"Shop"},"transaction":{"id":"tx1","amount":100}}`))
+	}), opts...)
+}
+
+func TestLastKnownGood(t *testing.T) {
+	down := false
+	client := newFlakyTestClient(t, &down, WithLastKnownGood(10))
+	merchant, err := client.GetMerchant("m1")
+	assert.Nil(t, err)
+	assert.Equal(t, "Shop", merchant.Name)
+	transaction, err := client.FindTransaction("tx1")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(100), transaction.Amount)
+
+	down = true
+	_, err = client.GetMerchant("m1")
+	assert.NotNil(t, err)
+
+	var staleness Staleness
+	merchant, err = client.GetMerchant("m1", AllowStale(&staleness))
+	assert.Nil(t, err)
+	assert.Equal(t, "Shop", merchant.Name)
+	assert.True(t, staleness.Stale)
+	assert.NotNil(t, staleness.Err)
+
+	staleness = Staleness{}
+	transaction, err = client.FindTransaction("tx1", AllowStale(&staleness))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(100), transaction.Amount)
+	assert.True(t, staleness.Stale)
+
+	_, err = client.FindTransaction("tx2", AllowStale(&staleness))
+	assert.NotNil(t, err)
+}
+
+func TestLastKnownGoodOnlyWhenUnavailable(t *testing.T) {
+	status := http.StatusOK
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			w.Write([]byte(`{"merchant":{"id":"m1","name":"Shop"}}`))
+		}
+	}), WithLastKnownGood(10))
+	_, err := client.GetMerchant("m1")
+	assert.Nil(t, err)
+
+	var staleness Staleness
+	for _, status = range []int{http.StatusNotFound, http.StatusUnauthorized} {
+		merchant, err := client.GetMerchant("m1", AllowStale(&staleness))
+		assert.True(t, IsClientError(err))
+		assert.Nil(t, merchant)
+		assert.False(t, staleness.Stale)
+	}
+	status = http.StatusInternalServerError
+	merchant, err := client.GetMerchant("m1", AllowStale(&staleness))
+	assert.Nil(t, err)
+	assert.Equal(t, "Shop", merchant.Name)
+	assert.True(t, staleness.Stale)
+}
+
+func TestLastKnownGoodPerKey(t *testing.T) {
+	down := false
+	client := newFlakyTestClient(t, &down, WithLastKnownGood(10))
+	tenant := ContextWithKey(context.Background(), "tenant-key")
+	_, err := client.GetMerchant("m1", WithContext(tenant))
+	assert.Nil(t, err)
+
+	down = true
+	var staleness Staleness
+	_, err = client.GetMerchant("m1", AllowStale(&staleness))
+	assert.NotNil(t, err)
+	assert.False(t, staleness.Stale)
+	merchant, err := client.GetMerchant("m1", WithContext(tenant), AllowStale(&staleness))
+	assert.Nil(t, err)
+	assert.Equal(t, "Shop", merchant.Name)
+	assert.True(t, staleness.Stale)
+}
+
+func TestLastKnownGoodDisabled(t *testing.T) {
+	down := false
+	client := newFlakyTestClient(t, &down)
+	_, err := client.GetMerchant("m1")
+	assert.Nil(t, err)
+
+	down = true
+	var staleness Staleness
+	merchant, err := client.GetMerchant("m1", AllowStale(&staleness))
+	assert.NotNil(t, err)
+	assert.Nil(t, merchant)
+	assert.False(t, staleness.Stale)
+
+	down = false
+	client = newFlakyTestClient(t, &down, WithLastKnownGood(0))
+	_, err = client.GetMerchant("m1")
+	assert.Nil(t, err)
+	down = true
+	_, err = client.GetMerchant("m1", AllowStale(&staleness))
+	assert.NotNil(t, err)
+}
+
+func TestLastKnownGoodCopies(t *testing.T) {
+	down := false
+	client := newFlakyTestClient(t, &down, WithLastKnownGood(10))
+	merchant, err := client.GetMerchant("m1")
+	assert.Nil(t, err)
+	merchant.Name = "Changed"
+
+	down = true
+	var staleness Staleness
+	merchant, err = client.GetMerchant("m1", AllowStale(&staleness))
+	assert.Nil(t, err)
+	assert.Equal(t, "Shop", merchant.Name)
+	merchant.Name = "Changed"
+	merchant, err = client.GetMerchant("m1", AllowStale(&staleness))
+	assert.Nil(t, err)
+	assert.Equal(t, "Shop", merchant.Name)
+	assert.True(t, staleness.Stale)
+
+	down = false
+	_, err = client.GetMerchant("m1", AllowStale(&staleness))
+	assert.Nil(t, err)
+	assert.Equal(t, Staleness{}, staleness)
+}
+
+func TestLastKnownGoodEviction(t *testing.T) {
+	store := &lastKnownGoodStore{max: 2, values: map[string]lastKnownGoodValue{}}
+	now := time.Now()
+	store.set("a", 1, now)
+	store.set("b", 2, now.Add(time.Second))
+	store.set("c", 3, now.Add(2*time.Second))
+	_, ok := store.get("a")
+	assert.False(t, ok)
+	_, ok = store.get("c")
+	assert.True(t, ok)
+}
@@ -0,0 +1,82 @@
+package paylike
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Deprecation describes the deprecation notices of a response, as sent in
+// the Deprecation, Sunset, Link and Warning headers
+type Deprecation struct {
+	Operation    Operation
+	Deprecation  string    // raw Deprecation header, if any
+	DeprecatedAt time.Time // date of the Deprecation header, zero if none or not a date
+	Sunset       time.Time // date the endpoint stops working, zero if unknown
+	Link         string    // documentation of the deprecation or sunset, if any
+	Warnings     []string  // raw Warning headers, if any
+}
+
+// WithDeprecationHook reports the deprecation notices of responses to the
+// given hook, so upcoming API changes show up in the telemetry of the
+// integration
+func WithDeprecationHook(hook func(Deprecation)) Option {
+	return func(c *Client) {
+		c.deprecationHook = hook
+	}
+}
+
+// reportDeprecation calls the deprecation hook, if any, when the given
+// response carries deprecation notices
+func (c Client) reportDeprecation(op Operation, resp *http.Response) {
+	if c.deprecationHook == nil {
+		return
+	}
+	header := resp.Header
+	deprecation := Deprecation{
+		Operation:   op,
+		Deprecation: header.Get("Deprecation"),
+		Warnings:    header.Values("Warning"),
+	}
+	if sunset := header.Get("Sunset"); sunset != "" {
+		deprecation.Sunset, _ = http.ParseTime(sunset)
+	}
+	if deprecation.Deprecation == "" && deprecation.Sunset.IsZero() && len(deprecation.Warnings) == 0 {
+		return
+	}
+	deprecation.DeprecatedAt = parseDeprecationDate(deprecation.Deprecation)
+	deprecation.Link = deprecationLink(header.Values("Link"))
+	c.deprecationHook(deprecation)
+}
+
+// parseDeprecationDate parses the date of a Deprecation header, either a
+// structured date (e.g. "@1688169599") or an HTTP date
+func parseDeprecationDate(value string) time.Time {
+	if strings.HasPrefix(value, "@") {
+		seconds, err := strconv.ParseInt(value[1:], 10, 64)
+		if err == nil {
+			return time.Unix(seconds, 0).UTC()
+		}
+	}
+	date, _ := http.ParseTime(value)
+	return date
+}
+
+// deprecationLink returns the target of the first link with the deprecation
+// or sunset relation in the given Link headers
+func deprecationLink(headers []string) string {
+	for _, header := range headers {
+		for _, link := range strings.Split(header, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.Trim(strings.TrimSpace(parts[0]), "<>")
+			for _, param := range parts[1:] {
+				param = strings.ReplaceAll(strings.TrimSpace(param), `"`, "")
+				if param == "rel=deprecation" || param == "rel=sunset" {
+					return target
+				}
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,43 @@
+package paylike
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeprecationHook(t *testing.T) {
+	var deprecations []Deprecation
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/transactions/tx1" {
+			w.Header().Set("Deprecation", "@1688169600")
+			w.Header().Set("Sunset", "Wed, 01 Jan 2025 00:00:00 GMT")
+			w.Header().Add("Link", `<https://example.com/changelog>; rel="deprecation"; type="text/html"`)
+			w.Header().Add("Warning", `299 - "Deprecated API"`)
+		}
+		w.Write([]byte(`{"transaction":{"id":"tx1"}}`))
+	}), WithDeprecationHook(func(d Deprecation) {
+		deprecations = append(deprecations, d)
+	}))
+
+	_, err := client.FindTransaction("tx1")
+	assert.Nil(t, err)
+	_, err = client.CaptureTransaction("tx2", TransactionTrailDTO{Amount: 100})
+	assert.Nil(t, err)
+	assert.Equal(t, []Deprecation{{
+		Operation:    OpFindTransaction,
+		Deprecation:  "@1688169600",
+		DeprecatedAt: time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC),
+		Sunset:       time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Link:         "https://example.com/changelog",
+		Warnings:     []string{`299 - "Deprecated API"`},
+	}}, deprecations)
+}
+
+func TestParseDeprecationDate(t *testing.T) {
+	assert.Equal(t, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), parseDeprecationDate("Sat, 01 Jul 2023 00:00:00 GMT"))
+	assert.True(t, parseDeprecationDate("true").IsZero())
+	assert.Equal(t, "https://example.com/sunset", deprecationLink([]string{`<https://example.com/next>; rel="next", <https://example.com/sunset>; rel=sunset`}))
+}
@@ -0,0 +1,54 @@
+package paylike
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// MaxDescriptorLength is the maximum length of a descriptor shown on bank
+// statements
+const MaxDescriptorLength = 22
+
+// Reasons a descriptor is rejected for, wrapped by the errors returned by
+// ValidateDescriptor with the details
+var (
+	ErrDescriptorEmpty     = errors.New("paylike: descriptor is empty")
+	ErrDescriptorTooLong   = errors.New("paylike: descriptor is too long")
+	ErrDescriptorCharacter = errors.New("paylike: descriptor contains a character not allowed on bank statements")
+	ErrDescriptorSpace     = errors.New("paylike: descriptor starts or ends with a space")
+)
+
+// ValidateDescriptor checks the given descriptor against the rules of bank
+// statements: between 1 and 22 printable ASCII characters (space through
+// tilde), not starting or ending with a space
+// The returned error wraps one of the ErrDescriptor errors and tells the
+// offending length or character
+func ValidateDescriptor(s string) error {
+	if s == "" {
+		return ErrDescriptorEmpty
+	}
+	for i, r := range s {
+		if r < 0x20 || r > 0x7e {
+			if r == utf8.RuneError {
+				return fmt.Errorf("%w: invalid UTF-8 at byte %d", ErrDescriptorCharacter, i)
+			}
+			return fmt.Errorf("%w: %q at position %d", ErrDescriptorCharacter, r, utf8.RuneCountInString(s[:i])+1)
+		}
+	}
+	if len(s) > MaxDescriptorLength {
+		return fmt.Errorf("%w: %d characters, at most %d allowed", ErrDescriptorTooLong, len(s), MaxDescriptorLength)
+	}
+	if s[0] == ' ' || s[len(s)-1] == ' ' {
+		return ErrDescriptorSpace
+	}
+	return nil
+}
+
+// Validate checks the descriptor of the trail, if any
+func (d TransactionTrailDTO) Validate() error {
+	if d.Descriptor == "" {
+		return nil
+	}
+	return ValidateDescriptor(d.Descriptor)
+}
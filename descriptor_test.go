@@ -0,0 +1,54 @@
+package paylike
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateDescriptor(t *testing.T) {
+	for _, s := range []string{"SHOP", "Order 1234", "1234567897891234", "A", "Shop.com *Order #12-34"} {
+		assert.Nil(t, ValidateDescriptor(s), s)
+	}
+
+	assert.Equal(t, ErrDescriptorEmpty, ValidateDescriptor(""))
+	assert.Equal(t, ErrDescriptorSpace, ValidateDescriptor(" SHOP"))
+	assert.Equal(t, ErrDescriptorSpace, ValidateDescriptor("SHOP "))
+
+	err := ValidateDescriptor("A descriptor way too long")
+	assert.True(t, errors.Is(err, ErrDescriptorTooLong))
+	assert.EqualError(t, err, "paylike: descriptor is too long: 25 characters, at most 22 allowed")
+
+	err = ValidateDescriptor("Blåbær")
+	assert.True(t, errors.Is(err, ErrDescriptorCharacter))
+	assert.EqualError(t, err, "paylike: descriptor contains a character not allowed on bank statements: 'å' at position 3")
+	err = ValidateDescriptor("Tab\there")
+	assert.EqualError(t, err, `paylike: descriptor contains a character not allowed on bank statements: '\t' at position 4`)
+	err = ValidateDescriptor("Bad\xffUTF8")
+	assert.EqualError(t, err, "paylike: descriptor contains a character not allowed on bank statements: invalid UTF-8 at byte 3")
+}
+
+func TestTransactionTrailDTOValidate(t *testing.T) {
+	assert.Nil(t, TransactionTrailDTO{Amount: 100}.Validate())
+	assert.Nil(t, TransactionTrailDTO{Amount: 100, Descriptor: "Order 1234"}.Validate())
+	assert.True(t, errors.Is(TransactionTrailDTO{Amount: 100, Descriptor: "Ordre nr. ½"}.Validate(), ErrDescriptorCharacter))
+}
+
+func TestTrailDescriptorValidated(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+	}), WithCaptureGuard())
+	dto := TransactionTrailDTO{Amount: 100, Descriptor: "Ordre nr. ½"}
+	_, err := client.CaptureTransaction("tx1", dto)
+	assert.True(t, errors.Is(err, ErrDescriptorCharacter))
+	_, err = client.RefundTransaction("tx1", dto)
+	assert.True(t, errors.Is(err, ErrDescriptorCharacter))
+	_, err = client.VoidTransaction("tx1", dto)
+	assert.True(t, errors.Is(err, ErrDescriptorCharacter))
+	_, err = client.CreateTransaction("m1", TransactionDTO{Currency: "EUR", Amount: 100, Descriptor: dto.Descriptor})
+	assert.True(t, errors.Is(err, ErrDescriptorCharacter))
+	_, err = client.CreateMerchant(MerchantCreateDTO{Currency: "EUR", Descriptor: "A very long shop descriptor"})
+	assert.True(t, errors.Is(err, ErrDescriptorTooLong))
+}
@@ -0,0 +1,87 @@
+package paylike
+
+// TransactionDiff describes how a transaction changed between two snapshots,
+// amounts being in minor units
+type TransactionDiff struct {
+	From, To       TransactionStatus
+	Captured       int64    // amount captured in between
+	Refunded       int64    // amount refunded in between
+	Voided         int64    // amount voided in between
+	Disputed       int64    // change of the disputed amount
+	Failed         bool     // whether the transaction has failed in between
+	DisputesOpened []string // IDs of the disputes opened in between
+	DisputesWon    []string // IDs of the disputes won in between
+	DisputesLost   []string // IDs of the disputes lost in between
+}
+
+// Changed returns whether anything changed between the snapshots
+func (d TransactionDiff) Changed() bool {
+	return d.From != d.To || d.Captured != 0 || d.Refunded != 0 || d.Voided != 0 ||
+		d.Disputed != 0 || d.Failed || len(d.DisputesOpened) > 0 ||
+		len(d.DisputesWon) > 0 || len(d.DisputesLost) > 0
+}
+
+// Diff compares two snapshots of a transaction, e.g. from successive polls
+// or webhooks, to tell which events happened in between
+// A nil before snapshot is treated as a transaction nothing happened to yet
+func Diff(before, after *Transaction) TransactionDiff {
+	if before == nil {
+		before = &Transaction{}
+	}
+	if after == nil {
+		after = before
+	}
+	diff := TransactionDiff{
+		From:     before.Status(),
+		To:       after.Status(),
+		Captured: after.CapturedAmount - before.CapturedAmount,
+		Refunded: after.RefundedAmount - before.RefundedAmount,
+		Voided:   after.VoidedAmount - before.VoidedAmount,
+		Disputed: after.DisputedAmount - before.DisputedAmount,
+		Failed:   after.Error && !before.Error,
+	}
+	previous := disputes(before)
+	for _, dispute := range orderedDisputes(after) {
+		known, ok := previous[dispute.ID]
+		if !ok {
+			diff.DisputesOpened = append(diff.DisputesOpened, dispute.ID)
+		}
+		if dispute.Won && !known.Won {
+			diff.DisputesWon = append(diff.DisputesWon, dispute.ID)
+		}
+		if dispute.Lost && !known.Lost {
+			diff.DisputesLost = append(diff.DisputesLost, dispute.ID)
+		}
+	}
+	return diff
+}
+
+// disputes returns the disputes of the given transaction by ID
+func disputes(t *Transaction) map[string]TrailDispute {
+	byID := map[string]TrailDispute{}
+	for _, dispute := range orderedDisputes(t) {
+		byID[dispute.ID] = dispute
+	}
+	return byID
+}
+
+// orderedDisputes returns the disputes of the given transaction in the order
+// they appear in its trail, merging the outcomes of the trail entries of each
+func orderedDisputes(t *Transaction) []TrailDispute {
+	var ordered []TrailDispute
+	index := map[string]int{}
+	for _, trail := range t.Trail {
+		if trail == nil || trail.Dispute.ID == "" {
+			continue
+		}
+		i, ok := index[trail.Dispute.ID]
+		if !ok {
+			i = len(ordered)
+			index[trail.Dispute.ID] = i
+			ordered = append(ordered, TrailDispute{ID: trail.Dispute.ID})
+		}
+		ordered[i].Won = ordered[i].Won || trail.Dispute.Won
+		ordered[i].Lost = ordered[i].Lost || trail.Dispute.Lost
+	}
+	return ordered
+}
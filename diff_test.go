@@ -0,0 +1,40 @@
+package paylike
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	authorized := &Transaction{Amount: 100, PendingAmount: 100}
+	captured := &Transaction{Amount: 100, CapturedAmount: 100, Trail: []*TransactionTrail{{Amount: 100, Capture: true}}}
+	diff := Diff(authorized, captured)
+	assert.True(t, diff.Changed())
+	assert.Equal(t, TransactionAuthorized, diff.From)
+	assert.Equal(t, TransactionCaptured, diff.To)
+	assert.Equal(t, int64(100), diff.Captured)
+	assert.Empty(t, diff.DisputesOpened)
+
+	disputed := &Transaction{Amount: 100, CapturedAmount: 100, DisputedAmount: 100, Trail: []*TransactionTrail{
+		{Amount: 100, Capture: true},
+		{Amount: -100, Dispute: TrailDispute{ID: "d1"}},
+	}}
+	diff = Diff(captured, disputed)
+	assert.Equal(t, TransactionDisputed, diff.To)
+	assert.Equal(t, int64(100), diff.Disputed)
+	assert.Equal(t, []string{"d1"}, diff.DisputesOpened)
+
+	won := &Transaction{Amount: 100, CapturedAmount: 100, Trail: []*TransactionTrail{
+		{Amount: 100, Capture: true},
+		{Amount: -100, Dispute: TrailDispute{ID: "d1"}},
+		{Amount: 100, Dispute: TrailDispute{ID: "d1", Won: true}},
+	}}
+	diff = Diff(disputed, won)
+	assert.Equal(t, int64(-100), diff.Disputed)
+	assert.Empty(t, diff.DisputesOpened)
+	assert.Equal(t, []string{"d1"}, diff.DisputesWon)
+
+	assert.False(t, Diff(won, won).Changed())
+	assert.Equal(t, int64(100), Diff(nil, captured).Captured)
+}
@@ -0,0 +1,111 @@
+package paylike
+
+import "sort"
+
+// DisputeOutcome describes where the disputes of a transaction stand
+type DisputeOutcome string
+
+// Possible dispute outcomes
+const (
+	DisputeNone DisputeOutcome = ""     // never disputed
+	DisputeOpen DisputeOutcome = "open" // a dispute awaits its outcome
+	DisputeWon  DisputeOutcome = "won"  // the latest dispute was won by the merchant
+	DisputeLost DisputeOutcome = "lost" // the latest dispute was lost by the merchant
+)
+
+// outcome returns the outcome of the dispute
+func (d TrailDispute) outcome() DisputeOutcome {
+	switch {
+	case d.Won:
+		return DisputeWon
+	case d.Lost:
+		return DisputeLost
+	}
+	return DisputeOpen
+}
+
+// Disputes returns the disputes of the transaction in the order they were
+// opened, merging the outcomes recorded by later trail entries of each
+func (t Transaction) Disputes() []TrailDispute {
+	return orderedDisputes(&t)
+}
+
+// HasOpenDispute reports whether a dispute of the transaction awaits its outcome
+func (t Transaction) HasOpenDispute() bool {
+	for _, dispute := range t.Disputes() {
+		if dispute.outcome() == DisputeOpen {
+			return true
+		}
+	}
+	return false
+}
+
+// DisputeOutcome returns DisputeOpen if a dispute of the transaction awaits
+// its outcome and the outcome of the latest dispute otherwise
+func (t Transaction) DisputeOutcome() DisputeOutcome {
+	disputes := t.Disputes()
+	if len(disputes) == 0 {
+		return DisputeNone
+	}
+	if t.HasOpenDispute() {
+		return DisputeOpen
+	}
+	return disputes[len(disputes)-1].outcome()
+}
+
+// DisputeTotals describes the disputes of a set of transactions in a currency
+type DisputeTotals struct {
+	Currency string
+	Open     int   // number of open disputes
+	Won      int   // number of disputes won
+	Lost     int   // number of disputes lost
+	Amount   int64 // amount currently disputed in minor units
+}
+
+// TotalDisputes counts the disputes of the given transactions by outcome,
+// per currency and ordered by currency, skipping currencies without disputes
+func TotalDisputes(transactions []*Transaction) []DisputeTotals {
+	groups := map[string]*DisputeTotals{}
+	for _, transaction := range transactions {
+		disputes := transaction.Disputes()
+		if len(disputes) == 0 {
+			continue
+		}
+		totals, ok := groups[transaction.Currency]
+		if !ok {
+			totals = &DisputeTotals{Currency: transaction.Currency}
+			groups[transaction.Currency] = totals
+		}
+		totals.Amount += transaction.DisputedAmount
+		for _, dispute := range disputes {
+			switch dispute.outcome() {
+			case DisputeWon:
+				totals.Won++
+			case DisputeLost:
+				totals.Lost++
+			default:
+				totals.Open++
+			}
+		}
+	}
+	result := make([]DisputeTotals, 0, len(groups))
+	for _, totals := range groups {
+		result = append(result, *totals)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Currency < result[j].Currency
+	})
+	return result
+}
+
+// OpenDisputes returns the given transactions having an open dispute, e.g.
+// to triage chargebacks from listed transactions
+func OpenDisputes(transactions []*Transaction) []*Transaction {
+	var open []*Transaction
+	for _, transaction := range transactions {
+		if transaction.HasOpenDispute() {
+			open = append(open, transaction)
+		}
+	}
+	return open
+}
@@ -0,0 +1,43 @@
+package paylike
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisputes(t *testing.T) {
+	captured := &Transaction{Currency: "EUR", CapturedAmount: 100, Trail: []*TransactionTrail{{Amount: 100, Capture: true}}}
+	open := &Transaction{Currency: "EUR", CapturedAmount: 100, DisputedAmount: 100, Trail: []*TransactionTrail{
+		{Amount: 100, Capture: true},
+		{Amount: -100, Dispute: TrailDispute{ID: "d1"}},
+	}}
+	won := &Transaction{Currency: "DKK", CapturedAmount: 100, Trail: []*TransactionTrail{
+		{Amount: 100, Capture: true},
+		{Amount: -100, Dispute: TrailDispute{ID: "d2"}},
+		{Amount: 100, Dispute: TrailDispute{ID: "d2", Won: true}},
+	}}
+	reopened := &Transaction{Currency: "EUR", CapturedAmount: 100, DisputedAmount: 50, Trail: []*TransactionTrail{
+		{Amount: 100, Capture: true},
+		{Amount: -50, Dispute: TrailDispute{ID: "d3"}},
+		{Amount: 0, Dispute: TrailDispute{ID: "d3", Lost: true}},
+		{Amount: -50, Dispute: TrailDispute{ID: "d4"}},
+	}}
+
+	assert.False(t, captured.HasOpenDispute())
+	assert.Equal(t, DisputeNone, captured.DisputeOutcome())
+	assert.True(t, open.HasOpenDispute())
+	assert.Equal(t, DisputeOpen, open.DisputeOutcome())
+	assert.False(t, won.HasOpenDispute())
+	assert.Equal(t, DisputeWon, won.DisputeOutcome())
+	assert.Equal(t, []TrailDispute{{ID: "d2", Won: true}}, won.Disputes())
+	assert.Equal(t, DisputeOpen, reopened.DisputeOutcome())
+
+	transactions := []*Transaction{captured, open, won, reopened}
+	assert.Equal(t, []*Transaction{open, reopened}, OpenDisputes(transactions))
+	assert.Equal(t, []DisputeTotals{
+		{Currency: "DKK", Won: 1},
+		{Currency: "EUR", Open: 2, Lost: 1, Amount: 150},
+	}, TotalDisputes(transactions))
+	assert.Empty(t, TotalDisputes([]*Transaction{captured}))
+}
@@ -0,0 +1,165 @@
+package paylike
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrDryRunRejected is wrapped by the errors of dry runs the API would most
+// likely reject, e.g. captures exceeding the amount left to capture
+var ErrDryRunRejected = errors.New("paylike: dry run rejected")
+
+// dryRunOperations are the operations not sent in dry-run mode
+var dryRunOperations = map[string]bool{
+	OpCaptureTransaction.Name: true,
+	OpRefundTransaction.Name:  true,
+	OpVoidTransaction.Name:    true,
+	OpUpdateMerchant.Name:     true,
+}
+
+// WithDryRun makes the client validate captures, refunds, voids and merchant
+// updates without sending them, e.g. to rehearse a batch job against the
+// production configuration
+// The transaction or merchant is fetched and the change applied to it to
+// synthesize the result, and the call is reported to the audit sink, if any,
+// with DryRun set; reads and other writes are performed as usual
+func WithDryRun() Option {
+	return func(c *Client) {
+		c.dryRun = true
+	}
+}
+
+// simulate performs the given operation in dry-run mode, decoding the
+// synthesized result into the given value, if any
+func (c Client) simulate(op Operation, body interface{}, value interface{}, params ...string) (err error) {
+	req, err := c.newRequest(op, nil, params...)
+	if err != nil {
+		return err
+	}
+	if c.auditSink != nil {
+		record, release, recordErr := c.newDryRunRecord(req, op, body)
+		if recordErr != nil {
+			return recordErr
+		}
+		defer release()
+		defer func() {
+			c.audit(req.Context(), record, 0, 0, err)
+		}()
+	}
+	var result interface{}
+	switch dto := body.(type) {
+	case TransactionTrailDTO:
+		transaction, err := c.simulateTrail(op, TxID(params[0]), dto)
+		if err != nil {
+			return err
+		}
+		result = map[string]interface{}{"transaction": transaction}
+	case MerchantUpdateDTO:
+		merchant, err := c.simulateMerchantUpdate(MerchantID(params[0]), dto)
+		if err != nil {
+			return err
+		}
+		result = map[string]interface{}{"merchant": merchant}
+	default:
+		return fmt.Errorf("paylike: dry run of %s is not supported", op.Name)
+	}
+	if value == nil {
+		return nil
+	}
+	b, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, value)
+}
+
+// newDryRunRecord starts the audit record of the given operation simulated
+// in dry-run mode, returning the function releasing the body of the request
+func (c Client) newDryRunRecord(req *http.Request, op Operation, body interface{}) (*AuditRecord, func(), error) {
+	var err error
+	if c.Key, err = c.resolveKey(req.Context()); err != nil {
+		return nil, nil, err
+	}
+	release, err := c.setBody(req, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	record := c.newAuditRecord(req, op)
+	record.DryRun = true
+	return record, release, nil
+}
+
+// simulateTrail returns the given transaction as it would be after the
+// given capture, refund or void
+func (c Client) simulateTrail(op Operation, transactionID TxID, dto TransactionTrailDTO) (*Transaction, error) {
+	if err := dto.Validate(); err != nil {
+		return nil, err
+	}
+	transaction, err := c.fetchFreshTransaction(transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if transaction == nil {
+		return nil, fmt.Errorf("%w: transaction %s not found", ErrDryRunRejected, transactionID)
+	}
+	if dto.Currency != "" && dto.Currency != transaction.Currency {
+		return nil, fmt.Errorf("%w: currency %s does not match the transaction currency %s", ErrDryRunRejected, dto.Currency, transaction.Currency)
+	}
+	available := transaction.PendingAmount
+	if op == OpRefundTransaction {
+		available = transaction.CapturedAmount - transaction.RefundedAmount
+	}
+	if dto.Amount <= 0 || dto.Amount > available {
+		return nil, fmt.Errorf("%w: amount must be between 1 and %d", ErrDryRunRejected, available)
+	}
+	switch op {
+	case OpCaptureTransaction:
+		transaction.PendingAmount -= dto.Amount
+		transaction.CapturedAmount += dto.Amount
+	case OpRefundTransaction:
+		transaction.RefundedAmount += dto.Amount
+	case OpVoidTransaction:
+		transaction.PendingAmount -= dto.Amount
+		transaction.VoidedAmount += dto.Amount
+	}
+	transaction.Trail = append(transaction.Trail, &TransactionTrail{
+		Amount:     dto.Amount,
+		Created:    c.now().UTC().Format(time.RFC3339),
+		Capture:    op == OpCaptureTransaction,
+		Descriptor: dto.Descriptor,
+	})
+	return transaction, nil
+}
+
+// simulateMerchantUpdate returns the given merchant as it would be after the
+// given update
+func (c Client) simulateMerchantUpdate(merchantID MerchantID, dto MerchantUpdateDTO) (*Merchant, error) {
+	if dto.Descriptor != "" {
+		if err := ValidateDescriptor(dto.Descriptor); err != nil {
+			return nil, err
+		}
+	}
+	merchant, err := getWrapped[Merchant](c.with([]CallOption{SkipCache()}), OpGetMerchant, nil, "merchant", string(merchantID))
+	if err != nil {
+		return nil, err
+	}
+	if merchant == nil {
+		return nil, fmt.Errorf("%w: merchant %s not found", ErrDryRunRejected, merchantID)
+	}
+	if dto.Name != "" {
+		merchant.Name = dto.Name
+	}
+	if dto.Email != "" {
+		merchant.Email = dto.Email
+	}
+	if dto.Descriptor != "" {
+		merchant.Descriptor = dto.Descriptor
+	}
+	if dto.TDS != nil {
+		merchant.TDS = *dto.TDS
+	}
+	return merchant, nil
+}
@@ -0,0 +1,72 @@
+package paylike
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDryRun(t *testing.T) {
+	var records []AuditRecord
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected %s %s in dry run", r.Method, r.URL.Path)
+			return
+		}
+		if r.URL.Path == "/merchants/m1" {
+			w.Write([]byte(`{"merchant":{"id":"m1","name":"Shop","descriptor":"SHOP"}}`))
+			return
+		}
+		w.Write([]byte(`{"transaction":{"id":"tx1","currency":"EUR","amount":100,"pendingAmount":100}}`))
+	}), WithDryRun(), WithAuditSink(AuditSinkFunc(func(ctx context.Context, record AuditRecord) {
+		records = append(records, record)
+	})))
+
+	transaction, err := client.CaptureTransaction("tx1", TransactionTrailDTO{Amount: 60, Descriptor: "Order 42"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(60), transaction.CapturedAmount)
+	assert.Equal(t, int64(40), transaction.PendingAmount)
+	assert.Len(t, transaction.Trail, 1)
+	assert.True(t, transaction.Trail[0].Capture)
+
+	_, err = client.RefundTransaction("tx1", TransactionTrailDTO{Amount: 10})
+	assert.True(t, errors.Is(err, ErrDryRunRejected))
+	assert.Equal(t, "paylike: dry run rejected: amount must be between 1 and 0", err.Error())
+	_, err = client.VoidTransaction("tx1", TransactionTrailDTO{Amount: 100, Currency: "DKK"})
+	assert.True(t, errors.Is(err, ErrDryRunRejected))
+
+	merchant, err := client.UpdateMerchantAndFetch("m1", MerchantUpdateDTO{Descriptor: "SHOP 2"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Shop", merchant.Name)
+	assert.Equal(t, "SHOP 2", merchant.Descriptor)
+	assert.Nil(t, client.UpdateMerchantTDS("m1", TDSModeFull))
+	err = client.UpdateMerchant("m1", MerchantUpdateDTO{Descriptor: "Ünicode"})
+	assert.True(t, errors.Is(err, ErrDescriptorCharacter))
+
+	assert.Len(t, records, 6)
+	assert.True(t, records[0].DryRun)
+	assert.Equal(t, OpCaptureTransaction, records[0].Operation)
+	assert.Equal(t, `{"amount":60,"descriptor":"Anonymized"}`, string(records[0].Payload))
+	assert.True(t, errors.Is(records[1].Err, ErrDryRunRejected))
+	assert.Equal(t, MerchantID("m1"), records[3].MerchantID)
+}
+
+func TestDryRunNotFound(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected %s %s in dry run", r.Method, r.URL.Path)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}), WithDryRun())
+
+	_, err := client.CaptureTransaction("tx1", TransactionTrailDTO{Amount: 60})
+	assert.True(t, errors.Is(err, ErrDryRunRejected))
+	assert.Equal(t, "paylike: dry run rejected: transaction tx1 not found", err.Error())
+	err = client.UpdateMerchant("m1", MerchantUpdateDTO{Name: "Shop"})
+	assert.True(t, errors.Is(err, ErrDryRunRejected))
+	assert.Equal(t, "paylike: dry run rejected: merchant m1 not found", err.Error())
+}
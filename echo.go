@@ -0,0 +1,39 @@
+package paylike
+
+import (
+	"fmt"
+)
+
+// MerchantWarning describes a field of a created merchant whose value differs
+// from the requested one, e.g. after the API normalized it
+type MerchantWarning struct {
+	Field     string // e.g. "descriptor" or "company.country"
+	Requested string
+	Returned  string
+}
+
+// String returns the field along with both values
+func (w MerchantWarning) String() string {
+	return fmt.Sprintf("%s: requested %q, returned %q", w.Field, w.Requested, w.Returned)
+}
+
+// merchantWarnings compares the fields of the created merchant echoed by the
+// API with the request, ignoring the fields the API did not return
+func merchantWarnings(dto MerchantCreateDTO, merchant *Merchant) []MerchantWarning {
+	var company MerchantCompany
+	if dto.Company != nil {
+		company = *dto.Company
+	}
+	var warnings []MerchantWarning
+	for _, field := range []MerchantWarning{
+		{"currency", dto.Currency, merchant.Currency},
+		{"descriptor", dto.Descriptor, merchant.Descriptor},
+		{"company.country", company.Country, merchant.Company.Country},
+		{"company.number", company.Number, merchant.Company.Number},
+	} {
+		if field.Returned != "" && field.Returned != field.Requested {
+			warnings = append(warnings, field)
+		}
+	}
+	return warnings
+}
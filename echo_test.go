@@ -0,0 +1,35 @@
+package paylike
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateMerchantWarnings(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"merchant":{"id":"m1","currency":"EUR","descriptor":"MY SHOP","company":{"country":"DK"}}}`))
+	}))
+
+	merchant, err := client.CreateMerchant(MerchantCreateDTO{
+		Currency:   "EUR",
+		Email:      "shop@example.com",
+		Website:    "https://example.com",
+		Descriptor: "My Shop",
+		Company:    &MerchantCompany{Country: "DK", Number: "12345678"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []MerchantWarning{{"descriptor", "My Shop", "MY SHOP"}}, merchant.Warnings)
+	assert.Equal(t, `descriptor: requested "My Shop", returned "MY SHOP"`, merchant.Warnings[0].String())
+}
+
+func TestCreateMerchantNoWarnings(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"merchant":{"id":"m1"}}`))
+	}))
+
+	merchant, err := client.CreateMerchant(MerchantCreateDTO{Currency: "EUR", Descriptor: "My Shop"})
+	assert.Nil(t, err)
+	assert.Nil(t, merchant.Warnings)
+}
@@ -0,0 +1,39 @@
+package paylike
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+)
+
+// ErrInvalidEmail is returned when an email address is malformed
+var ErrInvalidEmail = errors.New("paylike: invalid email address")
+
+// NormalizeEmail trims the given email address and lowercases its domain,
+// checking it is a bare RFC 5322 address with a dotted domain, e.g.
+// "Jane@Example.COM " becomes "Jane@example.com"
+// The local part is kept as is, being case sensitive in principle; quoted
+// local parts aren't accepted
+func NormalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Name != "" || address.Address != email {
+		return "", ErrInvalidEmail
+	}
+	at := strings.LastIndex(email, "@")
+	local, domain := email[:at], strings.ToLower(email[at+1:])
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") || strings.Contains(domain, "..") {
+		return "", ErrInvalidEmail
+	}
+	return local + "@" + domain, nil
+}
+
+// normalize normalizes the email of the update, if any
+func (dto *MerchantUpdateDTO) normalize() error {
+	if dto.Email == "" {
+		return nil
+	}
+	email, err := NormalizeEmail(dto.Email)
+	dto.Email = email
+	return err
+}
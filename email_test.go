@@ -0,0 +1,59 @@
+package paylike
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	for input, expected := range map[string]string{
+		"jane@example.com":          "jane@example.com",
+		" Jane.Doe@Example.COM\n":   "Jane.Doe@example.com",
+		"jane+shop@sub.example.dk":  "jane+shop@sub.example.dk",
+		"JANE@EXAMPLE.CO.UK":        "JANE@example.co.uk",
+		"o'brien@example.ie":        "o'brien@example.ie",
+		"jane_doe-99@xn--bcher.com": "jane_doe-99@xn--bcher.com",
+	} {
+		email, err := NormalizeEmail(input)
+		assert.Nil(t, err, input)
+		assert.Equal(t, expected, email, input)
+	}
+	for _, input := range []string{"", "jane", "jane@", "@example.com", "jane@example", "jane@@example.com", "Jane <jane@example.com>", "jane@example..com", "jane@.example.com", "jane doe@example.com"} {
+		_, err := NormalizeEmail(input)
+		assert.Equal(t, ErrInvalidEmail, err, input)
+	}
+}
+
+func TestEmailNormalizedBeforeSending(t *testing.T) {
+	var emails []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ Email string }
+		json.NewDecoder(r.Body).Decode(&body)
+		emails = append(emails, body.Email)
+		switch r.URL.Path {
+		case "/merchants":
+			w.Write([]byte(`{"merchant":{"id":"m1"}}`))
+		case "/merchants/m1/users":
+			w.Write([]byte(`{"isMember":false}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+
+	_, err := client.CreateMerchant(MerchantCreateDTO{Currency: "DKK", Email: " Shop@Example.COM "})
+	assert.Nil(t, err)
+	assert.Nil(t, client.UpdateMerchant("m1", MerchantUpdateDTO{Email: "Billing@EXAMPLE.com"}))
+	_, err = client.InviteUserToMerchant("m1", "Jane@Example.com")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Shop@example.com", "Billing@example.com", "Jane@example.com"}, emails)
+
+	_, err = client.InviteUserToMerchant("m1", "jane@example")
+	assert.Equal(t, ErrInvalidEmail, err)
+	_, err = client.CreateMerchant(MerchantCreateDTO{Currency: "DKK", Email: "shop"})
+	assert.Equal(t, ErrInvalidEmail, err)
+	assert.Equal(t, ErrInvalidEmail, client.UpdateMerchant("m1", MerchantUpdateDTO{Email: "billing"}))
+	assert.Len(t, emails, 3)
+}
@@ -0,0 +1,151 @@
+package paylike
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErasureRequest describes the data of a customer to erase on a data subject
+// request, identified by a custom field the merchant stores with the data
+type ErasureRequest struct {
+	MerchantID  MerchantID
+	CustomKey   string      // custom field identifying the customer, e.g. "customerId"
+	CustomValue interface{} // value of the custom field, compared as in FindTransactionsByCustom
+	CardIDs     []CardToken // optional, saved cards of the customer, as the API does not list cards
+	Pagination              // optional, how pages of transactions are fetched
+}
+
+// ErasureAction describes what has been done to a record of the customer
+type ErasureAction string
+
+// Possible erasure actions
+const (
+	ErasureAnonymized ErasureAction = "anonymized" // personal data removed from the record
+	ErasureRetained   ErasureAction = "retained"   // the API does not allow removing the data
+	ErasureFailed     ErasureAction = "failed"     // removing the data failed, see Error
+)
+
+// ErasureItem describes what has been done to a single record
+type ErasureItem struct {
+	Kind   string // "transaction" or "card"
+	ID     string // ID of the transaction or card
+	Action ErasureAction
+	Reason string // why the data has been retained, if it has
+	Error  error  // why removing the data failed, if it did
+}
+
+// ErasureReport describes the outcome of an erasure, kept as evidence of
+// how a data subject request has been handled
+type ErasureReport struct {
+	Request  ErasureRequest
+	Started  time.Time
+	Finished time.Time
+	Items    []ErasureItem
+}
+
+// Complete reports whether no data could have been removed but wasn't,
+// retained records aside
+func (r ErasureReport) Complete() bool {
+	for _, item := range r.Items {
+		if item.Action == ErasureFailed {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteTo writes the report in a human readable form, one record per line
+func (r ErasureReport) WriteTo(w io.Writer) (int64, error) {
+	var n int64
+	write := func(format string, args ...interface{}) error {
+		m, err := fmt.Fprintf(w, format, args...)
+		n += int64(m)
+		return err
+	}
+	if err := write("erasure of %s=%v at merchant %s from %s to %s\n", r.Request.CustomKey, r.Request.CustomValue,
+		r.Request.MerchantID, r.Started.Format(time.RFC3339), r.Finished.Format(time.RFC3339)); err != nil {
+		return n, err
+	}
+	for _, item := range r.Items {
+		detail := item.Reason
+		if item.Error != nil {
+			detail = item.Error.Error()
+		}
+		if detail != "" {
+			detail = " (" + detail + ")"
+		}
+		if err := write("%s %s: %s%s\n", item.Kind, item.ID, item.Action, detail); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// ErrErasureUnconfirmed is reported for a card still holding notes or custom
+// data when fetched after having been cleared
+var ErrErasureUnconfirmed = errors.New("paylike: card still holds notes or custom data after erasure")
+
+// transactionRetention is the reason transactions are retained
+const transactionRetention = "transactions cannot be changed or deleted through the API and are kept for bookkeeping"
+
+// EraseCustomer erases what the API allows of the data of the customer
+// identified by the given request: the notes and custom data of the given
+// saved cards are cleared, and only reported as anonymized once fetching the
+// card confirms it, while the transactions carrying the custom field are
+// reported as retained
+// The returned error is only set if the transactions could not be listed,
+// failures to clear single cards are reported in the items of the report
+func (c Client) EraseCustomer(req ErasureRequest, opts ...CallOption) (*ErasureReport, error) {
+	report := &ErasureReport{Request: req, Started: c.now()}
+	transactions, err := c.FindTransactionsByCustom(req.MerchantID, req.CustomKey, req.CustomValue, CustomSearch{Pagination: req.Pagination}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, transaction := range transactions {
+		report.Items = append(report.Items, ErasureItem{
+			Kind:   "transaction",
+			ID:     string(transaction.ID),
+			Action: ErasureRetained,
+			Reason: transactionRetention,
+		})
+	}
+	for _, cardID := range req.CardIDs {
+		item := ErasureItem{Kind: "card", ID: string(cardID), Action: ErasureAnonymized}
+		if err := c.eraseCard(cardID, opts); err != nil {
+			item.Action = ErasureFailed
+			item.Error = err
+		}
+		report.Items = append(report.Items, item)
+	}
+	report.Finished = c.now()
+	return report, nil
+}
+
+// cardErasure is the update clearing the notes and custom data of a card,
+// sending both explicitly as CardUpdateDTO omits empty custom data
+type cardErasure struct {
+	Notes  string                 `json:"notes"`
+	Custom map[string]interface{} `json:"custom"`
+}
+
+// eraseCard clears the notes and custom data of the given card and fetches
+// it to confirm they are gone
+func (c Client) eraseCard(cardID CardToken, opts []CallOption) error {
+	c = c.with(opts)
+	if err := c.execute(OpUpdateCard, cardErasure{Custom: map[string]interface{}{}}, nil, string(cardID)); err != nil {
+		return err
+	}
+	card, err := c.FetchCard(cardID, SkipCache())
+	if err != nil {
+		return err
+	}
+	if card == nil {
+		return errors.New("paylike: no card in response")
+	}
+	if card.Notes != "" || len(card.Custom) > 0 {
+		return ErrErasureUnconfirmed
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package paylike
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEraseCustomer(t *testing.T) {
+	updates := map[string]map[string]interface{}{}
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/cards/") && r.Method == http.MethodGet {
+			if r.URL.Path == "/cards/c3" {
+				w.Write([]byte(`{"card":{"id":"c3","notes":"","custom":{"customerId":"u1"}}}`))
+				return
+			}
+			w.Write([]byte(`{"card":{"id":"c1","notes":"","custom":{}}}`))
+			return
+		}
+		if r.Method == http.MethodPut {
+			if strings.HasSuffix(r.URL.Path, "/c2") {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			updates[r.URL.Path] = body
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Write([]byte(`[{"id":"tx2","custom":{"customerId":"u1"}},{"id":"tx1","custom":{"customerId":"u2"}}]`))
+	}), WithClock(ClockFunc(func() time.Time { return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) })))
+
+	report, err := client.EraseCustomer(ErasureRequest{
+		MerchantID:  TestMerchant,
+		CustomKey:   "customerId",
+		CustomValue: "u1",
+		CardIDs:     []CardToken{"c1", "c2", "c3"},
+	})
+	assert.Nil(t, err)
+	assert.Len(t, report.Items, 4)
+	assert.Equal(t, ErasureItem{Kind: "transaction", ID: "tx2", Action: ErasureRetained, Reason: transactionRetention}, report.Items[0])
+	assert.Equal(t, ErasureItem{Kind: "card", ID: "c1", Action: ErasureAnonymized}, report.Items[1])
+	assert.Equal(t, ErasureFailed, report.Items[2].Action)
+	assert.True(t, IsClientError(report.Items[2].Error))
+	assert.Equal(t, ErasureItem{Kind: "card", ID: "c3", Action: ErasureFailed, Error: ErrErasureUnconfirmed}, report.Items[3])
+	assert.False(t, report.Complete())
+	assert.Equal(t, map[string]interface{}{"notes": "", "custom": map[string]interface{}{}}, updates["/cards/c1"])
+
+	var b bytes.Buffer
+	_, err = report.WriteTo(&b)
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	assert.Len(t, lines, 5)
+	assert.Equal(t, "erasure of customerId=u1 at merchant "+TestMerchant+" from 2024-01-01T00:00:00Z to 2024-01-01T00:00:00Z", lines[0])
+	assert.Equal(t, "card c1: anonymized", lines[2])
+	assert.Equal(t, "card c2: failed (paylike: 404 Not Found)", lines[3])
+}
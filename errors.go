@@ -0,0 +1,188 @@
+package paylike
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// maxErrorBodySize limits how much of an error response body is read
+const maxErrorBodySize = 64 << 10
+
+// APIError describes an error response returned by the API
+type APIError struct {
+	StatusCode int
+	Operation  Operation
+	Code       string        // error code, if any
+	Message    string        // human readable error message, if any
+	Details    []ErrorDetail // field-level validation errors, if any
+	Body       []byte        // raw response body (truncated to 64 KiB)
+}
+
+// ErrorDetail describes a field-level error of a rejected request
+type ErrorDetail struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	Code    string `json:"code,omitempty"`
+}
+
+// Error returns the status code along with the error message and details
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("paylike: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	for i, detail := range e.Details {
+		sep := "; "
+		if i == 0 {
+			sep = ": "
+		}
+		msg += sep + detail.String()
+	}
+	return msg
+}
+
+// String returns the field along with its error message
+func (d ErrorDetail) String() string {
+	if d.Field == "" {
+		return d.Message
+	}
+	return fmt.Sprintf("%s: %s", d.Field, d.Message)
+}
+
+// Retryable returns whether the request may succeed when repeated,
+// which is the case for rate limiting and temporary server failures
+func (e *APIError) Retryable() bool {
+	switch e.StatusCode {
+	case http.StatusRequestTimeout,
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// RetryableError is implemented by errors knowing whether the failed request
+// may succeed when repeated; it is consulted by IsRetryable and thereby by
+// the retry policies of the client
+type RetryableError interface {
+	error
+	Retryable() bool
+}
+
+// IsRetryable returns whether the request that failed with the given error
+// may succeed when repeated, e.g. on rate limiting, temporary server failures,
+// timeouts and dropped connections
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var retryable RetryableError
+	if errors.As(err, &retryable) {
+		return retryable.Retryable()
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// IsNotProcessed returns whether the request that failed with the given
+// error has certainly not been processed by the API, so repeating it cannot
+// move money twice: the connection could not be established, the API refused
+// to handle the request (429 and 503) or the client did not send it at all
+// Timeouts and dropped connections are retryable but not known to be unprocessed
+func IsNotProcessed(err error) bool {
+	if errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrConcurrencyLimit) {
+		return true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode == http.StatusServiceUnavailable
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// IsClientError returns whether the API rejected the request with a 4xx status
+func IsClientError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode >= 400 && apiErr.StatusCode < 500
+}
+
+// IsServerError returns whether the API failed the request with a 5xx status
+func IsServerError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode >= 500
+}
+
+// errorBody describes the different shapes of error response bodies
+type errorBody struct {
+	Code    json.RawMessage `json:"code"`
+	Message string          `json:"message"`
+	Text    string          `json:"text"`
+	Errors  []errorField    `json:"errors"`
+}
+
+// errorField describes a field-level error in an error response body
+type errorField struct {
+	Field   string          `json:"field"`
+	Message string          `json:"message"`
+	Code    json.RawMessage `json:"code"`
+}
+
+// newAPIError builds an APIError from an error response, parsing the
+// error details from its body
+func newAPIError(op Operation, resp *http.Response) error {
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err != nil {
+		return err
+	}
+	apiErr := &APIError{StatusCode: resp.StatusCode, Operation: op, Body: b}
+	var fields []errorField
+	if json.Unmarshal(b, &fields) == nil {
+		apiErr.Details = toErrorDetails(fields)
+		return apiErr
+	}
+	var body errorBody
+	if json.Unmarshal(b, &body) == nil {
+		apiErr.Code = rawCode(body.Code)
+		apiErr.Message = body.Message
+		if apiErr.Message == "" {
+			apiErr.Message = body.Text
+		}
+		apiErr.Details = toErrorDetails(body.Errors)
+	}
+	return apiErr
+}
+
+// toErrorDetails converts the field errors of a response body to ErrorDetails
+func toErrorDetails(fields []errorField) []ErrorDetail {
+	var details []ErrorDetail
+	for _, f := range fields {
+		details = append(details, ErrorDetail{Field: f.Field, Message: f.Message, Code: rawCode(f.Code)})
+	}
+	return details
+}
+
+// rawCode converts a numeric or string error code to a string
+func rawCode(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	return strings.Trim(string(raw), `"`)
+}
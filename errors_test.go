@@ -0,0 +1,86 @@
+package paylike
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newErrorTestClient(t *testing.T, status int, body string, opts ...Option) *Client {
+	return newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}), opts...)
+}
+
+func TestAPIErrorFieldDetails(t *testing.T) {
+	client := newErrorTestClient(t, http.StatusBadRequest, `[{"field":"currency","message":"must be a valid currency"},{"field":"descriptor","message":"too long"}]`)
+	_, err := client.CreateMerchant(MerchantCreateDTO{Currency: "XXX"})
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.Equal(t, OpCreateMerchant, apiErr.Operation)
+	assert.Equal(t, []ErrorDetail{
+		{Field: "currency", Message: "must be a valid currency"},
+		{Field: "descriptor", Message: "too long"},
+	}, apiErr.Details)
+	assert.Equal(t, "paylike: 400 Bad Request: currency: must be a valid currency; descriptor: too long", err.Error())
+}
+
+func TestAPIErrorObject(t *testing.T) {
+	client := newErrorTestClient(t, http.StatusBadRequest, `{"code":2,"text":"Amount exceeds","errors":[{"field":"amount","message":"too high","code":"max"}]}`)
+	_, err := client.CaptureTransaction("tx1", TransactionTrailDTO{Amount: 1000})
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "2", apiErr.Code)
+	assert.Equal(t, "Amount exceeds", apiErr.Message)
+	assert.Equal(t, []ErrorDetail{{Field: "amount", Message: "too high", Code: "max"}}, apiErr.Details)
+}
+
+func TestAPIErrorUnparsable(t *testing.T) {
+	client := newErrorTestClient(t, http.StatusBadGateway, `<html>bad gateway</html>`)
+	err := client.RevokeAppFromMerchant(TestMerchant, "app")
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+	assert.Empty(t, apiErr.Details)
+	assert.Equal(t, "<html>bad gateway</html>", string(apiErr.Body))
+	assert.Equal(t, "paylike: 502 Bad Gateway", err.Error())
+}
+
+func TestErrorClassification(t *testing.T) {
+	client := newErrorTestClient(t, http.StatusServiceUnavailable, ``)
+	_, err := client.FetchApp()
+	assert.True(t, IsRetryable(err))
+	assert.True(t, IsServerError(err))
+	assert.False(t, IsClientError(err))
+
+	client = newErrorTestClient(t, http.StatusBadRequest, `[]`)
+	_, err = client.FetchApp()
+	assert.False(t, IsRetryable(err))
+	assert.False(t, IsServerError(err))
+	assert.True(t, IsClientError(err))
+
+	client = newErrorTestClient(t, http.StatusTooManyRequests, ``)
+	_, err = client.FetchApp()
+	assert.True(t, IsRetryable(err))
+	assert.True(t, IsClientError(err))
+
+	down := true
+	client = newFlakyTestClient(t, &down)
+	_, err = client.FetchApp()
+	assert.True(t, IsRetryable(err))
+	assert.False(t, IsClientError(err))
+
+	client = NewClient(TestKey)
+	client.baseAPI = "http://127.0.0.1:1"
+	_, err = client.FetchApp()
+	assert.True(t, IsRetryable(err))
+
+	assert.False(t, IsRetryable(nil))
+	assert.False(t, IsRetryable(context.Canceled))
+	assert.False(t, IsRetryable(errors.New("paylike: unknown")))
+}
@@ -1,7 +1,10 @@
 module github.com/paylike/go-api
 
-go 1.13
+go 1.18
 
 require github.com/stretchr/testify v1.3.0
 
-require github.com/davecgh/go-spew v1.1.1 // indirect
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+)
@@ -0,0 +1,100 @@
+package paylike
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// CaptureRefusedError is returned by CaptureTransaction when the capture
+// guard finds the transaction voided or disputed
+type CaptureRefusedError struct {
+	Transaction *Transaction      // the transaction as fetched before capturing
+	Status      TransactionStatus // TransactionVoided or TransactionDisputed
+}
+
+// Error returns why the capture was refused
+func (e *CaptureRefusedError) Error() string {
+	return fmt.Sprintf("paylike: refusing to capture %s transaction %s", e.Status, e.Transaction.ID)
+}
+
+// ErrNoTransaction is returned when fetching a transaction before or after
+// a capture yields a response without a transaction
+var ErrNoTransaction = errors.New("paylike: no transaction in response")
+
+// WithCaptureGuard makes CaptureTransaction fetch the transaction before
+// capturing it, bypassing any cache, and refuse with a CaptureRefusedError
+// if any amount of it has been voided or it has been disputed, e.g. when a
+// cancellation flow may void transactions a capture job is about to capture
+// This narrows the race between voids and captures but cannot rule it out
+func WithCaptureGuard() Option {
+	return func(c *Client) {
+		c.captureGuard = true
+	}
+}
+
+// guardCapture fetches the given transaction and refuses to capture it if
+// it has been voided or disputed
+func (c Client) guardCapture(transactionID TxID) error {
+	transaction, err := c.fetchFreshTransaction(transactionID)
+	if err != nil {
+		return err
+	}
+	if transaction == nil {
+		return ErrNoTransaction
+	}
+	switch {
+	case transaction.DisputedAmount > 0 || transaction.HasOpenDispute():
+		return &CaptureRefusedError{Transaction: transaction, Status: TransactionDisputed}
+	case transaction.VoidedAmount > 0:
+		return &CaptureRefusedError{Transaction: transaction, Status: TransactionVoided}
+	}
+	return nil
+}
+
+// ErrAmountExceeded is matched by the errors of captures rejected for
+// exceeding the amount left to capture, see AmountExceededError
+var ErrAmountExceeded = errors.New("paylike: amount exceeds the amount left to capture")
+
+// AmountExceededError is returned by CaptureTransaction when the API rejects
+// a capture exceeding the amount left to capture, e.g. because a competing
+// worker captured the same transaction first
+type AmountExceededError struct {
+	Requested   int64        // amount of the rejected capture in minor units
+	Remaining   int64        // amount left to capture in minor units
+	Transaction *Transaction // the transaction as fetched after the rejection
+	Err         error        // the error the API rejected the capture with
+}
+
+// Error returns the requested and remaining amounts
+func (e *AmountExceededError) Error() string {
+	return fmt.Sprintf("paylike: capture of %d exceeds the %d left to capture of transaction %s", e.Requested, e.Remaining, e.Transaction.ID)
+}
+
+// Is reports whether the target is ErrAmountExceeded
+func (e *AmountExceededError) Is(target error) bool {
+	return target == ErrAmountExceeded
+}
+
+// Unwrap returns the error the API rejected the capture with
+func (e *AmountExceededError) Unwrap() error {
+	return e.Err
+}
+
+// explainCaptureError fetches the transaction of a capture rejected with the
+// given error, returning an AmountExceededError if the requested amount
+// exceeds the amount left to capture and the given error otherwise
+func (c Client) explainCaptureError(transactionID TxID, amount int64, err error) error {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || amount <= 0 {
+		return err
+	}
+	if _, ok := DeclineCodes[DeclineCode(err)]; ok {
+		return err
+	}
+	transaction, fetchErr := c.fetchFreshTransaction(transactionID)
+	if fetchErr != nil || transaction == nil || amount <= transaction.PendingAmount {
+		return err
+	}
+	return &AmountExceededError{Requested: amount, Remaining: transaction.PendingAmount, Transaction: transaction, Err: err}
+}
@@ -0,0 +1,92 @@
+package paylike
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCaptureGuard(t *testing.T) {
+	var found string
+	captures := 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"transaction":` + found + `}`))
+			return
+		}
+		captures++
+		w.Write([]byte(`{"transaction":{"id":"tx1","capturedAmount":100}}`))
+	}), WithCaptureGuard(), WithCache(NewMemoryCache(10), time.Minute))
+
+	found = `{"id":"tx1","amount":100,"pendingAmount":100}`
+	transaction, err := client.CaptureTransaction("tx1", TransactionTrailDTO{Amount: 100})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(100), transaction.CapturedAmount)
+	assert.Equal(t, 1, captures)
+
+	found = `{"id":"tx1","amount":100,"voidedAmount":100}`
+	_, err = client.CaptureTransaction("tx1", TransactionTrailDTO{Amount: 100})
+	var refused *CaptureRefusedError
+	assert.True(t, errors.As(err, &refused))
+	assert.Equal(t, TransactionVoided, refused.Status)
+	assert.Equal(t, int64(100), refused.Transaction.VoidedAmount)
+	assert.Equal(t, "paylike: refusing to capture voided transaction tx1", err.Error())
+
+	found = `{"id":"tx1","amount":100,"pendingAmount":100,"trail":[{"amount":-100,"dispute":{"id":"d1"}}]}`
+	_, err = client.CaptureTransaction("tx1", TransactionTrailDTO{Amount: 100})
+	assert.True(t, errors.As(err, &refused))
+	assert.Equal(t, TransactionDisputed, refused.Status)
+
+	found = `null`
+	_, err = client.CaptureTransaction("tx1", TransactionTrailDTO{Amount: 100})
+	assert.Equal(t, ErrNoTransaction, err)
+	assert.Equal(t, 1, captures)
+}
+
+func TestAmountExceeded(t *testing.T) {
+	fetches := 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			fetches++
+			w.Write([]byte(`{"transaction":{"id":"tx1","amount":100,"capturedAmount":70,"pendingAmount":30}}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":"AMOUNT_INVALID","message":"amount must be between 1 and 30"}`))
+	}))
+
+	_, err := client.CaptureTransaction("tx1", TransactionTrailDTO{Amount: 50})
+	assert.True(t, errors.Is(err, ErrAmountExceeded))
+	assert.True(t, IsClientError(err))
+	var exceeded *AmountExceededError
+	assert.True(t, errors.As(err, &exceeded))
+	assert.Equal(t, int64(50), exceeded.Requested)
+	assert.Equal(t, int64(30), exceeded.Remaining)
+	assert.Equal(t, int64(70), exceeded.Transaction.CapturedAmount)
+	assert.Equal(t, "paylike: capture of 50 exceeds the 30 left to capture of transaction tx1", err.Error())
+
+	_, err = client.CaptureTransaction("tx1", TransactionTrailDTO{Amount: 20})
+	assert.False(t, errors.Is(err, ErrAmountExceeded))
+	assert.True(t, IsClientError(err))
+	assert.Equal(t, 2, fetches)
+}
+
+func TestAmountExceededWithoutTransaction(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":"AMOUNT_INVALID","message":"amount must be between 1 and 30"}`))
+	}))
+
+	_, err := client.CaptureTransaction("tx1", TransactionTrailDTO{Amount: 50})
+	assert.False(t, errors.Is(err, ErrAmountExceeded))
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+}
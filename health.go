@@ -0,0 +1,50 @@
+package paylike
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// HealthStatus classifies the outcome of a Ping
+type HealthStatus string
+
+// Possible health statuses
+const (
+	HealthOK           HealthStatus = "ok"           // the API accepted the key
+	HealthUnauthorized HealthStatus = "unauthorized" // the API rejected the key
+	HealthDegraded     HealthStatus = "degraded"     // the API responded with an error
+	HealthUnreachable  HealthStatus = "unreachable"  // the API did not respond in time or the circuit is open
+)
+
+// Health describes the outcome of a Ping
+type Health struct {
+	Status  HealthStatus
+	Latency time.Duration // time taken by the call, including retries
+}
+
+// Ping performs a cheap authenticated call bypassing the cache, e.g. for the
+// readiness probes of services depending on Paylike
+// The returned error is nil only when the status is HealthOK
+func (c Client) Ping(ctx context.Context, opts ...CallOption) (Health, error) {
+	opts = append(opts[:len(opts):len(opts)], WithContext(ctx), SkipCache())
+	start := c.now()
+	_, err := c.FetchApp(opts...)
+	return Health{Status: healthStatus(err), Latency: c.now().Sub(start)}, err
+}
+
+// healthStatus classifies the error of a ping
+func healthStatus(err error) HealthStatus {
+	var apiErr *APIError
+	switch {
+	case err == nil:
+		return HealthOK
+	case errors.As(err, &apiErr):
+		if apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden {
+			return HealthUnauthorized
+		}
+		return HealthDegraded
+	}
+	return HealthUnreachable
+}
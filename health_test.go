@@ -0,0 +1,46 @@
+package paylike
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPing(t *testing.T) {
+	for status, expected := range map[int]HealthStatus{
+		http.StatusOK:                 HealthOK,
+		http.StatusUnauthorized:       HealthUnauthorized,
+		http.StatusServiceUnavailable: HealthDegraded,
+	} {
+		client := newErrorTestClient(t, status, `{"identity":{"id":"app1"}}`)
+		health, err := client.Ping(context.Background())
+		assert.Equal(t, expected, health.Status)
+		assert.Equal(t, expected == HealthOK, err == nil)
+		assert.True(t, health.Latency > 0)
+	}
+}
+
+func TestPingUnreachable(t *testing.T) {
+	client := newSlowTestClient(t, time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	health, err := client.Ping(ctx)
+	assert.NotNil(t, err)
+	assert.Equal(t, HealthUnreachable, health.Status)
+}
+
+func TestPingBypassesCache(t *testing.T) {
+	requests := 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"identity":{"id":"app1"}}`))
+	}), WithCache(NewMemoryCache(10), time.Minute))
+	for i := 0; i < 2; i++ {
+		_, err := client.Ping(context.Background())
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 2, requests)
+}
@@ -0,0 +1,16 @@
+package paylike
+
+// MerchantID identifies a merchant
+type MerchantID string
+
+// TxID identifies a transaction
+type TxID string
+
+// CardToken identifies a saved card
+type CardToken string
+
+// AppID identifies an app
+type AppID string
+
+// UserID identifies a user
+type UserID string
@@ -0,0 +1,202 @@
+package paylike
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// IdempotencyKeyHeader is the header carrying the idempotency key of a
+// request, letting the API recognize a repeated capture, refund or void
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// JournalStatus describes the state of a journaled operation
+type JournalStatus string
+
+// Possible journal statuses
+const (
+	JournalPending JournalStatus = "pending" // about to be performed, or interrupted while being performed
+	JournalDone    JournalStatus = "done"    // performed successfully
+	JournalFailed  JournalStatus = "failed"  // certainly not performed, performed again when resumed
+	JournalUnknown JournalStatus = "unknown" // failed without telling whether it was performed, reconciled when resumed
+	JournalReview  JournalStatus = "review"  // cannot be reconciled, left for someone to look into
+)
+
+// JournalEntry describes an operation of a batch recorded in a journal
+type JournalEntry struct {
+	Key       string        `json:"key"`       // idempotency key of the request
+	Operation string        `json:"operation"` // name of the operation, e.g. "CaptureTransaction"
+	Request   TrailRequest  `json:"request"`
+	Status    JournalStatus `json:"status"`
+	Baseline  *int64        `json:"baseline,omitempty"` // amount of the operation on the transaction before performing it
+	LastError string        `json:"lastError,omitempty"`
+}
+
+// Journal persists the operations of batches, so a batch interrupted by a
+// crash can be run again, skipping the operations already performed
+type Journal interface {
+	// Load returns the entry with the given key, if any
+	Load(ctx context.Context, key string) (*JournalEntry, bool, error)
+	// Save stores the given entry
+	Save(ctx context.Context, entry *JournalEntry) error
+}
+
+// ErrJournalKey is the error of batch requests without a key when the
+// client has been created with WithJournal
+var ErrJournalKey = errors.New("paylike: journaled request requires a key")
+
+// ErrJournalReview is matched by the errors of journaled requests whose
+// outcome cannot be told from the transaction, left in JournalReview
+var ErrJournalReview = errors.New("paylike: journaled request requires review")
+
+// WithJournal records every request of BatchCapture, BatchRefund and
+// BatchVoid in the given journal before performing it, along with the
+// amount captured, refunded or voided on the transaction at that time, and
+// marks it as done, failed or unknown after, sending its key as idempotency
+// key (see IdempotencyKeyHeader)
+// Running a batch again skips the requests done and performs again those
+// certainly not performed; the requests interrupted or failed without a
+// known outcome are reconciled against the amounts of their transaction,
+// assuming nothing else trails it meanwhile, and left for review
+// (ErrJournalReview) when the amounts cannot tell
+// The journal is left untouched in dry-run mode (see WithDryRun), so a
+// rehearsal does not mark requests as done
+func WithJournal(journal Journal) Option {
+	return func(c *Client) {
+		c.journal = journal
+	}
+}
+
+// journaled performs the given request through the journal of the client
+func (c Client) journaled(ctx context.Context, op Operation, request TrailRequest, perform func(...CallOption) (*Transaction, error), opts []CallOption) (*Transaction, bool, error) {
+	if request.Key == "" {
+		return nil, false, ErrJournalKey
+	}
+	entry, ok, err := c.journal.Load(ctx, request.Key)
+	if err != nil {
+		return nil, false, err
+	}
+	c = c.with([]CallOption{WithContext(ctx)})
+	switch {
+	case ok && entry.Status == JournalDone:
+		return nil, true, nil
+	case ok && entry.Status == JournalReview:
+		return nil, false, fmt.Errorf("%w: %s", ErrJournalReview, entry.LastError)
+	case ok && (entry.Status == JournalPending || entry.Status == JournalUnknown):
+		transaction, done, err := c.reconcileJournal(ctx, op, entry)
+		if err != nil || done {
+			return transaction, done, err
+		}
+	default:
+		transaction, err := c.fetchFreshTransaction(request.TransactionID)
+		if err == nil && transaction == nil {
+			err = ErrNoTransaction
+		}
+		entry = &JournalEntry{Key: request.Key, Operation: op.Name, Request: request, Status: JournalFailed}
+		if err != nil {
+			entry.LastError = err.Error()
+			c.journal.Save(ctx, entry)
+			return nil, false, err
+		}
+		baseline := trailedAmount(op, transaction)
+		entry.Baseline = &baseline
+	}
+	entry.Status = JournalPending
+	entry.LastError = ""
+	if err := c.journal.Save(ctx, entry); err != nil {
+		return nil, false, err
+	}
+	opts = append(opts[:len(opts):len(opts)], WithCallHeader(IdempotencyKeyHeader, request.Key))
+	transaction, err := perform(opts...)
+	switch {
+	case err == nil:
+		entry.Status = JournalDone
+	case IsNotProcessed(err) || IsClientError(err):
+		entry.Status = JournalFailed
+		entry.LastError = err.Error()
+	default:
+		entry.Status = JournalUnknown
+		entry.LastError = err.Error()
+	}
+	if saveErr := c.journal.Save(ctx, entry); saveErr != nil && err == nil {
+		err = saveErr
+	}
+	return transaction, false, err
+}
+
+// reconcileJournal tells whether the given interrupted or unknown entry has
+// been performed by comparing the amount of its operation on the transaction
+// with the baseline, marking it as done if so and for review if the amounts
+// cannot tell; it returns whether the entry has been performed
+func (c Client) reconcileJournal(ctx context.Context, op Operation, entry *JournalEntry) (*Transaction, bool, error) {
+	if entry.Baseline == nil {
+		return nil, false, c.reviewJournal(ctx, entry, "outcome unknown without baseline")
+	}
+	transaction, err := c.fetchFreshTransaction(entry.Request.TransactionID)
+	if err == nil && transaction == nil {
+		err = ErrNoTransaction
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	amount := trailedAmount(op, transaction)
+	switch {
+	case amount >= *entry.Baseline+entry.Request.Amount:
+		entry.Status = JournalDone
+		entry.LastError = ""
+		return transaction, true, c.journal.Save(ctx, entry)
+	case amount == *entry.Baseline:
+		return nil, false, nil
+	}
+	return nil, false, c.reviewJournal(ctx, entry, fmt.Sprintf("%d of %d reflected on the transaction (was %d)", amount, entry.Request.Amount, *entry.Baseline))
+}
+
+// reviewJournal leaves the given entry for review for the given reason
+func (c Client) reviewJournal(ctx context.Context, entry *JournalEntry, reason string) error {
+	entry.Status = JournalReview
+	entry.LastError = reason
+	if err := c.journal.Save(ctx, entry); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %s", ErrJournalReview, reason)
+}
+
+// trailedAmount returns the amount captured, refunded or voided on the
+// given transaction, according to the given operation
+func trailedAmount(op Operation, transaction *Transaction) int64 {
+	switch op.Name {
+	case OpRefundTransaction.Name:
+		return transaction.RefundedAmount
+	case OpVoidTransaction.Name:
+		return transaction.VoidedAmount
+	}
+	return transaction.CapturedAmount
+}
+
+// MemoryJournal is an in-memory Journal, mostly useful for testing
+type MemoryJournal struct {
+	mu      sync.Mutex
+	entries map[string]JournalEntry
+}
+
+// NewMemoryJournal creates a new empty in-memory journal
+func NewMemoryJournal() *MemoryJournal {
+	return &MemoryJournal{entries: map[string]JournalEntry{}}
+}
+
+// Load returns a copy of the entry with the given key, if any
+func (j *MemoryJournal) Load(ctx context.Context, key string) (*JournalEntry, bool, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entry, ok := j.entries[key]
+	return &entry, ok, nil
+}
+
+// Save stores a copy of the given entry
+func (j *MemoryJournal) Save(ctx context.Context, entry *JournalEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.entries[entry.Key] = *entry
+	return nil
+}
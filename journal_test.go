@@ -0,0 +1,171 @@
+package paylike
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalResume(t *testing.T) {
+	var mu sync.Mutex
+	keys := map[TxID]string{}
+	failing := true
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := TxID(strings.Split(r.URL.Path, "/")[2])
+		if r.Method != http.MethodPost {
+			w.Write([]byte(`{"transaction":{"id":"` + string(id) + `"}}`))
+			return
+		}
+		mu.Lock()
+		keys[id] = r.Header.Get(IdempotencyKeyHeader)
+		fail := failing && id == "tx2"
+		mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"transaction":{"id":"` + string(id) + `","capturedAmount":100}}`))
+	}), WithJournal(NewMemoryJournal()))
+
+	requests := []TrailRequest{
+		{Key: "k1", TransactionID: "tx1", TransactionTrailDTO: TransactionTrailDTO{Amount: 100}},
+		{Key: "k2", TransactionID: "tx2", TransactionTrailDTO: TransactionTrailDTO{Amount: 100}},
+	}
+	results := client.BatchCapture(context.Background(), requests)
+	assert.Nil(t, results[0].Err)
+	assert.NotNil(t, results[1].Err)
+	assert.Equal(t, map[TxID]string{"tx1": "k1", "tx2": "k2"}, keys)
+
+	entry, ok, err := client.journal.Load(context.Background(), "k2")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, JournalFailed, entry.Status)
+	assert.Equal(t, "CaptureTransaction", entry.Operation)
+	assert.NotEmpty(t, entry.LastError)
+
+	failing = false
+	keys = map[TxID]string{}
+	results = client.BatchCapture(context.Background(), requests)
+	assert.Nil(t, results.Failed())
+	assert.True(t, results[0].Skipped)
+	assert.Nil(t, results[0].Transaction)
+	assert.False(t, results[1].Skipped)
+	assert.Equal(t, int64(100), results[1].Transaction.CapturedAmount)
+	assert.Equal(t, map[TxID]string{"tx2": "k2"}, keys)
+
+	entry, _, _ = client.journal.Load(context.Background(), "k2")
+	assert.Equal(t, JournalDone, entry.Status)
+	assert.Empty(t, entry.LastError)
+}
+
+func TestJournalPendingResumed(t *testing.T) {
+	journal := NewMemoryJournal()
+	baseline := int64(0)
+	request := TrailRequest{Key: "k1", TransactionID: "tx1", TransactionTrailDTO: TransactionTrailDTO{Amount: 100}}
+	journal.Save(context.Background(), &JournalEntry{Key: "k1", Operation: "VoidTransaction", Request: request, Status: JournalPending, Baseline: &baseline})
+	var key string
+	voided := 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			key = r.Header.Get(IdempotencyKeyHeader)
+			voided = 100
+		}
+		fmt.Fprintf(w, `{"transaction":{"id":"tx1","voidedAmount":%d}}`, voided)
+	}), WithJournal(journal))
+
+	results := client.BatchVoid(context.Background(), []TrailRequest{request})
+	assert.Nil(t, results[0].Err)
+	assert.False(t, results[0].Skipped)
+	assert.Equal(t, "k1", key)
+}
+
+func TestJournalReconcilesUnknownOutcomes(t *testing.T) {
+	journal := NewMemoryJournal()
+	captured := map[TxID]int64{}
+	var posts []TxID
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := TxID(strings.Split(r.URL.Path, "/")[2])
+		if r.Method == http.MethodPost {
+			posts = append(posts, id)
+			switch id {
+			case "tx1":
+				captured[id] += 100 // performed although the response is lost
+			case "tx3":
+				captured[id] += 40 // reflected partially, e.g. by another capture
+			}
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprintf(w, `{"transaction":{"id":%q,"capturedAmount":%d}}`, id, captured[id])
+	}), WithJournal(journal), WithBatchConcurrency(1))
+
+	requests := []TrailRequest{
+		{Key: "k1", TransactionID: "tx1", TransactionTrailDTO: TransactionTrailDTO{Amount: 100}},
+		{Key: "k2", TransactionID: "tx2", TransactionTrailDTO: TransactionTrailDTO{Amount: 100}},
+		{Key: "k3", TransactionID: "tx3", TransactionTrailDTO: TransactionTrailDTO{Amount: 100}},
+	}
+	results := client.BatchCapture(context.Background(), requests)
+	assert.Len(t, results.Failed(), 3)
+	for _, key := range []string{"k1", "k2", "k3"} {
+		entry, _, _ := journal.Load(context.Background(), key)
+		assert.Equal(t, JournalUnknown, entry.Status, key)
+		assert.Equal(t, int64(0), *entry.Baseline, key)
+	}
+
+	posts = nil
+	results = client.BatchCapture(context.Background(), requests)
+	assert.Nil(t, results[0].Err)
+	assert.True(t, results[0].Skipped)
+	assert.Equal(t, int64(100), results[0].Transaction.CapturedAmount)
+	assert.NotNil(t, results[1].Err)
+	assert.True(t, errors.Is(results[2].Err, ErrJournalReview))
+	assert.Equal(t, []TxID{"tx2"}, posts, "only the capture certainly not performed is repeated")
+
+	entry, _, _ := journal.Load(context.Background(), "k3")
+	assert.Equal(t, JournalReview, entry.Status)
+	posts = nil
+	results = client.BatchCapture(context.Background(), requests[2:])
+	assert.True(t, errors.Is(results[0].Err, ErrJournalReview))
+	assert.Empty(t, posts)
+}
+
+func TestJournalRequiresKey(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unexpected request")
+	}), WithJournal(NewMemoryJournal()))
+	results := client.BatchRefund(context.Background(), []TrailRequest{{TransactionID: "tx1"}})
+	assert.Equal(t, ErrJournalKey, results[0].Err)
+}
+
+func TestJournalDryRun(t *testing.T) {
+	journal := NewMemoryJournal()
+	var posts int
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			posts++
+		}
+		w.Write([]byte(`{"transaction":{"id":"tx1","currency":"EUR","amount":100,"pendingAmount":100}}`))
+	})
+	requests := []TrailRequest{{Key: "k1", TransactionID: "tx1", TransactionTrailDTO: TransactionTrailDTO{Amount: 100}}}
+
+	results := newTestClient(t, handler, WithDryRun(), WithJournal(journal)).BatchCapture(context.Background(), requests)
+	assert.Nil(t, results[0].Err)
+	assert.False(t, results[0].Skipped)
+	assert.Equal(t, 0, posts)
+	_, ok, err := journal.Load(context.Background(), "k1")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	results = newTestClient(t, handler, WithJournal(journal)).BatchCapture(context.Background(), requests)
+	assert.Nil(t, results[0].Err)
+	assert.False(t, results[0].Skipped)
+	assert.Equal(t, 1, posts)
+	entry, _, _ := journal.Load(context.Background(), "k1")
+	assert.Equal(t, JournalDone, entry.Status)
+}
@@ -0,0 +1,129 @@
+package paylike
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// KeyProvider returns the API key to authenticate a request with, e.g. the
+// current version of a key rotated in Vault or a KMS
+// Implementations are called for every call and should cache the key
+type KeyProvider interface {
+	Key(ctx context.Context) (string, error)
+}
+
+// KeyProviderFunc is an adapter to allow the use of ordinary functions as KeyProvider
+type KeyProviderFunc func(ctx context.Context) (string, error)
+
+// Key calls f(ctx)
+func (f KeyProviderFunc) Key(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// WithKeyProvider authenticates every call with the key returned by the
+// given provider at the time of the call, taking precedence over the key
+// given to NewClient or SetKey
+func WithKeyProvider(provider KeyProvider) Option {
+	return func(c *Client) {
+		c.keyProvider = provider
+	}
+}
+
+// KeyResolver selects the API key of a request on behalf of the given
+// merchant, e.g. for platforms managing the apps of many tenants
+// merchantID is empty if the request concerns no particular merchant;
+// returning an empty key falls back to the key of the client
+type KeyResolver interface {
+	ResolveKey(ctx context.Context, merchantID MerchantID) (string, error)
+}
+
+// KeyResolverFunc is an adapter to allow the use of ordinary functions as KeyResolver
+type KeyResolverFunc func(ctx context.Context, merchantID MerchantID) (string, error)
+
+// ResolveKey calls f(ctx, merchantID)
+func (f KeyResolverFunc) ResolveKey(ctx context.Context, merchantID MerchantID) (string, error) {
+	return f(ctx, merchantID)
+}
+
+// WithKeyResolver authenticates every call with the key the given resolver
+// selects for the merchant of the call, being the merchant in the path of the
+// request or, for requests without one (e.g. captures), the merchant set
+// with ContextWithMerchant
+func WithKeyResolver(resolver KeyResolver) Option {
+	return func(c *Client) {
+		c.keyResolver = resolver
+	}
+}
+
+// ContextWithMerchant returns a copy of the given context marking the calls
+// made within it as being on behalf of the given merchant
+func ContextWithMerchant(ctx context.Context, merchantID MerchantID) context.Context {
+	return context.WithValue(ctx, merchantKey{}, merchantID)
+}
+
+// MerchantFromContext returns the merchant a call is made on behalf of
+func MerchantFromContext(ctx context.Context) (MerchantID, bool) {
+	merchantID, ok := ctx.Value(merchantKey{}).(MerchantID)
+	return merchantID, ok
+}
+
+// merchantKey is the context key for the merchant of a call
+type merchantKey struct{}
+
+// ContextWithKey returns a copy of the given context authenticating the calls
+// made within it with the given API key, taking precedence over the key of
+// the client, its provider and its resolver, e.g. to canary a new key on a
+// few calls of a shared client
+func ContextWithKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, apiKeyKey{}, key)
+}
+
+// KeyFromContext returns the API key set with ContextWithKey
+func KeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(apiKeyKey{}).(string)
+	return key, ok && key != ""
+}
+
+// apiKeyKey is the context key for the API key of a call
+type apiKeyKey struct{}
+
+// ContextWithBaseURL returns a copy of the given context sending the calls
+// made within it to the given URL instead of the base URL of the client
+func ContextWithBaseURL(ctx context.Context, url string) context.Context {
+	return context.WithValue(ctx, baseURLKey{}, strings.TrimSuffix(url, "/"))
+}
+
+// BaseURLFromContext returns the base URL set with ContextWithBaseURL
+func BaseURLFromContext(ctx context.Context) (string, bool) {
+	url, ok := ctx.Value(baseURLKey{}).(string)
+	return url, ok && url != ""
+}
+
+// baseURLKey is the context key for the base URL of a call
+type baseURLKey struct{}
+
+// resolveKey returns the API key to authenticate a call within the given context with
+func (c Client) resolveKey(ctx context.Context) (string, error) {
+	if key, ok := KeyFromContext(ctx); ok {
+		return key, nil
+	}
+	if c.keyResolver != nil {
+		merchantID, _ := MerchantFromContext(ctx)
+		key, err := c.keyResolver.ResolveKey(ctx, merchantID)
+		if err != nil {
+			return "", fmt.Errorf("paylike: resolving API key: %w", err)
+		}
+		if key != "" {
+			return key, nil
+		}
+	}
+	if c.keyProvider == nil {
+		return c.Key, nil
+	}
+	key, err := c.keyProvider.Key(ctx)
+	if err != nil {
+		return "", fmt.Errorf("paylike: resolving API key: %w", err)
+	}
+	return key, nil
+}
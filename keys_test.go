@@ -0,0 +1,120 @@
+package paylike
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithKeyProvider(t *testing.T) {
+	var keys []string
+	current := "key-v1"
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, key, _ := r.BasicAuth()
+		keys = append(keys, key)
+	}), WithKeyProvider(KeyProviderFunc(func(ctx context.Context) (string, error) {
+		return current, nil
+	})))
+
+	_, err := client.FetchApp()
+	assert.Nil(t, err)
+	current = "key-v2"
+	_, err = client.FetchApp()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"key-v1", "key-v2"}, keys)
+}
+
+func TestKeyProviderError(t *testing.T) {
+	failure := errors.New("vault sealed")
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unexpected request")
+	}), WithKeyProvider(KeyProviderFunc(func(ctx context.Context) (string, error) {
+		return "", failure
+	})))
+
+	_, err := client.FetchApp()
+	assert.True(t, errors.Is(err, failure))
+}
+
+func TestWithKeyResolver(t *testing.T) {
+	var keys []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, key, _ := r.BasicAuth()
+		keys = append(keys, key)
+	}), WithKeyResolver(KeyResolverFunc(func(ctx context.Context, merchantID MerchantID) (string, error) {
+		if merchantID == "" {
+			return "", nil
+		}
+		return "key-" + string(merchantID), nil
+	})))
+
+	_, err := client.ListTransactions("m1", 10)
+	assert.Nil(t, err)
+	_, err = client.CaptureTransaction("tx1", TransactionTrailDTO{Amount: 1}, WithContext(ContextWithMerchant(context.Background(), "m2")))
+	assert.Nil(t, err)
+	_, err = client.GetMerchant("m3", WithContext(ContextWithMerchant(context.Background(), "m2")))
+	assert.Nil(t, err)
+	_, err = client.FetchApp()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"key-m1", "key-m2", "key-m3", TestKey}, keys)
+}
+
+func TestContextWithKey(t *testing.T) {
+	var keys []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, key, _ := r.BasicAuth()
+		keys = append(keys, key)
+	}), WithKeyResolver(KeyResolverFunc(func(ctx context.Context, merchantID MerchantID) (string, error) {
+		return "key-" + string(merchantID), nil
+	})))
+
+	_, err := client.GetMerchant("m1", WithContext(ContextWithKey(context.Background(), "canary")))
+	assert.Nil(t, err)
+	_, err = client.GetMerchant("m1")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"canary", "key-m1"}, keys)
+}
+
+func TestContextWithBaseURL(t *testing.T) {
+	var hits []string
+	canary := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits = append(hits, "canary "+r.URL.Path)
+	}))
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits = append(hits, "default "+r.URL.Path)
+	}))
+
+	_, err := client.FetchApp(WithContext(ContextWithBaseURL(context.Background(), canary.baseAPI+"/")))
+	assert.Nil(t, err)
+	_, err = client.FetchApp()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"canary /me", "default /me"}, hits)
+}
+
+func TestContextWithBaseURLCached(t *testing.T) {
+	var hits []string
+	canary := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits = append(hits, "canary "+r.URL.Path)
+		w.Write([]byte(`{"merchant":{"id":"m1","name":"Canary"}}`))
+	}))
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits = append(hits, "default "+r.URL.Path)
+		w.Write([]byte(`{"merchant":{"id":"m1","name":"Default"}}`))
+	}), WithCache(NewMemoryCache(10), time.Minute))
+
+	ctx := ContextWithBaseURL(context.Background(), canary.baseAPI)
+	merchant, err := client.GetMerchant("m1", WithContext(ctx))
+	assert.Nil(t, err)
+	assert.Equal(t, "Canary", merchant.Name)
+	merchant, err = client.GetMerchant("m1")
+	assert.Nil(t, err)
+	assert.Equal(t, "Default", merchant.Name)
+	merchant, err = client.GetMerchant("m1", WithContext(ctx))
+	assert.Nil(t, err)
+	assert.Equal(t, "Canary", merchant.Name)
+	assert.Equal(t, []string{"canary /merchants/m1", "default /merchants/m1"}, hits)
+}
@@ -0,0 +1,231 @@
+package paylike
+
+import (
+	"net/url"
+	"sort"
+	"time"
+)
+
+// LineFilter describes which lines of a merchant to fetch
+type LineFilter struct {
+	After         time.Time // optional, only lines created at or after this time
+	Before        time.Time // optional, only lines created before this time
+	TransactionID TxID      // optional, only lines of this transaction
+	Pagination              // optional, how pages are fetched
+}
+
+// matches reports whether the given line passes the filter
+func (f LineFilter) matches(line *Line) bool {
+	if f.TransactionID != "" && line.TransactionID != f.TransactionID {
+		return false
+	}
+	if f.After.IsZero() && f.Before.IsZero() {
+		return true
+	}
+	created, err := time.Parse(time.RFC3339, line.Created)
+	if err != nil {
+		return false
+	}
+	return !created.Before(f.After) && (f.Before.IsZero() || created.Before(f.Before))
+}
+
+// pastRange reports whether the given line is older than the filtered range,
+// meaning no later page can contain matching lines
+func (f LineFilter) pastRange(line *Line) bool {
+	if f.After.IsZero() {
+		return false
+	}
+	created, err := time.Parse(time.RFC3339, line.Created)
+	return err == nil && created.Before(f.After)
+}
+
+// FetchFilteredLinesToMerchant fetches the lines of a given merchant matching
+// the given filter, newest first, paginating until the filtered range is passed
+// The transaction ID is sent to the API while the date range is applied to
+// each page, stopping as soon as a line older than After is seen
+// The maximum number of items of the pagination caps the lines scanned,
+// including those the filter rejects; reaching it returns the lines matched
+// so far along with ErrPaginationLimit
+func (c Client) FetchFilteredLinesToMerchant(merchantID MerchantID, filter LineFilter, opts ...CallOption) ([]*Line, error) {
+	query := url.Values{}
+	if filter.TransactionID != "" {
+		query.Set("filter[transactionId]", string(filter.TransactionID))
+	}
+	var matching []*Line
+	err := paginate(c.with(opts), OpFetchLinesToMerchant, query, filter.Pagination, lineCursor, func(line *Line) error {
+		if filter.pastRange(line) {
+			return ErrStopPagination
+		}
+		if filter.matches(line) {
+			matching = append(matching, line)
+		}
+		return nil
+	}, string(merchantID))
+	if err != nil && err != ErrPaginationLimit {
+		return nil, err
+	}
+	return matching, err
+}
+
+// LineTotals describes the aggregated amounts of a set of merchant lines
+type LineTotals struct {
+	Currency string // currency of the aggregated amounts
+	Day      string // YYYY-MM-DD, only set by TotalsByDay
+	Captures int64  // captured amount in minor units
+	Refunds  int64  // refunded amount in minor units
+	Fees     int64  // fees in minor units of the merchant currency
+	Count    int    // number of lines
+}
+
+// ComputeBalance returns the merchant balance after the most recent of
+// the given lines, as lines are usually listed newest first
+func ComputeBalance(lines []*Line) int64 {
+	var latest *Line
+	for _, line := range lines {
+		if latest == nil || createdBefore(latest, line) {
+			latest = line
+		}
+	}
+	if latest == nil {
+		return 0
+	}
+	return latest.Balance
+}
+
+// createdBefore reports whether line a was created before line b, comparing
+// the parsed times so offsets and fractional seconds order correctly, and
+// falling back to the raw values if either is unparsable
+func createdBefore(a, b *Line) bool {
+	aCreated, aErr := time.Parse(time.RFC3339Nano, a.Created)
+	bCreated, bErr := time.Parse(time.RFC3339Nano, b.Created)
+	if aErr != nil || bErr != nil {
+		return a.Created < b.Created
+	}
+	return aCreated.Before(bCreated)
+}
+
+// TotalsByCurrency aggregates the given lines per currency, ordered by currency
+func TotalsByCurrency(lines []*Line) []LineTotals {
+	return aggregateLines(lines, func(*Line) string { return "" })
+}
+
+// TotalsByDay aggregates the given lines per day in the given location and
+// currency, ordered by day and currency
+// Lines with an unparsable creation date are aggregated with an empty day
+func TotalsByDay(lines []*Line, loc *time.Location) []LineTotals {
+	return aggregateLines(lines, func(line *Line) string {
+		created, err := time.Parse(time.RFC3339, line.Created)
+		if err != nil {
+			return ""
+		}
+		return created.In(loc).Format("2006-01-02")
+	})
+}
+
+// CurrencyGroup describes the lines in a single currency along with their totals
+type CurrencyGroup struct {
+	Currency string
+	Totals   LineTotals
+	Lines    []*Line // in the order they were given
+}
+
+// GroupLinesByCurrency groups the given lines by the currency of their
+// amount, e.g. for cross-border merchants, ordered by currency
+func GroupLinesByCurrency(lines []*Line) []CurrencyGroup {
+	index := map[string]int{}
+	var groups []CurrencyGroup
+	for _, line := range lines {
+		i, ok := index[line.Amount.Currency]
+		if !ok {
+			i = len(groups)
+			index[line.Amount.Currency] = i
+			groups = append(groups, CurrencyGroup{Currency: line.Amount.Currency})
+		}
+		groups[i].Lines = append(groups[i].Lines, line)
+	}
+	for i := range groups {
+		groups[i].Totals = TotalsByCurrency(groups[i].Lines)[0]
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Currency < groups[j].Currency
+	})
+	return groups
+}
+
+// Currencies returns the distinct currencies of the given lines, sorted
+func Currencies(lines []*Line) []string {
+	var currencies []string
+	for _, group := range GroupLinesByCurrency(lines) {
+		currencies = append(currencies, group.Currency)
+	}
+	return currencies
+}
+
+// TrailLine joins a trail entry of a transaction to the line it changed the
+// merchant balance with, e.g. to attribute the fee of the line to a capture
+type TrailLine struct {
+	Transaction *Transaction
+	Trail       *TransactionTrail
+	Line        *Line // nil if the line of the entry is not among the given lines
+}
+
+// JoinTrailsToLines joins the trail entries of the given transactions that
+// changed the merchant balance (captures, refunds and disputes, having a
+// LineID) to their lines, in the order of the transactions and their trails
+func JoinTrailsToLines(transactions []*Transaction, lines []*Line) []TrailLine {
+	byID := make(map[string]*Line, len(lines))
+	for _, line := range lines {
+		byID[line.ID] = line
+	}
+	var joined []TrailLine
+	for _, transaction := range transactions {
+		for _, trail := range transaction.Trail {
+			if trail.LineID == "" {
+				continue
+			}
+			joined = append(joined, TrailLine{Transaction: transaction, Trail: trail, Line: byID[trail.LineID]})
+		}
+	}
+	return joined
+}
+
+// aggregateLines groups the given lines by day and currency
+func aggregateLines(lines []*Line, day func(*Line) string) []LineTotals {
+	type key struct{ day, currency string }
+	groups := map[key]*LineTotals{}
+	for _, line := range lines {
+		k := key{day(line), line.Amount.Currency}
+		totals, ok := groups[k]
+		if !ok {
+			totals = &LineTotals{Day: k.day, Currency: k.currency}
+			groups[k] = totals
+		}
+		totals.Count++
+		totals.Fees += line.Fee
+		switch {
+		case line.Refund:
+			totals.Refunds += abs(line.Amount.Amount)
+		case line.TransactionID != "":
+			totals.Captures += line.Amount.Amount
+		}
+	}
+	result := make([]LineTotals, 0, len(groups))
+	for _, totals := range groups {
+		result = append(result, *totals)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Day != result[j].Day {
+			return result[i].Day < result[j].Day
+		}
+		return result[i].Currency < result[j].Currency
+	})
+	return result
+}
+
+// abs returns the absolute value of the given amount
+func abs(amount int64) int64 {
+	if amount < 0 {
+		return -amount
+	}
+	return amount
+}
@@ -0,0 +1,125 @@
+package paylike
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testLines = []*Line{
+	{Created: "2024-01-02T09:00:00.000Z", Balance: 1450, Fee: 25, TransactionID: "tx3", Amount: PricingAmount{Currency: "EUR", Amount: -500}, Refund: true},
+	{Created: "2024-01-01T23:30:00.000Z", Balance: 1975, Fee: 0, TransactionID: "tx2", Amount: PricingAmount{Currency: "DKK", Amount: 1000}},
+	{Created: "2024-01-01T10:00:00.000Z", Balance: 975, Fee: 25, TransactionID: "tx1", Amount: PricingAmount{Currency: "EUR", Amount: 1000}},
+}
+
+func TestComputeBalance(t *testing.T) {
+	assert.Equal(t, int64(1450), ComputeBalance(testLines))
+	assert.Equal(t, int64(0), ComputeBalance(nil))
+	assert.Equal(t, int64(2), ComputeBalance([]*Line{
+		{Created: "2024-01-02T10:00:00Z", Balance: 1},
+		{Created: "2024-01-02T10:00:00.5Z", Balance: 2},
+		{Created: "2024-01-02T11:00:00+02:00", Balance: 3},
+	}))
+}
+
+func TestTotalsByCurrency(t *testing.T) {
+	assert.Equal(t, []LineTotals{
+		{Currency: "DKK", Captures: 1000, Count: 1},
+		{Currency: "EUR", Captures: 1000, Refunds: 500, Fees: 50, Count: 2},
+	}, TotalsByCurrency(testLines))
+}
+
+func TestTotalsByDay(t *testing.T) {
+	assert.Equal(t, []LineTotals{
+		{Day: "2024-01-01", Currency: "EUR", Captures: 1000, Fees: 25, Count: 1},
+		{Day: "2024-01-02", Currency: "DKK", Captures: 1000, Count: 1},
+		{Day: "2024-01-02", Currency: "EUR", Refunds: 500, Fees: 25, Count: 1},
+	}, TotalsByDay(testLines, time.FixedZone("CET", 3600)))
+}
+
+func TestFetchFilteredLinesToMerchant(t *testing.T) {
+	var queries []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		switch r.URL.Query().Get("before") {
+		case "":
+			w.Write([]byte(`[{"id":"l5","created":"2024-02-02T00:00:00Z"},{"id":"l4","created":"2024-01-31T00:00:00Z"}]`))
+		case "l4":
+			w.Write([]byte(`[{"id":"l3","created":"2024-01-15T00:00:00Z"},{"id":"l2","created":"2023-12-31T00:00:00Z"}]`))
+		default:
+			t.Errorf("unexpected page %s", r.URL.RawQuery)
+		}
+	}))
+	lines, err := client.FetchFilteredLinesToMerchant(TestMerchant, LineFilter{
+		After:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Before:     time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		Pagination: Pagination{PageSize: 2},
+	})
+	assert.Nil(t, err)
+	assert.Len(t, lines, 2)
+	assert.Equal(t, "l4", lines[0].ID)
+	assert.Equal(t, "l3", lines[1].ID)
+	assert.Equal(t, []string{"limit=2", "before=l4&limit=2"}, queries)
+
+	lines, err = client.FetchFilteredLinesToMerchant(TestMerchant, LineFilter{
+		After:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Before:     time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		Pagination: Pagination{PageSize: 2, MaxItems: 3},
+	})
+	assert.Equal(t, ErrPaginationLimit, err)
+	assert.Len(t, lines, 2)
+
+	queries = nil
+	lines, err = client.FetchFilteredLinesToMerchant(TestMerchant, LineFilter{TransactionID: "tx1"})
+	assert.Nil(t, err)
+	assert.Len(t, lines, 0)
+	assert.Equal(t, []string{"filter%5BtransactionId%5D=tx1&limit=100"}, queries)
+}
+
+func TestGroupLinesByCurrency(t *testing.T) {
+	lines := []*Line{
+		{ID: "l1", TransactionID: "tx1", Amount: PricingAmount{Currency: "USD", Amount: 100}, Fee: 3},
+		{ID: "l2", TransactionID: "tx2", Amount: PricingAmount{Currency: "EUR", Amount: 200}, Fee: 5},
+		{ID: "l3", TransactionID: "tx1", Amount: PricingAmount{Currency: "USD", Amount: -40}, Refund: true},
+	}
+	groups := GroupLinesByCurrency(lines)
+	assert.Len(t, groups, 2)
+	assert.Equal(t, "EUR", groups[0].Currency)
+	assert.Equal(t, []*Line{lines[1]}, groups[0].Lines)
+	assert.Equal(t, LineTotals{Currency: "EUR", Captures: 200, Fees: 5, Count: 1}, groups[0].Totals)
+	assert.Equal(t, "USD", groups[1].Currency)
+	assert.Equal(t, []*Line{lines[0], lines[2]}, groups[1].Lines)
+	assert.Equal(t, LineTotals{Currency: "USD", Captures: 100, Refunds: 40, Fees: 3, Count: 2}, groups[1].Totals)
+
+	assert.Equal(t, []string{"EUR", "USD"}, Currencies(lines))
+	assert.Empty(t, GroupLinesByCurrency(nil))
+
+	statement := &Statement{Lines: lines, Totals: TotalsByCurrency(lines)}
+	assert.Equal(t, groups, statement.ByCurrency())
+	assert.Equal(t, int64(40), statement.Total("USD").Refunds)
+	assert.Equal(t, LineTotals{Currency: "DKK"}, statement.Total("DKK"))
+}
+
+func TestJoinTrailsToLines(t *testing.T) {
+	capture := &TransactionTrail{Amount: 1000, Capture: true, LineID: "l1"}
+	void := &TransactionTrail{Amount: 200}
+	refund := &TransactionTrail{Amount: 300, LineID: "l2"}
+	missing := &TransactionTrail{Amount: 500, Capture: true, LineID: "l9"}
+	transactions := []*Transaction{
+		{TransactionID: TransactionID{ID: "tx1"}, Trail: []*TransactionTrail{capture, void, refund}},
+		{TransactionID: TransactionID{ID: "tx2"}, Trail: []*TransactionTrail{missing}},
+	}
+	lines := []*Line{
+		{ID: "l2", TransactionID: "tx1", Refund: true, Fee: 0},
+		{ID: "l1", TransactionID: "tx1", Fee: 25},
+	}
+	joined := JoinTrailsToLines(transactions, lines)
+	assert.Equal(t, []TrailLine{
+		{Transaction: transactions[0], Trail: capture, Line: lines[1]},
+		{Transaction: transactions[0], Trail: refund, Line: lines[0]},
+		{Transaction: transactions[1], Trail: missing},
+	}, joined)
+	assert.Empty(t, JoinTrailsToLines(nil, lines))
+}
@@ -0,0 +1,46 @@
+package paylike
+
+import (
+	"net/http"
+	"time"
+)
+
+// EndpointHeader is the conventional header name for endpoint labels,
+// to be used with WithEndpointHeader
+const EndpointHeader = "X-Paylike-Endpoint"
+
+// RequestMetrics describes a single finished attempt of a request as reported to the metrics hook
+type RequestMetrics struct {
+	Operation  Operation
+	Endpoint   string // normalized endpoint label, e.g. "GET /merchants/{merchantId}"
+	Attempt    int    // starting from 1, increasing with each retry
+	StatusCode int    // zero if no response has been received
+	Duration   time.Duration
+	Err        error
+	RequestID  string // ID of the originating request, see WithRequestIDFromContext
+}
+
+// Endpoint returns the normalized endpoint label of the operation,
+// consisting of the HTTP method and the path template
+func (o Operation) Endpoint() string {
+	return o.Method + " " + o.Path
+}
+
+// reportMetrics calls the metrics hook, if any, with the outcome of a given attempt
+func (c Client) reportMetrics(req *http.Request, op Operation, attempt int, start time.Time, resp *http.Response, err error) {
+	if c.metricsHook == nil {
+		return
+	}
+	metrics := RequestMetrics{
+		Operation: op,
+		Endpoint:  op.Endpoint(),
+		Attempt:   attempt,
+		Duration:  time.Since(start),
+		Err:       err,
+		RequestID: c.requestID(req.Context()),
+	}
+	if resp != nil {
+		metrics.StatusCode = resp.StatusCode
+	}
+	c.metricsHook(metrics)
+}
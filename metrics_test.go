@@ -0,0 +1,40 @@
+package paylike
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointLabels(t *testing.T) {
+	var header string
+	var reported []RequestMetrics
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get(EndpointHeader)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"transaction":{"id":"tx1"}}`))
+	}), WithEndpointHeader(EndpointHeader), WithMetricsHook(func(m RequestMetrics) {
+		reported = append(reported, m)
+	}))
+
+	_, err := client.CaptureTransaction("tx1", TransactionTrailDTO{Amount: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, "POST /transactions/{transactionId}/captures", header)
+	assert.Len(t, reported, 1)
+	assert.Equal(t, "POST /transactions/{transactionId}/captures", reported[0].Endpoint)
+	assert.Equal(t, OpCaptureTransaction, reported[0].Operation)
+	assert.Equal(t, http.StatusCreated, reported[0].StatusCode)
+	assert.Equal(t, 1, reported[0].Attempt)
+	assert.Nil(t, reported[0].Err)
+}
+
+func TestEndpointHeaderDisabledByDefault(t *testing.T) {
+	var header string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get(EndpointHeader)
+	}))
+	_, err := client.FindTransaction("tx1")
+	assert.Nil(t, err)
+	assert.Empty(t, header)
+}
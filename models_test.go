@@ -0,0 +1,144 @@
+package paylike
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// merchantPayload is a merchant as returned by the API
+const merchantPayload = `{"merchant":{
+	"id":"5f0c0b7c2a1e4b0012345678",
+	"name":"Shop",
+	"currency":"EUR",
+	"test":true,
+	"email":"shop@example.com",
+	"website":"https://example.com",
+	"descriptor":"SHOP",
+	"company":{"country":"DK","number":"12345678"},
+	"bank":{"iban":"DK5000400440116243"},
+	"claim":{"canChargeCard":true,"canSaveCard":true,"canTransferToCard":false,"canCapture":true,"canRefund":true,"canVoid":true},
+	"pricing":{
+		"rate":0.0125,
+		"flat":{"currency":"EUR","amount":25},
+		"dispute":{"currency":"EUR","amount":1500},
+		"transfer":{"toCard":{"rate":0.01,"flat":{"currency":"EUR","amount":50},"dispute":{"currency":"EUR","amount":0}}}
+	},
+	"tds":{"mode":"attempt"},
+	"key":"f1e2d3c4-b5a6-4789-8abc-def012345678",
+	"created":"2020-07-13T07:29:00.000Z",
+	"balance":125050
+}}`
+
+// appPayload is an app as returned by the API when creating it
+const appPayload = `{"app":{"id":"5f0c0b7c2a1e4b0012345679","name":"Backend","key":"0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d","created":"2020-07-13T07:29:00.000Z"}}`
+
+// identityPayload is the identity of the current app as returned by the API
+const identityPayload = `{"identity":{"id":"5f0c0b7c2a1e4b0012345679","name":"Backend","created":"2020-07-13T07:29:00.000Z"}}`
+
+// responseModels are the models of all API responses
+var responseModels = []interface{}{App{}, Identity{}, Merchant{}, User{}, Line{}, Transaction{}, Card{}, InviteUserToMerchantResponse{}}
+
+func TestMerchantFixture(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(merchantPayload))
+	}), WithStrictDecoding())
+
+	merchant, err := client.GetMerchant("5f0c0b7c2a1e4b0012345678")
+	assert.Nil(t, err)
+	assert.Equal(t, MerchantID("5f0c0b7c2a1e4b0012345678"), merchant.ID)
+	assert.Equal(t, "Shop", merchant.Name)
+	assert.Equal(t, "EUR", merchant.Currency)
+	assert.True(t, merchant.Test)
+	assert.Equal(t, "shop@example.com", merchant.Email)
+	assert.Equal(t, "https://example.com", merchant.Website)
+	assert.Equal(t, "SHOP", merchant.Descriptor)
+	assert.Equal(t, MerchantCompany{Country: "DK", Number: "12345678"}, merchant.Company)
+	assert.Equal(t, MerchantBank{Iban: "DK5000400440116243"}, merchant.Bank)
+	assert.Equal(t, MerchantClaim{CanChargeCard: true, CanSaveCard: true, CanCapture: true, CanRefund: true, CanVoid: true}, merchant.Claim)
+	assert.Equal(t, MerchantPricing{
+		Pricing: Pricing{
+			Rate:    0.0125,
+			Flat:    PricingAmount{Currency: "EUR", Amount: 25},
+			Dispute: PricingAmount{Currency: "EUR", Amount: 1500},
+		},
+		Transfer: MerchantTransfer{ToCard: Pricing{
+			Rate:    0.01,
+			Flat:    PricingAmount{Currency: "EUR", Amount: 50},
+			Dispute: PricingAmount{Currency: "EUR"},
+		}},
+	}, merchant.Pricing)
+	assert.Equal(t, MerchantTDS{Mode: "attempt"}, merchant.TDS)
+	assert.Equal(t, "f1e2d3c4-b5a6-4789-8abc-def012345678", merchant.Key)
+	assert.Equal(t, "2020-07-13T07:29:00.000Z", merchant.Created)
+	assert.Equal(t, int64(125050), merchant.Balance)
+}
+
+func TestAppFixtures(t *testing.T) {
+	payload := appPayload
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(payload))
+	}), WithStrictDecoding())
+
+	app, err := client.CreateApp()
+	assert.Nil(t, err)
+	assert.Equal(t, AppID("5f0c0b7c2a1e4b0012345679"), app.ID)
+	assert.Equal(t, "Backend", app.Name)
+	assert.Equal(t, "0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d", app.Key)
+	assert.Equal(t, "2020-07-13T07:29:00.000Z", app.Created)
+
+	payload = identityPayload
+	identity, err := client.FetchApp()
+	assert.Nil(t, err)
+	assert.Equal(t, Identity{ID: "5f0c0b7c2a1e4b0012345679", Name: "Backend", Created: "2020-07-13T07:29:00.000Z"}, *identity)
+
+	payload = `{"isMember":true}`
+	response, err := client.InviteUserToMerchant("m1", "user@example.com")
+	assert.Nil(t, err)
+	assert.True(t, response.IsMember)
+}
+
+func TestResponseModelTags(t *testing.T) {
+	seen := map[reflect.Type]bool{}
+	var check func(typ reflect.Type)
+	check = func(typ reflect.Type) {
+		for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Map {
+			typ = typ.Elem()
+		}
+		if typ.Kind() != reflect.Struct || seen[typ] {
+			return
+		}
+		seen[typ] = true
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			tag, ok := field.Tag.Lookup("json")
+			if !ok && !field.Anonymous {
+				t.Errorf("%s.%s has no JSON tag", typ.Name(), field.Name)
+			}
+			if tag == "-" {
+				continue
+			}
+			check(field.Type)
+		}
+	}
+	for _, model := range responseModels {
+		check(reflect.TypeOf(model))
+	}
+}
+
+func TestLargeAmounts(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":"l1","balance":9007199254740993,"fee":3000000000}]`))
+	}), WithStrictDecoding())
+
+	lines, err := client.FetchLinesToMerchant("m1", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(9007199254740993), lines[0].Balance)
+	assert.Equal(t, int64(3000000000), lines[0].Fee)
+	assert.Equal(t, int64(9007199254740993), ComputeBalance(lines))
+}
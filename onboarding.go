@@ -0,0 +1,126 @@
+package paylike
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Onboarding creates a merchant along with its apps and admin users in one
+// call, validating the merchant first and undoing the links and invitations
+// made so far if a later step fails
+// The API cannot delete merchants, so a merchant created by a failed
+// onboarding remains and is returned along with the error
+type Onboarding struct {
+	client     Client
+	dto        MerchantCreateDTO
+	apps       []AppID
+	admins     []string
+	validators []MerchantValidator
+}
+
+// Onboard starts the onboarding of a merchant described by the given DTO
+func (c Client) Onboard(dto MerchantCreateDTO) *Onboarding {
+	return &Onboarding{client: c, dto: dto, validators: []MerchantValidator{ValidateMerchant}}
+}
+
+// WithApp links the given app to the merchant, besides the app of the client
+func (o *Onboarding) WithApp(appID AppID) *Onboarding {
+	o.apps = append(o.apps, appID)
+	return o
+}
+
+// WithAdmin invites the user with the given email to the merchant
+func (o *Onboarding) WithAdmin(email string) *Onboarding {
+	o.admins = append(o.admins, email)
+	return o
+}
+
+// WithValidator adds a check of the merchant to the validation pipeline,
+// e.g. for rules of the platform onboarding the merchant
+func (o *Onboarding) WithValidator(validator MerchantValidator) *Onboarding {
+	o.validators = append(o.validators, validator)
+	return o
+}
+
+// Validate runs the validation pipeline, returning a *ValidationError
+// listing all rejected fields
+func (o *Onboarding) Validate() error {
+	var details []ErrorDetail
+	for _, validator := range o.validators {
+		details = append(details, validator(o.dto)...)
+	}
+	for _, admin := range o.admins {
+		if admin == "" {
+			details = append(details, ErrorDetail{Field: "admins", Message: "must not be empty"})
+		}
+	}
+	if len(details) > 0 {
+		return &ValidationError{Details: details}
+	}
+	return nil
+}
+
+// Run validates and creates the merchant, links the apps and invites the
+// admins, returning a *SagaError if a step fails
+func (o *Onboarding) Run(ctx context.Context) (*Merchant, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	c := o.client.with([]CallOption{WithContext(ctx)})
+	var merchant *Merchant
+	saga := NewSaga(NewMemorySagaStore(), SagaStep{
+		Name: "create merchant",
+		Action: func(ctx context.Context, state *SagaState) error {
+			var err error
+			merchant, err = c.CreateMerchant(o.dto)
+			if err == nil && merchant == nil {
+				err = errors.New("paylike: no merchant in response")
+			}
+			return err
+		},
+	})
+	for _, appID := range o.apps {
+		appID := appID
+		saga.Then(SagaStep{
+			Name: "add app " + string(appID),
+			Action: func(ctx context.Context, state *SagaState) error {
+				return c.AddAppToMerchant(merchant.ID, appID)
+			},
+			Compensate: func(ctx context.Context, state *SagaState) error {
+				return c.RevokeAppFromMerchant(merchant.ID, appID)
+			},
+		})
+	}
+	for _, email := range o.admins {
+		email := email
+		saga.Then(SagaStep{
+			Name: "invite " + email,
+			Action: func(ctx context.Context, state *SagaState) error {
+				_, err := c.InviteUserToMerchant(merchant.ID, email)
+				return err
+			},
+			Compensate: func(ctx context.Context, state *SagaState) error {
+				return c.revokeUserByEmail(merchant.ID, email)
+			},
+		})
+	}
+	_, err := saga.Run(ctx, "onboarding")
+	return merchant, err
+}
+
+// revokeUserByEmail revokes the user with the given email from the merchant
+func (c Client) revokeUserByEmail(merchantID MerchantID, email string) error {
+	var userID UserID
+	err := c.EachUserToMerchant(merchantID, Pagination{}, func(user *User) error {
+		if !strings.EqualFold(user.Email, email) {
+			return nil
+		}
+		userID = user.ID
+		return ErrStopPagination
+	})
+	if err != nil || userID == "" {
+		return err
+	}
+	return c.RevokeUserFromMerchant(merchantID, userID)
+}
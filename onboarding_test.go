@@ -0,0 +1,105 @@
+package paylike
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func validMerchantDTO() MerchantCreateDTO {
+	return MerchantCreateDTO{
+		Name:       "Shop",
+		Currency:   "DKK",
+		Email:      "shop@example.com",
+		Website:    "https://shop.example.com",
+		Descriptor: "SHOP",
+		Company:    &MerchantCompany{Country: "DK"},
+	}
+}
+
+// newOnboardingTestClient creates a client talking to a fake API failing to
+// invite the given email, recording the requests made
+func newOnboardingTestClient(t *testing.T, failing string, requests *[]string) *Client {
+	return newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.Method+" "+r.URL.Path)
+		switch r.Method + " " + r.URL.Path {
+		case "POST /merchants":
+			w.Write([]byte(`{"merchant":{"id":"m1","name":"Shop"}}`))
+		case "POST /merchants/m1/users":
+			var body struct{ Email string }
+			json.NewDecoder(r.Body).Decode(&body)
+			if body.Email == failing {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"message":"invalid email"}`))
+				return
+			}
+			w.Write([]byte(`{"isMember":false}`))
+		case "GET /merchants/m1/users":
+			w.Write([]byte(`[{"id":"u1","email":"admin@example.com"}]`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+}
+
+func TestOnboarding(t *testing.T) {
+	var requests []string
+	client := newOnboardingTestClient(t, "", &requests)
+	merchant, err := client.Onboard(validMerchantDTO()).
+		WithApp("app2").
+		WithAdmin("admin@example.com").
+		Run(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, MerchantID("m1"), merchant.ID)
+	assert.Equal(t, []string{"POST /merchants", "POST /merchants/m1/apps", "POST /merchants/m1/users"}, requests)
+}
+
+func TestOnboardingRollback(t *testing.T) {
+	var requests []string
+	client := newOnboardingTestClient(t, "other@example.com", &requests)
+	merchant, err := client.Onboard(validMerchantDTO()).
+		WithApp("app2").
+		WithAdmin("admin@example.com").
+		WithAdmin("other@example.com").
+		Run(context.Background())
+	var sagaErr *SagaError
+	assert.True(t, errors.As(err, &sagaErr))
+	assert.Equal(t, "invite other@example.com", sagaErr.Step)
+	assert.Empty(t, sagaErr.CompensationErrors)
+	assert.Equal(t, MerchantID("m1"), merchant.ID)
+	assert.Equal(t, []string{
+		"POST /merchants",
+		"POST /merchants/m1/apps",
+		"POST /merchants/m1/users",
+		"POST /merchants/m1/users",
+		"GET /merchants/m1/users",
+		"DELETE /merchants/m1/users/u1",
+		"DELETE /merchants/m1/apps/app2",
+	}, requests)
+}
+
+func TestOnboardingValidation(t *testing.T) {
+	var requests []string
+	client := newOnboardingTestClient(t, "", &requests)
+	dto := validMerchantDTO()
+	dto.Bank = &MerchantBank{Iban: "DK5000400440116244"}
+	_, err := client.Onboard(dto).
+		WithValidator(func(dto MerchantCreateDTO) []ErrorDetail {
+			if dto.Currency != "EUR" {
+				return []ErrorDetail{{Field: "currency", Message: "must be EUR on this platform"}}
+			}
+			return nil
+		}).
+		Run(context.Background())
+	var validationErr *ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []ErrorDetail{
+		{Field: "bank.iban", Message: "must be a valid IBAN"},
+		{Field: "currency", Message: "must be EUR on this platform"},
+	}, validationErr.Details)
+	assert.Empty(t, requests)
+}
@@ -0,0 +1,167 @@
+package paylike
+
+import (
+	"context"
+	"strings"
+)
+
+// Operation describes a single API operation performed by the client
+type Operation struct {
+	Name       string // name of the client method, e.g. "CaptureTransaction"
+	Method     string // HTTP method
+	Path       string // path template, e.g. "/transactions/{transactionId}/captures"
+	Idempotent bool   // whether repeating the request has no further effect
+	MovesMoney bool   // whether the operation authorizes, captures, refunds or voids money
+}
+
+// All operations supported by the client
+var (
+	OpCreateApp              = Operation{"CreateApp", "POST", "/apps", false, false}
+	OpFetchApp               = Operation{"FetchApp", "GET", "/me", true, false}
+	OpCreateMerchant         = Operation{"CreateMerchant", "POST", "/merchants", false, false}
+	OpGetMerchant            = Operation{"GetMerchant", "GET", "/merchants/{merchantId}", true, false}
+	OpFetchMerchants         = Operation{"FetchMerchants", "GET", "/identities/{appId}/merchants", true, false}
+	OpUpdateMerchant         = Operation{"UpdateMerchant", "PUT", "/merchants/{merchantId}", true, false}
+	OpInviteUserToMerchant   = Operation{"InviteUserToMerchant", "POST", "/merchants/{merchantId}/users", false, false}
+	OpFetchUsersToMerchant   = Operation{"FetchUsersToMerchant", "GET", "/merchants/{merchantId}/users", true, false}
+	OpRevokeUserFromMerchant = Operation{"RevokeUserFromMerchant", "DELETE", "/merchants/{merchantId}/users/{userId}", true, false}
+	OpAddAppToMerchant       = Operation{"AddAppToMerchant", "POST", "/merchants/{merchantId}/apps", false, false}
+	OpFetchAppsToMerchant    = Operation{"FetchAppsToMerchant", "GET", "/merchants/{merchantId}/apps", true, false}
+	OpRevokeAppFromMerchant  = Operation{"RevokeAppFromMerchant", "DELETE", "/merchants/{merchantId}/apps/{appId}", true, false}
+	OpFetchLinesToMerchant   = Operation{"FetchLinesToMerchant", "GET", "/merchants/{merchantId}/lines", true, false}
+	OpCreateTransaction      = Operation{"CreateTransaction", "POST", "/merchants/{merchantId}/transactions", false, true}
+	OpListTransactions       = Operation{"ListTransactions", "GET", "/merchants/{merchantId}/transactions", true, false}
+	OpCaptureTransaction     = Operation{"CaptureTransaction", "POST", "/transactions/{transactionId}/captures", false, true}
+	OpRefundTransaction      = Operation{"RefundTransaction", "POST", "/transactions/{transactionId}/refunds", false, true}
+	OpVoidTransaction        = Operation{"VoidTransaction", "POST", "/transactions/{transactionId}/voids", false, true}
+	OpFindTransaction        = Operation{"FindTransaction", "GET", "/transactions/{transactionId}", true, false}
+	OpFetchCard              = Operation{"FetchCard", "GET", "/cards/{cardId}", true, false}
+	OpCreateCard             = Operation{"CreateCard", "POST", "/merchants/{merchantId}/cards", false, false}
+	OpUpdateCard             = Operation{"UpdateCard", "PUT", "/cards/{cardId}", true, false}
+	OpFetchCountries         = Operation{"FetchCountries", "GET", "/countries", true, false}
+	OpFetchCurrencies        = Operation{"FetchCurrencies", "GET", "/currencies", true, false}
+)
+
+// operations is the catalog of all operations in the order of the API docs
+var operations = []Operation{
+	OpCreateApp,
+	OpFetchApp,
+	OpCreateMerchant,
+	OpGetMerchant,
+	OpFetchMerchants,
+	OpUpdateMerchant,
+	OpInviteUserToMerchant,
+	OpFetchUsersToMerchant,
+	OpRevokeUserFromMerchant,
+	OpAddAppToMerchant,
+	OpFetchAppsToMerchant,
+	OpRevokeAppFromMerchant,
+	OpFetchLinesToMerchant,
+	OpCreateTransaction,
+	OpListTransactions,
+	OpCaptureTransaction,
+	OpRefundTransaction,
+	OpVoidTransaction,
+	OpFindTransaction,
+	OpFetchCard,
+	OpCreateCard,
+	OpUpdateCard,
+	OpFetchCountries,
+	OpFetchCurrencies,
+}
+
+// Operations returns the catalog of all operations supported by the client,
+// e.g. for generating authorization matrices or audit documentation
+func Operations() []Operation {
+	return append([]Operation(nil), operations...)
+}
+
+// LookupOperation finds an operation by the name of its client method
+func LookupOperation(name string) (Operation, bool) {
+	for _, op := range operations {
+		if op.Name == name {
+			return op, true
+		}
+	}
+	return Operation{}, false
+}
+
+// MatchOperation finds the operation served by the given request method and
+// path along with the values of its path parameters, useful for fakes and
+// recorders of the API
+func MatchOperation(method, path string) (Operation, []string, bool) {
+	for _, op := range operations {
+		if op.Method != method {
+			continue
+		}
+		if params, ok := op.match(path); ok {
+			return op, params, true
+		}
+	}
+	return Operation{}, nil, false
+}
+
+// OperationFromContext returns the operation a given outgoing request
+// is performing, useful for transports and other middlewares
+func OperationFromContext(ctx context.Context) (Operation, bool) {
+	op, ok := ctx.Value(operationKey{}).(Operation)
+	return op, ok
+}
+
+// operationKey is the context key for the operation of a request
+type operationKey struct{}
+
+// expand fills the placeholders of the path template with the given params in order
+func (o Operation) expand(params ...string) string {
+	path := o.Path
+	for _, param := range params {
+		start := strings.Index(path, "{")
+		end := strings.Index(path, "}")
+		if start < 0 || end < start {
+			break
+		}
+		path = path[:start] + param + path[end+1:]
+	}
+	return path
+}
+
+// param returns the value of the given path parameter among the given params
+func (o Operation) param(name string, params ...string) string {
+	path := o.Path
+	for _, param := range params {
+		start := strings.Index(path, "{")
+		end := strings.Index(path, "}")
+		if start < 0 || end < start {
+			break
+		}
+		if path[start+1:end] == name {
+			return param
+		}
+		path = path[end+1:]
+	}
+	return ""
+}
+
+// match returns the values of the path parameters if the given path
+// matches the path template
+func (o Operation) match(path string) ([]string, bool) {
+	template := strings.Split(o.Path, "/")
+	segments := strings.Split(path, "/")
+	if len(template) != len(segments) {
+		return nil, false
+	}
+	var params []string
+	for i, segment := range segments {
+		if strings.HasPrefix(template[i], "{") {
+			if segment == "" {
+				return nil, false
+			}
+			params = append(params, segment)
+			continue
+		}
+		if segment != template[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
@@ -0,0 +1,69 @@
+package paylike
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperations(t *testing.T) {
+	ops := Operations()
+	assert.Len(t, ops, len(operations))
+	for _, op := range ops {
+		found, ok := LookupOperation(op.Name)
+		assert.True(t, ok)
+		assert.Equal(t, op, found)
+		if op.MovesMoney {
+			assert.Equal(t, "POST", op.Method)
+		}
+	}
+	_, ok := LookupOperation("Unknown")
+	assert.False(t, ok)
+}
+
+func TestOperationExpand(t *testing.T) {
+	assert.Equal(t, "/merchants/m1/users/u1", OpRevokeUserFromMerchant.expand("m1", "u1"))
+	assert.Equal(t, "/merchants/{merchantId}/users/{userId}", OpRevokeUserFromMerchant.expand())
+	assert.Equal(t, "/me", OpFetchApp.expand())
+}
+
+func TestMatchOperation(t *testing.T) {
+	op, params, ok := MatchOperation("DELETE", "/merchants/m1/users/u1")
+	assert.True(t, ok)
+	assert.Equal(t, OpRevokeUserFromMerchant, op)
+	assert.Equal(t, []string{"m1", "u1"}, params)
+
+	op, params, ok = MatchOperation("GET", "/merchants/m1")
+	assert.True(t, ok)
+	assert.Equal(t, OpGetMerchant, op)
+	assert.Equal(t, []string{"m1"}, params)
+
+	_, _, ok = MatchOperation("GET", "/merchants/m1/unknown")
+	assert.False(t, ok)
+	_, _, ok = MatchOperation("PATCH", "/merchants/m1")
+	assert.False(t, ok)
+}
+
+func TestOperationRequest(t *testing.T) {
+	var method, uri string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, uri = r.Method, r.URL.RequestURI()
+	}))
+	_, err := client.FetchLinesToMerchant(TestMerchant, 5)
+	assert.Nil(t, err)
+	assert.Equal(t, "GET", method)
+	assert.Equal(t, "/merchants/"+TestMerchant+"/lines?limit=5", uri)
+
+	req, err := client.newRequest(OpCaptureTransaction, nil, "tx1")
+	assert.Nil(t, err)
+	op, ok := OperationFromContext(req.Context())
+	assert.True(t, ok)
+	assert.Equal(t, OpCaptureTransaction, op)
+}
+
+func TestOperationParam(t *testing.T) {
+	assert.Equal(t, "m1", OpRevokeUserFromMerchant.param("merchantId", "m1", "u1"))
+	assert.Equal(t, "u1", OpRevokeUserFromMerchant.param("userId", "m1", "u1"))
+	assert.Equal(t, "", OpCaptureTransaction.param("merchantId", "tx1"))
+}
@@ -0,0 +1,52 @@
+package paylike
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Option configures optional behaviour of a client at construction time
+type Option func(*Client)
+
+// WithBaseURL sends the requests to the given URL instead of the live API,
+// e.g. to a fake server in tests
+func WithBaseURL(url string) Option {
+	return func(c *Client) {
+		c.baseAPI = strings.TrimSuffix(url, "/")
+	}
+}
+
+// WithHTTPClient sends the requests using the given HTTP client, e.g. one
+// recording or replaying interactions with the API in tests
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.client = client
+	}
+}
+
+// WithHeader sets the given header on every request of the client, e.g. a
+// tenant header required by an outbound gateway
+func WithHeader(name, value string) Option {
+	return func(c *Client) {
+		if c.header == nil {
+			c.header = http.Header{}
+		}
+		c.header.Set(name, value)
+	}
+}
+
+// WithEndpointHeader sends the normalized endpoint label of every request
+// (e.g. "POST /transactions/{transactionId}/captures") in the given header,
+// so service meshes and L7 tooling can aggregate requests without raw IDs
+func WithEndpointHeader(name string) Option {
+	return func(c *Client) {
+		c.endpointHeader = name
+	}
+}
+
+// WithMetricsHook registers a hook called after every finished request attempt
+func WithMetricsHook(hook func(RequestMetrics)) Option {
+	return func(c *Client) {
+		c.metricsHook = hook
+	}
+}
@@ -0,0 +1,484 @@
+// Package outbox queues captures, refunds and voids in a persistent outbox
+// and flushes them to Paylike with retries, so short outages of the API
+// don't lose work such as end-of-day captures
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	paylike "github.com/paylike/go-api"
+)
+
+// IdempotencyKeyHeader is the header carrying the key of an entry on every
+// attempt to perform it
+const IdempotencyKeyHeader = paylike.IdempotencyKeyHeader
+
+// Kind describes the operation performed by an entry
+type Kind string
+
+// Possible entry kinds
+const (
+	Capture Kind = "capture"
+	Refund  Kind = "refund"
+	Void    Kind = "void"
+)
+
+// Status describes the state of an entry
+type Status string
+
+// Possible entry statuses
+const (
+	Pending Status = "pending" // performed when due
+	Done    Status = "done"    // performed successfully
+	Failed  Status = "failed"  // rejected by the API or out of attempts
+	Unknown Status = "unknown" // outcome unknown, reconciled against the transaction before anything is repeated
+)
+
+// Entry describes a queued operation on a transaction
+type Entry struct {
+	Key           string                      `json:"key"` // idempotency key, unique per operation
+	Kind          Kind                        `json:"kind"`
+	TransactionID paylike.TxID                `json:"transactionId"`
+	Trail         paylike.TransactionTrailDTO `json:"trail"`
+	Status        Status                      `json:"status"`
+	Created       time.Time                   `json:"created"`
+	NextAttempt   time.Time                   `json:"nextAttempt"`
+	Attempts      int                         `json:"attempts"` // failed attempts so far
+	LastError     string                      `json:"lastError,omitempty"`
+	Baseline      *int64                      `json:"baseline,omitempty"` // amount of the kind on the transaction before the first attempt
+}
+
+// ErrDuplicate is returned by Storage.Add when an entry with the same key
+// has been added before
+var ErrDuplicate = errors.New("outbox: duplicate entry")
+
+// Storage persists the entries of an outbox
+type Storage interface {
+	// Add stores a new entry, returning ErrDuplicate if its key is taken
+	Add(ctx context.Context, entry *Entry) error
+	// Due returns the pending entries to be performed at the given time
+	Due(ctx context.Context, at time.Time) ([]*Entry, error)
+	// Unknown returns the entries whose outcome is unknown
+	Unknown(ctx context.Context) ([]*Entry, error)
+	// Save stores the given entry
+	Save(ctx context.Context, entry *Entry) error
+}
+
+// Trailer captures, refunds, voids and finds transactions, implemented by
+// paylike.Client
+type Trailer interface {
+	FindTransaction(transactionID paylike.TxID, opts ...paylike.CallOption) (*paylike.Transaction, error)
+	CaptureTransaction(transactionID paylike.TxID, dto paylike.TransactionTrailDTO, opts ...paylike.CallOption) (*paylike.Transaction, error)
+	RefundTransaction(transactionID paylike.TxID, dto paylike.TransactionTrailDTO, opts ...paylike.CallOption) (*paylike.Transaction, error)
+	VoidTransaction(transactionID paylike.TxID, dto paylike.TransactionTrailDTO, opts ...paylike.CallOption) (*paylike.Transaction, error)
+}
+
+// Outbox queues entries and performs them through a trailer
+type Outbox struct {
+	trailer     Trailer
+	storage     Storage
+	interval    time.Duration
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	maxAttempts int
+	onDone      func(ctx context.Context, entry *Entry, transaction *paylike.Transaction)
+	onFailed    func(ctx context.Context, entry *Entry, err error)
+	now         func() time.Time
+	ids         paylike.IDGenerator
+}
+
+// Option configures an outbox
+type Option func(*Outbox)
+
+// WithPollInterval changes how often Run looks for due entries,
+// defaults to ten seconds
+func WithPollInterval(interval time.Duration) Option {
+	return func(o *Outbox) {
+		o.interval = interval
+	}
+}
+
+// WithBackoff changes the delay before retrying a failed entry, doubled on
+// every attempt up to the given maximum, defaults to 30 seconds up to 30 minutes
+func WithBackoff(base, max time.Duration) Option {
+	return func(o *Outbox) {
+		o.baseDelay = base
+		o.maxDelay = max
+	}
+}
+
+// WithMaxAttempts gives up on entries after the given number of failed
+// attempts, by default entries are retried until the API accepts or rejects them
+func WithMaxAttempts(n int) Option {
+	return func(o *Outbox) {
+		o.maxAttempts = n
+	}
+}
+
+// OnDone registers a hook called after an entry has been performed
+func OnDone(hook func(ctx context.Context, entry *Entry, transaction *paylike.Transaction)) Option {
+	return func(o *Outbox) {
+		o.onDone = hook
+	}
+}
+
+// OnFailed registers a hook called after giving up on an entry
+func OnFailed(hook func(ctx context.Context, entry *Entry, err error)) Option {
+	return func(o *Outbox) {
+		o.onFailed = hook
+	}
+}
+
+// WithClock makes the outbox tell the time with the given clock, e.g. to
+// schedule retries deterministically in tests
+func WithClock(clock paylike.Clock) Option {
+	return func(o *Outbox) {
+		o.now = clock.Now
+	}
+}
+
+// WithIDGenerator changes how EnqueueNew generates idempotency keys,
+// defaults to paylike.RandomIDs
+func WithIDGenerator(ids paylike.IDGenerator) Option {
+	return func(o *Outbox) {
+		o.ids = ids
+	}
+}
+
+// New creates an outbox performing its entries through the given trailer
+func New(trailer Trailer, storage Storage, opts ...Option) *Outbox {
+	o := &Outbox{
+		trailer:   trailer,
+		storage:   storage,
+		interval:  10 * time.Second,
+		baseDelay: 30 * time.Second,
+		maxDelay:  30 * time.Minute,
+		now:       time.Now,
+		ids:       paylike.RandomIDs,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Enqueue adds a pending entry performing the given operation as soon as
+// possible, enqueuing the same key again has no effect
+func (o *Outbox) Enqueue(ctx context.Context, key string, kind Kind, transactionID paylike.TxID, dto paylike.TransactionTrailDTO) error {
+	if key == "" {
+		return errors.New("outbox: missing idempotency key")
+	}
+	if kind != Capture && kind != Refund && kind != Void {
+		return errors.New("outbox: unknown kind " + string(kind))
+	}
+	now := o.now()
+	err := o.storage.Add(ctx, &Entry{
+		Key:           key,
+		Kind:          kind,
+		TransactionID: transactionID,
+		Trail:         dto,
+		Status:        Pending,
+		Created:       now,
+		NextAttempt:   now,
+	})
+	if errors.Is(err, ErrDuplicate) {
+		return nil
+	}
+	return err
+}
+
+// EnqueueNew adds a pending entry under a newly generated idempotency key,
+// returned so it can be stored along with the operation it performs
+func (o *Outbox) EnqueueNew(ctx context.Context, kind Kind, transactionID paylike.TxID, dto paylike.TransactionTrailDTO) (string, error) {
+	key := o.ids.NewID()
+	return key, o.Enqueue(ctx, key, kind, transactionID, dto)
+}
+
+// Run flushes due entries every poll interval until the context is done
+func (o *Outbox) Run(ctx context.Context) error {
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+	for {
+		if err := o.Flush(ctx); err != nil && ctx.Err() == nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Flush reconciles the entries whose outcome is unknown, then performs all
+// entries that are due
+func (o *Outbox) Flush(ctx context.Context) error {
+	unknown, err := o.storage.Unknown(ctx)
+	if err != nil {
+		return err
+	}
+	for _, entry := range unknown {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := o.reconcile(ctx, entry); err != nil {
+			return err
+		}
+	}
+	due, err := o.storage.Due(ctx, o.now())
+	if err != nil {
+		return err
+	}
+	for _, entry := range due {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := o.perform(ctx, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// perform performs the given entry and schedules its retry on failure
+// Only failures known not to have been processed are retried; the others
+// are marked as unknown and reconciled against the transaction
+func (o *Outbox) perform(ctx context.Context, entry *Entry) error {
+	if entry.Baseline == nil {
+		transaction, err := o.find(ctx, entry)
+		if err != nil {
+			return o.fail(ctx, entry, err, paylike.IsRetryable(err))
+		}
+		baseline := performed(entry.Kind, transaction)
+		entry.Baseline = &baseline
+		if err := o.storage.Save(ctx, entry); err != nil {
+			return err
+		}
+	} else {
+		// the entry may have been performed before a crash kept its outcome
+		// from being saved, whatever its attempts
+		retry, err := o.reconcile(ctx, entry)
+		if err != nil || !retry {
+			return err
+		}
+	}
+	method := o.trailer.CaptureTransaction
+	switch entry.Kind {
+	case Refund:
+		method = o.trailer.RefundTransaction
+	case Void:
+		method = o.trailer.VoidTransaction
+	}
+	transaction, err := method(entry.TransactionID, entry.Trail,
+		paylike.WithContext(ctx),
+		paylike.WithCallHeader(IdempotencyKeyHeader, entry.Key),
+	)
+	switch {
+	case err == nil:
+		return o.done(ctx, entry, transaction)
+	case paylike.IsNotProcessed(err):
+		return o.fail(ctx, entry, err, true)
+	case paylike.IsClientError(err):
+		return o.fail(ctx, entry, err, false)
+	}
+	entry.Attempts++
+	entry.Status = Unknown
+	entry.LastError = err.Error()
+	if saveErr := o.storage.Save(ctx, entry); saveErr != nil {
+		return saveErr
+	}
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+	return nil
+}
+
+// reconcile tells whether the given entry has been performed by comparing
+// the amount of its kind on the transaction with the baseline, assuming the
+// outbox is the only one to capture, refund or void the transaction
+// Performed entries are marked as done, while the entries that cannot be told
+// apart are failed for someone to look into; it returns whether the entry has
+// certainly not been performed and is to be performed (again)
+func (o *Outbox) reconcile(ctx context.Context, entry *Entry) (bool, error) {
+	transaction, err := o.find(ctx, entry)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return false, err
+		}
+		if entry.Status == Unknown {
+			return false, nil // reconciled again on the next flush
+		}
+		return false, o.fail(ctx, entry, err, paylike.IsRetryable(err))
+	}
+	if entry.Baseline == nil {
+		return false, o.fail(ctx, entry, errors.New("outbox: unknown outcome without baseline"), false)
+	}
+	amount := performed(entry.Kind, transaction)
+	switch {
+	case amount >= *entry.Baseline+entry.Trail.Amount:
+		return false, o.done(ctx, entry, transaction)
+	case amount == *entry.Baseline:
+		if entry.Status == Unknown {
+			entry.Status = Pending
+			entry.NextAttempt = o.now()
+			return true, o.storage.Save(ctx, entry)
+		}
+		return true, nil
+	}
+	return false, o.fail(ctx, entry, fmt.Errorf("outbox: %s of %d partially reflected on the transaction (%d, was %d)",
+		entry.Kind, entry.Trail.Amount, amount, *entry.Baseline), false)
+}
+
+// find fetches the transaction of the given entry, bypassing any cache
+func (o *Outbox) find(ctx context.Context, entry *Entry) (*paylike.Transaction, error) {
+	transaction, err := o.trailer.FindTransaction(entry.TransactionID, paylike.WithContext(ctx), paylike.SkipCache())
+	if err == nil && transaction == nil {
+		err = errors.New("outbox: transaction not found")
+	}
+	return transaction, err
+}
+
+// done marks the given entry as performed
+func (o *Outbox) done(ctx context.Context, entry *Entry, transaction *paylike.Transaction) error {
+	entry.Status = Done
+	entry.LastError = ""
+	if err := o.storage.Save(ctx, entry); err != nil {
+		return err
+	}
+	if o.onDone != nil {
+		o.onDone(ctx, entry, transaction)
+	}
+	return nil
+}
+
+// fail records the given failure of the entry, scheduling a retry if the
+// failure is retryable and attempts are left, giving up otherwise
+func (o *Outbox) fail(ctx context.Context, entry *Entry, err error, retryable bool) error {
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+	entry.Attempts++
+	entry.LastError = err.Error()
+	if retryable && (o.maxAttempts <= 0 || entry.Attempts < o.maxAttempts) {
+		entry.Status = Pending
+		entry.NextAttempt = o.now().Add(o.delay(entry.Attempts))
+		return o.storage.Save(ctx, entry)
+	}
+	entry.Status = Failed
+	if err := o.storage.Save(ctx, entry); err != nil {
+		return err
+	}
+	if o.onFailed != nil {
+		o.onFailed(ctx, entry, err)
+	}
+	return nil
+}
+
+// performed returns the amount of the given kind performed on the transaction
+func performed(kind Kind, transaction *paylike.Transaction) int64 {
+	switch kind {
+	case Refund:
+		return transaction.RefundedAmount
+	case Void:
+		return transaction.VoidedAmount
+	}
+	return transaction.CapturedAmount
+}
+
+// delay returns the delay before the next attempt after the given number
+// of failed attempts
+func (o *Outbox) delay(attempts int) time.Duration {
+	delay := o.baseDelay
+	for i := 1; i < attempts && delay < o.maxDelay; i++ {
+		delay *= 2
+	}
+	if o.maxDelay > 0 && delay > o.maxDelay {
+		delay = o.maxDelay
+	}
+	return delay
+}
+
+// MemoryStorage is an in-memory Storage, mostly useful for testing
+type MemoryStorage struct {
+	mu      sync.Mutex
+	entries map[string]Entry
+}
+
+// NewMemoryStorage creates a new empty in-memory storage
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{entries: map[string]Entry{}}
+}
+
+// Add stores a copy of the given entry unless its key is taken
+func (s *MemoryStorage) Add(ctx context.Context, entry *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entries[entry.Key]; ok {
+		return ErrDuplicate
+	}
+	s.entries[entry.Key] = *entry
+	return nil
+}
+
+// Due returns copies of the pending entries due at the given time, in the
+// order they have been created
+func (s *MemoryStorage) Due(ctx context.Context, at time.Time) ([]*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []*Entry
+	for _, entry := range s.entries {
+		if entry.Status == Pending && !entry.NextAttempt.After(at) {
+			entry := entry
+			due = append(due, &entry)
+		}
+	}
+	sortEntries(due)
+	return due, nil
+}
+
+// sortEntries sorts the given entries in the order they have been created
+func sortEntries(entries []*Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Created.Equal(entries[j].Created) {
+			return entries[i].Key < entries[j].Key
+		}
+		return entries[i].Created.Before(entries[j].Created)
+	})
+}
+
+// Unknown returns copies of the entries whose outcome is unknown, in the
+// order they have been created
+func (s *MemoryStorage) Unknown(ctx context.Context) ([]*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var unknown []*Entry
+	for _, entry := range s.entries {
+		if entry.Status == Unknown {
+			entry := entry
+			unknown = append(unknown, &entry)
+		}
+	}
+	sortEntries(unknown)
+	return unknown, nil
+}
+
+// Save stores a copy of the given entry
+func (s *MemoryStorage) Save(ctx context.Context, entry *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[entry.Key] = *entry
+	return nil
+}
+
+// Get returns a copy of the entry with the given key
+func (s *MemoryStorage) Get(key string) (*Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	return &entry, ok
+}
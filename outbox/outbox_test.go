@@ -0,0 +1,197 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	paylike "github.com/paylike/go-api"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestOutbox(t *testing.T, handler http.HandlerFunc, storage Storage, now *time.Time, opts ...Option) *Outbox {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client := paylike.NewClient("key", paylike.WithBaseURL(server.URL))
+	clock := paylike.ClockFunc(func() time.Time { return *now })
+	return New(client, storage, append([]Option{WithClock(clock)}, opts...)...)
+}
+
+func TestFlush(t *testing.T) {
+	now := time.Date(2024, 1, 31, 22, 0, 0, 0, time.UTC)
+	var calls []string
+	storage := NewMemoryStorage()
+	o := newTestOutbox(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			calls = append(calls, r.URL.Path+" "+r.Header.Get(IdempotencyKeyHeader))
+		}
+		w.Write([]byte(`{"transaction":{"id":"tx1"}}`))
+	}, storage, &now)
+
+	assert.Nil(t, o.Enqueue(context.Background(), "order-1-capture", Capture, "tx1", paylike.TransactionTrailDTO{Amount: 100}))
+	assert.Nil(t, o.Enqueue(context.Background(), "order-1-capture", Capture, "tx1", paylike.TransactionTrailDTO{Amount: 100}))
+	assert.Nil(t, o.Enqueue(context.Background(), "order-2-refund", Refund, "tx2", paylike.TransactionTrailDTO{Amount: 50}))
+	assert.Nil(t, o.Flush(context.Background()))
+	assert.ElementsMatch(t, []string{"/transactions/tx1/captures order-1-capture", "/transactions/tx2/refunds order-2-refund"}, calls)
+
+	entry, _ := storage.Get("order-1-capture")
+	assert.Equal(t, Done, entry.Status)
+	assert.Nil(t, o.Flush(context.Background()))
+	assert.Len(t, calls, 2)
+}
+
+func TestFlushRetriesOutages(t *testing.T) {
+	now := time.Date(2024, 1, 31, 22, 0, 0, 0, time.UTC)
+	status := http.StatusServiceUnavailable
+	storage := NewMemoryStorage()
+	var done []string
+	o := newTestOutbox(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(`{"transaction":{"id":"tx1"}}`))
+	}, storage, &now, WithBackoff(time.Minute, time.Hour), OnDone(func(ctx context.Context, entry *Entry, transaction *paylike.Transaction) {
+		done = append(done, entry.Key)
+	}))
+
+	assert.Nil(t, o.Enqueue(context.Background(), "k1", Capture, "tx1", paylike.TransactionTrailDTO{Amount: 100}))
+	assert.Nil(t, o.Flush(context.Background()))
+	entry, _ := storage.Get("k1")
+	assert.Equal(t, Pending, entry.Status)
+	assert.Equal(t, 1, entry.Attempts)
+	assert.Equal(t, now.Add(time.Minute), entry.NextAttempt)
+
+	now = now.Add(time.Minute)
+	assert.Nil(t, o.Flush(context.Background()))
+	entry, _ = storage.Get("k1")
+	assert.Equal(t, now.Add(2*time.Minute), entry.NextAttempt)
+
+	status = http.StatusOK
+	assert.Nil(t, o.Flush(context.Background()))
+	assert.Empty(t, done)
+	now = now.Add(2 * time.Minute)
+	assert.Nil(t, o.Flush(context.Background()))
+	assert.Equal(t, []string{"k1"}, done)
+}
+
+func TestFlushGivesUp(t *testing.T) {
+	now := time.Date(2024, 1, 31, 22, 0, 0, 0, time.UTC)
+	storage := NewMemoryStorage()
+	var failed []string
+	o := newTestOutbox(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet:
+			w.Write([]byte(`{"transaction":{"id":"tx1"}}`))
+			return
+		case r.URL.Path == "/transactions/tx1/captures":
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, storage, &now, WithBackoff(0, 0), WithMaxAttempts(2), OnFailed(func(ctx context.Context, entry *Entry, err error) {
+		failed = append(failed, entry.Key)
+	}))
+
+	assert.Nil(t, o.Enqueue(context.Background(), "rejected", Capture, "tx1", paylike.TransactionTrailDTO{Amount: 100}))
+	assert.Nil(t, o.Enqueue(context.Background(), "outage", Void, "tx2", paylike.TransactionTrailDTO{Amount: 100}))
+	assert.Nil(t, o.Flush(context.Background()))
+	assert.Equal(t, []string{"rejected"}, failed)
+	assert.Nil(t, o.Flush(context.Background()))
+	assert.Equal(t, []string{"rejected", "outage"}, failed)
+
+	entry, _ := storage.Get("outage")
+	assert.Equal(t, Failed, entry.Status)
+	assert.Equal(t, 2, entry.Attempts)
+	assert.NotEmpty(t, entry.LastError)
+}
+
+func TestFlushReconcilesUnknownOutcomes(t *testing.T) {
+	now := time.Date(2024, 1, 31, 22, 0, 0, 0, time.UTC)
+	storage := NewMemoryStorage()
+	captured := map[string]int64{}
+	var captures []string
+	fail := map[string]bool{"tx1": true, "tx2": true}
+	var done []string
+	o := newTestOutbox(t, func(w http.ResponseWriter, r *http.Request) {
+		id := strings.Split(r.URL.Path, "/")[2]
+		if r.Method == http.MethodPost {
+			captures = append(captures, id)
+			if id == "tx1" {
+				captured[id] += 100 // performed although the response is lost
+			}
+			if fail[id] {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			captured[id] += 100
+		}
+		fmt.Fprintf(w, `{"transaction":{"id":%q,"capturedAmount":%d}}`, id, captured[id])
+	}, storage, &now, OnDone(func(ctx context.Context, entry *Entry, transaction *paylike.Transaction) {
+		done = append(done, entry.Key)
+	}))
+
+	assert.Nil(t, o.Enqueue(context.Background(), "k1", Capture, "tx1", paylike.TransactionTrailDTO{Amount: 100}))
+	assert.Nil(t, o.Enqueue(context.Background(), "k2", Capture, "tx2", paylike.TransactionTrailDTO{Amount: 100}))
+	assert.Nil(t, o.Flush(context.Background()))
+	assert.Equal(t, []string{"tx1", "tx2"}, captures)
+	for _, key := range []string{"k1", "k2"} {
+		entry, _ := storage.Get(key)
+		assert.Equal(t, Unknown, entry.Status)
+		assert.Equal(t, int64(0), *entry.Baseline)
+	}
+
+	fail["tx2"] = false
+	assert.Nil(t, o.Flush(context.Background()))
+	assert.Equal(t, []string{"tx1", "tx2", "tx2"}, captures, "only the capture not performed is repeated")
+	assert.Equal(t, []string{"k1", "k2"}, done)
+	assert.Equal(t, int64(100), captured["tx1"])
+	assert.Equal(t, int64(100), captured["tx2"])
+}
+
+func TestFlushReconcilesAfterCrash(t *testing.T) {
+	now := time.Date(2024, 1, 31, 22, 0, 0, 0, time.UTC)
+	storage := NewMemoryStorage()
+	var captures int
+	o := newTestOutbox(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			captures++
+		}
+		w.Write([]byte(`{"transaction":{"id":"tx1","capturedAmount":100}}`))
+	}, storage, &now)
+
+	// the baseline has been saved and the capture performed, but the process
+	// crashed before saving the outcome
+	assert.Nil(t, o.Enqueue(context.Background(), "k1", Capture, "tx1", paylike.TransactionTrailDTO{Amount: 100}))
+	entry, _ := storage.Get("k1")
+	baseline := int64(0)
+	entry.Baseline = &baseline
+	assert.Nil(t, storage.Save(context.Background(), entry))
+
+	assert.Nil(t, o.Flush(context.Background()))
+	assert.Equal(t, 0, captures)
+	entry, _ = storage.Get("k1")
+	assert.Equal(t, Done, entry.Status)
+}
+
+func TestEnqueueNew(t *testing.T) {
+	now := time.Date(2024, 1, 31, 22, 0, 0, 0, time.UTC)
+	storage := NewMemoryStorage()
+	o := New(nil, storage, WithClock(paylike.ClockFunc(func() time.Time { return now })), WithIDGenerator(paylike.IDGeneratorFunc(func() string {
+		return "generated"
+	})))
+	key, err := o.EnqueueNew(context.Background(), Refund, "tx1", paylike.TransactionTrailDTO{Amount: 100})
+	assert.Nil(t, err)
+	assert.Equal(t, "generated", key)
+	entry, ok := storage.Get("generated")
+	assert.True(t, ok)
+	assert.Equal(t, Refund, entry.Kind)
+	assert.Equal(t, now, entry.Created)
+}
+
+func TestEnqueueValidates(t *testing.T) {
+	o := New(nil, NewMemoryStorage())
+	assert.NotNil(t, o.Enqueue(context.Background(), "", Capture, "tx1", paylike.TransactionTrailDTO{Amount: 100}))
+	assert.NotNil(t, o.Enqueue(context.Background(), "k1", Kind("charge"), "tx1", paylike.TransactionTrailDTO{Amount: 100}))
+}
@@ -0,0 +1,266 @@
+package paylike
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+)
+
+// Defaults used by the auto-paginating variants of the list endpoints
+const (
+	DefaultPageSize = 100
+	DefaultMaxItems = 10000
+)
+
+// ErrPaginationLimit is returned when auto-pagination stops after reaching
+// the maximum number of items, along with the items fetched so far
+var ErrPaginationLimit = errors.New("paylike: pagination limit reached")
+
+// ErrStopPagination can be returned from the callback of the Each variants
+// to stop paginating without failing
+var ErrStopPagination = errors.New("paylike: stop pagination")
+
+// Pagination describes how the auto-paginating variants of the list
+// endpoints walk through the pages
+type Pagination struct {
+	PageSize int // optional, items fetched per request, defaults to DefaultPageSize
+	MaxItems int // optional, safety cap on the items fetched, defaults to DefaultMaxItems
+}
+
+// PageRequest describes which page of a list endpoint to fetch
+type PageRequest struct {
+	Limit  int    // required, maximum number of items of the page
+	Before string // optional, cursor of the previous page
+}
+
+// Page describes a page of a list endpoint along with its pagination metadata
+type Page[T any] struct {
+	Items   []*T
+	Count   int    // number of items of the page
+	HasMore bool   // whether another page exists
+	Cursor  string // to be passed as Before to fetch the next page, empty if none
+}
+
+// fetchPage fetches the requested page of the list endpoint performing the
+// given operation, asking for one more item than requested to tell whether
+// another page exists
+func fetchPage[T any](c Client, op Operation, r PageRequest, id func(*T) string, params ...string) (*Page[T], error) {
+	if r.Limit <= 0 {
+		return nil, errors.New("paylike: page limit must be positive")
+	}
+	query := url.Values{"limit": {strconv.Itoa(r.Limit + 1)}}
+	if r.Before != "" {
+		query.Set("before", r.Before)
+	}
+	items, list, err := listPage[T](c, op, query, id, params...)
+	if err != nil {
+		return nil, err
+	}
+	page := &Page[T]{Items: make([]*T, 0, len(items)), HasMore: len(list.ids) > r.Limit}
+	for i, item := range items {
+		if list.indexes[i] < r.Limit {
+			page.Items = append(page.Items, item)
+		}
+	}
+	page.Count = len(page.Items)
+	if page.HasMore {
+		page.Cursor = list.ids[r.Limit-1]
+	}
+	return page, nil
+}
+
+// listPage fetches a page of the list endpoint performing the given
+// operation, along with the IDs of all its elements as returned by the API
+func listPage[T any](c Client, op Operation, query url.Values, id func(*T) string, params ...string) ([]*T, *listInfo, error) {
+	list := &listInfo{}
+	c.call.list = list
+	items, err := listQuery[T](c, op, query, params...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !list.lenient {
+		list.ids = make([]string, len(items))
+		list.indexes = make([]int, len(items))
+		for i, item := range items {
+			list.ids[i] = id(item)
+			list.indexes[i] = i
+		}
+	}
+	return items, list, nil
+}
+
+// paginate fetches the pages of the list endpoint performing the given
+// operation until exhaustion, feeding every item to the given callback
+// The ID of the last element of a page, decoded or not, is used as cursor
+// for the next one
+func paginate[T any](c Client, op Operation, query url.Values, p Pagination, id func(*T) string, each func(*T) error, params ...string) error {
+	pageSize, maxItems := p.PageSize, p.MaxItems
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if maxItems <= 0 {
+		maxItems = DefaultMaxItems
+	}
+	query.Set("limit", strconv.Itoa(pageSize))
+	seen := 0
+	for {
+		page, list, err := listPage[T](c, op, query, id, params...)
+		if err != nil {
+			return err
+		}
+		for _, item := range page {
+			if seen == maxItems {
+				return ErrPaginationLimit
+			}
+			seen++
+			if err := each(item); err != nil {
+				if err == ErrStopPagination {
+					return nil
+				}
+				return err
+			}
+		}
+		if len(list.ids) < pageSize || list.ids[len(list.ids)-1] == "" {
+			return nil
+		}
+		query.Set("before", list.ids[len(list.ids)-1])
+	}
+}
+
+// fetchAll collects all items of the list endpoint performing the given operation
+func fetchAll[T any](c Client, op Operation, p Pagination, id func(*T) string, params ...string) ([]*T, error) {
+	var all []*T
+	err := paginate(c, op, url.Values{}, p, id, func(item *T) error {
+		all = append(all, item)
+		return nil
+	}, params...)
+	return all, err
+}
+
+// stream paginates the list endpoint performing the given operation in the
+// background, sending every item on the returned channel
+// Both channels are closed once pagination stops, the error channel
+// receiving the error that stopped it, if any
+func stream[T any](ctx context.Context, c Client, op Operation, p Pagination, id func(*T) string, params ...string) (<-chan *T, <-chan error) {
+	items := make(chan *T)
+	errs := make(chan error, 1)
+	c.call.ctx = ctx
+	go func() {
+		defer close(errs)
+		defer close(items)
+		err := paginate(c, op, url.Values{}, p, id, func(item *T) error {
+			select {
+			case items <- item:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-c.closing():
+				return ErrClientClosed
+			}
+		}, params...)
+		if err != nil {
+			errs <- err
+		}
+	}()
+	return items, errs
+}
+
+// cursors of the paginated models
+func merchantCursor(m *Merchant) string       { return string(m.ID) }
+func userCursor(u *User) string               { return string(u.ID) }
+func appCursor(a *App) string                 { return string(a.ID) }
+func lineCursor(l *Line) string               { return l.ID }
+func transactionCursor(t *Transaction) string { return string(t.ID) }
+
+// FetchAllMerchants fetches all merchants for given app ID, following the pages
+func (c Client) FetchAllMerchants(appID AppID, p Pagination, opts ...CallOption) ([]*Merchant, error) {
+	return fetchAll(c.with(opts), OpFetchMerchants, p, merchantCursor, string(appID))
+}
+
+// EachMerchant calls fn for every merchant of given app ID, following the pages
+func (c Client) EachMerchant(appID AppID, p Pagination, fn func(*Merchant) error, opts ...CallOption) error {
+	return paginate(c.with(opts), OpFetchMerchants, url.Values{}, p, merchantCursor, fn, string(appID))
+}
+
+// FetchAllUsersToMerchant fetches all users for a given merchant, following the pages
+func (c Client) FetchAllUsersToMerchant(merchantID MerchantID, p Pagination, opts ...CallOption) ([]*User, error) {
+	return fetchAll(c.with(opts), OpFetchUsersToMerchant, p, userCursor, string(merchantID))
+}
+
+// EachUserToMerchant calls fn for every user of a given merchant, following the pages
+func (c Client) EachUserToMerchant(merchantID MerchantID, p Pagination, fn func(*User) error, opts ...CallOption) error {
+	return paginate(c.with(opts), OpFetchUsersToMerchant, url.Values{}, p, userCursor, fn, string(merchantID))
+}
+
+// FetchAllAppsToMerchant fetches all apps for a given merchant, following the pages
+func (c Client) FetchAllAppsToMerchant(merchantID MerchantID, p Pagination, opts ...CallOption) ([]*App, error) {
+	return fetchAll(c.with(opts), OpFetchAppsToMerchant, p, appCursor, string(merchantID))
+}
+
+// EachAppToMerchant calls fn for every app of a given merchant, following the pages
+func (c Client) EachAppToMerchant(merchantID MerchantID, p Pagination, fn func(*App) error, opts ...CallOption) error {
+	return paginate(c.with(opts), OpFetchAppsToMerchant, url.Values{}, p, appCursor, fn, string(merchantID))
+}
+
+// FetchAllLinesToMerchant fetches the full history of a given merchant's balance,
+// following the pages
+func (c Client) FetchAllLinesToMerchant(merchantID MerchantID, p Pagination, opts ...CallOption) ([]*Line, error) {
+	return fetchAll(c.with(opts), OpFetchLinesToMerchant, p, lineCursor, string(merchantID))
+}
+
+// EachLineToMerchant calls fn for every line of a given merchant, following the pages
+func (c Client) EachLineToMerchant(merchantID MerchantID, p Pagination, fn func(*Line) error, opts ...CallOption) error {
+	return paginate(c.with(opts), OpFetchLinesToMerchant, url.Values{}, p, lineCursor, fn, string(merchantID))
+}
+
+// ListAllTransactions lists all transactions under the given merchantID,
+// following the pages
+func (c Client) ListAllTransactions(merchantID MerchantID, p Pagination, opts ...CallOption) ([]*Transaction, error) {
+	return fetchAll(c.with(opts), OpListTransactions, p, transactionCursor, string(merchantID))
+}
+
+// EachTransaction calls fn for every transaction under the given merchantID,
+// following the pages
+func (c Client) EachTransaction(merchantID MerchantID, p Pagination, fn func(*Transaction) error, opts ...CallOption) error {
+	return paginate(c.with(opts), OpListTransactions, url.Values{}, p, transactionCursor, fn, string(merchantID))
+}
+
+// StreamTransactions lists all transactions under the given merchantID in
+// the background, fetching the next page only once the previous one has
+// been consumed
+// Stop consuming by canceling the context
+func (c Client) StreamTransactions(ctx context.Context, merchantID MerchantID, p Pagination, opts ...CallOption) (<-chan *Transaction, <-chan error) {
+	return stream(ctx, c.with(opts), OpListTransactions, p, transactionCursor, string(merchantID))
+}
+
+// StreamLinesToMerchant fetches the full history of a given merchant's
+// balance in the background, like StreamTransactions
+func (c Client) StreamLinesToMerchant(ctx context.Context, merchantID MerchantID, p Pagination, opts ...CallOption) (<-chan *Line, <-chan error) {
+	return stream(ctx, c.with(opts), OpFetchLinesToMerchant, p, lineCursor, string(merchantID))
+}
+
+// FetchMerchantsPage fetches a page of the merchants for given app ID
+func (c Client) FetchMerchantsPage(appID AppID, r PageRequest, opts ...CallOption) (*Page[Merchant], error) {
+	return fetchPage(c.with(opts), OpFetchMerchants, r, merchantCursor, string(appID))
+}
+
+// FetchUsersToMerchantPage fetches a page of the users for a given merchant
+func (c Client) FetchUsersToMerchantPage(merchantID MerchantID, r PageRequest, opts ...CallOption) (*Page[User], error) {
+	return fetchPage(c.with(opts), OpFetchUsersToMerchant, r, userCursor, string(merchantID))
+}
+
+// FetchAppsToMerchantPage fetches a page of the apps for a given merchant
+func (c Client) FetchAppsToMerchantPage(merchantID MerchantID, r PageRequest, opts ...CallOption) (*Page[App], error) {
+	return fetchPage(c.with(opts), OpFetchAppsToMerchant, r, appCursor, string(merchantID))
+}
+
+// FetchLinesToMerchantPage fetches a page of the history of a given merchant's balance
+func (c Client) FetchLinesToMerchantPage(merchantID MerchantID, r PageRequest, opts ...CallOption) (*Page[Line], error) {
+	return fetchPage(c.with(opts), OpFetchLinesToMerchant, r, lineCursor, string(merchantID))
+}
+
+// ListTransactionsPage lists a page of the transactions under the given merchantID
+func (c Client) ListTransactionsPage(merchantID MerchantID, r PageRequest, opts ...CallOption) (*Page[Transaction], error) {
+	return fetchPage(c.with(opts), OpListTransactions, r, transactionCursor, string(merchantID))
+}
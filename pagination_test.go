@@ -0,0 +1,165 @@
+package paylike
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newPagedTestClient creates a client serving the given number of
+// transactions, newest first, paginated by the before cursor
+func newPagedTestClient(t *testing.T, total int, requests *int, opts ...Option) *Client {
+	return newPagedTestClientWithBad(t, total, 0, requests, opts...)
+}
+
+// newPagedTestClientWithBad creates a client like newPagedTestClient, the
+// transaction with the given number failing to decode
+func newPagedTestClientWithBad(t *testing.T, total, bad int, requests *int, opts ...Option) *Client {
+	return newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		next := total
+		if before := r.URL.Query().Get("before"); before != "" {
+			fmt.Sscanf(before, "tx%d", &next)
+			next--
+		}
+		body := "["
+		for i := next; i > 0 && i > next-limit; i-- {
+			if i != next {
+				body += ","
+			}
+			if i == bad {
+				body += fmt.Sprintf(`{"id":"tx%d","amount":"100"}`, i)
+				continue
+			}
+			body += fmt.Sprintf(`{"id":"tx%d"}`, i)
+		}
+		w.Write([]byte(body + "]"))
+	}), opts...)
+}
+
+func TestListAllTransactions(t *testing.T) {
+	requests := 0
+	client := newPagedTestClient(t, 5, &requests)
+	transactions, err := client.ListAllTransactions(TestMerchant, Pagination{PageSize: 2})
+	assert.Nil(t, err)
+	assert.Len(t, transactions, 5)
+	assert.Equal(t, TxID("tx1"), transactions[4].ID)
+	assert.Equal(t, 3, requests)
+
+	requests = 0
+	transactions, err = client.ListAllTransactions(TestMerchant, Pagination{PageSize: 2, MaxItems: 3})
+	assert.Equal(t, ErrPaginationLimit, err)
+	assert.Len(t, transactions, 3)
+	assert.Equal(t, 2, requests)
+}
+
+func TestEachTransaction(t *testing.T) {
+	requests := 0
+	client := newPagedTestClient(t, 5, &requests)
+	var ids []TxID
+	err := client.EachTransaction(TestMerchant, Pagination{PageSize: 2}, func(tx *Transaction) error {
+		ids = append(ids, tx.ID)
+		if tx.ID == "tx3" {
+			return ErrStopPagination
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []TxID{"tx5", "tx4", "tx3"}, ids)
+	assert.Equal(t, 2, requests)
+
+	failure := errors.New("failure")
+	err = client.EachTransaction(TestMerchant, Pagination{}, func(tx *Transaction) error {
+		return failure
+	})
+	assert.Equal(t, failure, err)
+}
+
+func TestStreamTransactions(t *testing.T) {
+	requests := 0
+	client := newPagedTestClient(t, 5, &requests)
+	transactions, errs := client.StreamTransactions(context.Background(), TestMerchant, Pagination{PageSize: 2})
+	var ids []TxID
+	for tx := range transactions {
+		ids = append(ids, tx.ID)
+	}
+	assert.Nil(t, <-errs)
+	assert.Equal(t, []TxID{"tx5", "tx4", "tx3", "tx2", "tx1"}, ids)
+	assert.Equal(t, 3, requests)
+
+	requests = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	transactions, errs = client.StreamTransactions(ctx, TestMerchant, Pagination{PageSize: 2})
+	tx := <-transactions
+	assert.Equal(t, TxID("tx5"), tx.ID)
+	cancel()
+	for range transactions {
+	}
+	assert.True(t, errors.Is(<-errs, context.Canceled))
+}
+
+func TestListTransactionsPage(t *testing.T) {
+	requests := 0
+	client := newPagedTestClient(t, 5, &requests)
+	page, err := client.ListTransactionsPage(TestMerchant, PageRequest{Limit: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, page.Count)
+	assert.True(t, page.HasMore)
+	assert.Equal(t, "tx4", page.Cursor)
+
+	page, err = client.ListTransactionsPage(TestMerchant, PageRequest{Limit: 2, Before: page.Cursor})
+	assert.Nil(t, err)
+	assert.Equal(t, TxID("tx3"), page.Items[0].ID)
+	assert.True(t, page.HasMore)
+
+	page, err = client.ListTransactionsPage(TestMerchant, PageRequest{Limit: 2, Before: page.Cursor})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, page.Count)
+	assert.False(t, page.HasMore)
+	assert.Equal(t, "", page.Cursor)
+
+	page, err = client.ListTransactionsPage(TestMerchant, PageRequest{Limit: 5})
+	assert.Nil(t, err)
+	assert.Equal(t, 5, page.Count)
+	assert.False(t, page.HasMore)
+
+	_, err = client.ListTransactionsPage(TestMerchant, PageRequest{})
+	assert.NotNil(t, err)
+	assert.Equal(t, 4, requests)
+}
+
+func TestLenientPagination(t *testing.T) {
+	requests := 0
+	var warnings []DecodeWarning
+	client := newPagedTestClientWithBad(t, 7, 4, &requests, WithLenientLists(func(w DecodeWarning) {
+		warnings = append(warnings, w)
+	}))
+
+	transactions, err := client.ListAllTransactions(TestMerchant, Pagination{PageSize: 3})
+	assert.Nil(t, err)
+	var ids []TxID
+	for _, tx := range transactions {
+		ids = append(ids, tx.ID)
+	}
+	assert.Equal(t, []TxID{"tx7", "tx6", "tx5", "tx3", "tx2", "tx1"}, ids)
+	assert.Len(t, warnings, 1)
+	assert.Equal(t, 3, requests)
+
+	page, err := client.ListTransactionsPage(TestMerchant, PageRequest{Limit: 3, Before: "tx5"})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, page.Count)
+	assert.True(t, page.HasMore)
+	assert.Equal(t, "tx2", page.Cursor)
+
+	page, err = client.ListTransactionsPage(TestMerchant, PageRequest{Limit: 2, Before: "tx6"})
+	assert.Nil(t, err)
+	assert.Equal(t, []*Transaction{{TransactionID: TransactionID{ID: "tx5"}}}, page.Items)
+	assert.True(t, page.HasMore)
+	assert.Equal(t, "tx4", page.Cursor)
+}
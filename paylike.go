@@ -1,34 +1,85 @@
 package paylike
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"runtime"
+	"strconv"
+	"time"
 )
 
+// Version is the current version of the SDK, sent in the User-Agent header
+const Version = "1.0.0"
+
 // Client describes all information regarding the API
 type Client struct {
-	Key     string
-	client  *http.Client
-	baseAPI string
+	Key                 string
+	client              *http.Client
+	baseAPI             string
+	userAgent           string
+	endpointHeader      string
+	header              http.Header
+	requestIDKey        interface{}
+	requestIDHeader     string
+	metricsHook         func(RequestMetrics)
+	rateLimiter         RateLimiter
+	timeout             time.Duration
+	lastKnownGood       *lastKnownGoodStore
+	retryPolicy         RetryPolicy
+	breaker             CircuitBreaker
+	strictDecoding      bool
+	unknownFieldsHook   func(op Operation, fields []string)
+	batchConcurrency    int
+	requestDump         bool
+	cache               Cache
+	cacheTTL            time.Duration
+	revalidateFor       time.Duration
+	slots               chan struct{}
+	failFastWhenBusy    bool
+	lifecycle           *lifecycle
+	codec               Codec
+	transportChanges    []func(*http.Transport)
+	keyProvider         KeyProvider
+	keyResolver         KeyResolver
+	clock               Clock
+	websiteCheck        bool
+	lenientLists        bool
+	decodeWarningHook   func(DecodeWarning)
+	deprecationHook     func(Deprecation)
+	apiVersion          string
+	signer              RequestSigner
+	softDeclineAttempts int
+	softDeclineSpacing  time.Duration
+	captureGuard        bool
+	auditSink           AuditSink
+	dryRun              bool
+	journal             Journal
+	authFailures        *authFailures
+	call                callOptions
 }
 
 // App describes information about the application
 type App struct {
-	ID   string
-	Name string
-	Key  string
+	rawFields
+	ID      AppID    `json:"id"`
+	Name    string   `json:"name"`
+	Key     string   `json:"key"`     // only returned when creating the app
+	Created string   `json:"created"` // creation date, if reported
+	Scopes  []string `json:"scopes"`  // scopes granted on the merchant, if reported
 }
 
 // Identity describes information about the current application that has
 // been created
 type Identity struct {
-	ID      string
-	Name    string
-	Created string
+	rawFields
+	ID      AppID  `json:"id"`
+	Name    string `json:"name"`
+	Created string `json:"created"`
 }
 
 // MerchantCreateDTO describes options for creating a merchant
@@ -46,79 +97,87 @@ type MerchantCreateDTO struct {
 // MerchantUpdateDTO describes options to update a given merchant
 // If you cannot find your desired option here, create a new merchant instead
 type MerchantUpdateDTO struct {
-	Name       string `json:"name,omitempty"`       // optional, name of merchant
-	Email      string `json:"email,omitempty"`      // optional, contact email
-	Descriptor string `json:"descriptor,omitempty"` // optional, text on client bank statements
+	Name       string       `json:"name,omitempty"`       // optional, name of merchant
+	Email      string       `json:"email,omitempty"`      // optional, contact email
+	Descriptor string       `json:"descriptor,omitempty"` // optional, text on client bank statements
+	TDS        *MerchantTDS `json:"tds,omitempty"`        // optional, 3-D Secure mode
 }
 
 // InviteUserToMerchantResponse describes the response when a user
 // is being invited to a given merchant
 type InviteUserToMerchantResponse struct {
-	IsMember bool
+	IsMember bool `json:"isMember"`
 }
 
-// PricingAmount describes the currency and the amount
+// PricingAmount describes the currency and the amount in minor units
 type PricingAmount struct {
-	Currency string
-	Amount   float64
+	Currency string `json:"currency"`
+	Amount   int64  `json:"amount"`
 }
 
 // MerchantTransfer describes a transfer to a given card
 type MerchantTransfer struct {
-	ToCard Pricing
+	ToCard Pricing `json:"toCard"`
 }
 
 // Pricing describes the exact amounts for a given item
 type Pricing struct {
-	Rate    float64
-	Flat    PricingAmount
-	Dispute PricingAmount
+	Rate    float64       `json:"rate"`
+	Flat    PricingAmount `json:"flat"`
+	Dispute PricingAmount `json:"dispute"`
 }
 
 // MerchantPricing describes a pricing included in the merchant
 type MerchantPricing struct {
 	Pricing
-	Transfer MerchantTransfer
+	Transfer MerchantTransfer `json:"transfer"`
 }
 
 // MerchantTDS either "attempt" or "full" based on 3-D secure
 type MerchantTDS struct {
-	Mode string
+	Mode string `json:"mode"`
 }
 
 // Merchant describes information about a given merchant
 type Merchant struct {
-	ID         string
-	Name       string
-	Company    MerchantCompany
-	Claim      MerchantClaim
-	Pricing    MerchantPricing
-	Currency   string
-	Email      string
-	TDS        MerchantTDS
-	Key        string
-	Bank       MerchantBank
-	Created    string
-	Test       bool
-	Descriptor string
-	Website    string
-	Balance    float64
+	rawFields
+	ID         MerchantID        `json:"id"`
+	Name       string            `json:"name"`
+	Company    MerchantCompany   `json:"company"`
+	Claim      MerchantClaim     `json:"claim"`
+	Pricing    MerchantPricing   `json:"pricing"`
+	Currency   string            `json:"currency"`
+	Email      string            `json:"email"`
+	TDS        MerchantTDS       `json:"tds"`
+	Key        string            `json:"key"`
+	Bank       MerchantBank      `json:"bank"`
+	Created    string            `json:"created"`
+	Test       bool              `json:"test"`
+	Descriptor string            `json:"descriptor"`
+	Website    string            `json:"website"`
+	Balance    int64             `json:"balance"`
+	Warnings   []MerchantWarning `json:"-"` // differences with the request the merchant has been created from, see CreateMerchant
 }
 
 // MerchantClaim describes claims for a given merchant
 type MerchantClaim struct {
-	CanChargeCard     bool
-	CanSaveCard       bool
-	CanTransferToCard bool
-	CanCapture        bool
-	CanRefund         bool
-	CanVoid           bool
+	CanChargeCard     bool `json:"canChargeCard"`
+	CanSaveCard       bool `json:"canSaveCard"`
+	CanTransferToCard bool `json:"canTransferToCard"`
+	CanCapture        bool `json:"canCapture"`
+	CanRefund         bool `json:"canRefund"`
+	CanVoid           bool `json:"canVoid"`
 }
 
 // User describes a user in the system
 type User struct {
-	ID    string `json:"id"`
-	Email string `json:"email"`
+	rawFields
+	ID      UserID `json:"id"`
+	Email   string `json:"email"`
+	Name    string `json:"name"`
+	Created string `json:"created"`
+	Role    string `json:"role"`    // role of the user on the merchant, if reported
+	Pending bool   `json:"pending"` // whether the user has not accepted the invitation yet
 }
 
 // MerchantCompany describes the company of a given merchant
@@ -134,12 +193,13 @@ type MerchantBank struct {
 
 // Line desccribes a given item in the history of the merchant balance
 type Line struct {
+	rawFields
 	ID            string        `json:"id"`
 	Created       string        `json:"created"`
-	MerchantID    string        `json:"merchantId"`
-	Balance       int           `json:"balance"`
-	Fee           int           `json:"fee"`
-	TransactionID string        `json:"transactionId"`
+	MerchantID    MerchantID    `json:"merchantId"`
+	Balance       int64         `json:"balance"`
+	Fee           int64         `json:"fee"`
+	TransactionID TxID          `json:"transactionId"`
 	Amount        PricingAmount `json:"amount"`
 	Refund        bool          `json:"refund"`
 	Test          bool          `json:"test"`
@@ -148,23 +208,25 @@ type Line struct {
 // TransactionDTO describes options in terms of the transaction
 // creation API
 type TransactionDTO struct {
-	CardID        string                 `json:"cardId,omitempty"`        // required if no TransactionID is present
-	TransactionID string                 `json:"transactionId,omitempty"` // required if no CardID is present
-	Descriptor    string                 `json:"descriptor,omitempty"`    // optional, will fallback to merchant descriptor
-	Currency      string                 `json:"currency"`                // required, three letter ISO
-	Amount        int                    `json:"amount"`                  // required, amount in minor units
-	Custom        map[string]interface{} `json:"custom,omitempty"`        // optional, any custom data
-
+	CardID           CardToken              `json:"cardId,omitempty"`           // required if no TransactionID is present
+	TransactionID    TxID                   `json:"transactionId,omitempty"`    // required if no CardID is present
+	Descriptor       string                 `json:"descriptor,omitempty"`       // optional, will fallback to merchant descriptor
+	Currency         string                 `json:"currency"`                   // required, three letter ISO
+	Amount           int64                  `json:"amount"`                     // required, amount in minor units
+	Custom           map[string]interface{} `json:"custom,omitempty"`           // optional, any custom data
+	TDS              *TransactionTDS        `json:"tds,omitempty"`              // optional, result of a 3-D Secure authentication
+	Recurring        bool                   `json:"recurring,omitempty"`        // optional, marks a follow-up charge of a subscription
+	StoredCredential *StoredCredential      `json:"storedCredential,omitempty"` // optional, who initiates the charge of a stored card and why
 }
 
 // TransactionID describes the ID for a given unique transaction used for referencing
 type TransactionID struct {
-	ID string `json:"id"`
+	ID TxID `json:"id"`
 }
 
 // TransactionTrailDTO describes information about the the capturing / refunding / voiding amount
 type TransactionTrailDTO struct {
-	Amount     int    `json:"amount"`               // required, amount in minor units (100 = DKK 1,00)
+	Amount     int64  `json:"amount"`               // required, amount in minor units (100 = DKK 1,00)
 	Currency   string `json:"currency,omitempty"`   // optional, expected currency (for additional verification)
 	Descriptor string `json:"descriptor,omitempty"` // optional, text on client bank statement
 }
@@ -176,25 +238,26 @@ type CardCode struct {
 
 // TransactionCard describes card information that can be found in transactions
 type TransactionCard struct {
-	Bin    string   `json:"bin"`
-	Last4  string   `json:"last4"`
-	Expiry string   `json:"expiry"`
-	Scheme string   `json:"scheme"`
-	Code   CardCode `json:"code"`
+	Bin    string     `json:"bin"`
+	Last4  string     `json:"last4"`
+	Expiry string     `json:"expiry"`
+	Scheme CardScheme `json:"scheme"`
+	Code   CardCode   `json:"code"`
 }
 
 // Transaction describes information about a given transaction
 type Transaction struct {
+	rawFields
 	TransactionID
 	Test           bool                   `json:"test"`
-	MerchantID     string                 `json:"merchantId"`
+	MerchantID     MerchantID             `json:"merchantId"`
 	Created        string                 `json:"created"`
-	Amount         int                    `json:"amount"`
-	RefundedAmount int                    `json:"refundedAmount"`
-	CapturedAmount int                    `json:"capturedAmount"`
-	VoidedAmount   int                    `json:"voidedAmount"`
-	PendingAmount  int                    `json:"pendingAmount"`
-	DisputedAmount int                    `json:"disputedAmount"`
+	Amount         int64                  `json:"amount"`
+	RefundedAmount int64                  `json:"refundedAmount"`
+	CapturedAmount int64                  `json:"capturedAmount"`
+	VoidedAmount   int64                  `json:"voidedAmount"`
+	PendingAmount  int64                  `json:"pendingAmount"`
+	DisputedAmount int64                  `json:"disputedAmount"`
 	Card           TransactionCard        `json:"card"`
 	TDS            string                 `json:"tds"`
 	Currency       string                 `json:"currency"`
@@ -202,23 +265,25 @@ type Transaction struct {
 	Recurring      bool                   `json:"recurring"`
 	Successful     bool                   `json:"successful"`
 	Error          bool                   `json:"error"`
+	ErrorCode      ErrorCode              `json:"errorCode"`    // decline or error code of a failed transaction, if reported
+	ErrorMessage   string                 `json:"errorMessage"` // human readable reason of the failure, if reported
 	Descriptor     string                 `json:"descriptor"`
 	Trail          []*TransactionTrail    `json:"trail"`
 }
 
 // TransactionTrailFee describes fee included in the given trail
 type TransactionTrailFee struct {
-	Flat int `json:"flat"`
-	Rate int `json:"rate"`
+	Flat int64 `json:"flat"`
+	Rate int64 `json:"rate"`
 }
 
 // TransactionTrail describes a given trail element in the transactions
 type TransactionTrail struct {
 	Fee        TransactionTrailFee `json:"fee"`
-	Amount     int                 `json:"amount"`
-	Balance    int                 `json:"balance"`
+	Amount     int64               `json:"amount"`
+	Balance    int64               `json:"balance"`
 	Created    string              `json:"created"`
-	Capture    bool                `json:"captrue"`
+	Capture    bool                `json:"capture"`
 	Descriptor string              `json:"descriptor"`
 	LineID     string              `json:"lineId"`
 	Dispute    TrailDispute        `json:"dispute"`
@@ -233,26 +298,56 @@ type TrailDispute struct {
 
 // Card describes the full information about a given card
 type Card struct {
+	rawFields
 	TransactionCard
 	CardID
-	MerchantID string `json:"merchantId"`
-	Created    string `json:"created"`
+	MerchantID MerchantID             `json:"merchantId"`
+	Created    string                 `json:"created"`
+	Notes      string                 `json:"notes"`
+	Custom     map[string]interface{} `json:"custom"`
 }
 
 // CardDTO describes required information to create a new card
 type CardDTO struct {
-	TransactionID string `json:"transactionId"`
-	Notes         string `json:"notes"`
+	TransactionID TxID                   `json:"transactionId"`
+	Notes         string                 `json:"notes"`
+	Custom        map[string]interface{} `json:"custom,omitempty"` // optional, any custom data, e.g. a customer reference
+}
+
+// CardUpdateDTO describes the fields of a card that can be updated
+type CardUpdateDTO struct {
+	Notes  *string                `json:"notes,omitempty"`  // optional, an empty string clears the notes
+	Custom map[string]interface{} `json:"custom,omitempty"` // optional, replaces the custom data
 }
 
 // CardID describes a given card's ID
 type CardID struct {
-	ID string `json:"id"`
+	ID CardToken `json:"id"`
+}
+
+// NewClient creates a new client configured with the given options
+func NewClient(key string, opts ...Option) *Client {
+	c := &Client{
+		Key:       key,
+		client:    &http.Client{},
+		baseAPI:   "https://api.paylike.io",
+		userAgent: defaultUserAgent(),
+		timeout:   DefaultTimeout,
+		lifecycle: newLifecycle(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if cache, ok := c.cache.(*MemoryCache); ok && c.clock != nil {
+		cache.adoptClock(c.clock)
+	}
+	c.applyTransportChanges()
+	return c
 }
 
-// NewClient creates a new client
-func NewClient(key string) *Client {
-	return &Client{key, &http.Client{}, "https://api.paylike.io"}
+// defaultUserAgent builds the User-Agent identifying the SDK and the Go version
+func defaultUserAgent() string {
+	return fmt.Sprintf("paylike-go/%s (%s)", Version, runtime.Version())
 }
 
 // SetKey provides an elegent way to deal with
@@ -262,166 +357,284 @@ func (c *Client) SetKey(key string) *Client {
 	return c
 }
 
+// SetAppIdentifier appends the given application identifier (e.g. "myshop/2.1")
+// to the User-Agent header so requests can be attributed to your application
+func (c *Client) SetAppIdentifier(id string) *Client {
+	c.userAgent = defaultUserAgent()
+	if id != "" {
+		c.userAgent = fmt.Sprintf("%s %s", c.userAgent, id)
+	}
+	return c
+}
+
 // CreateApp creates a new application
 // https://github.com/paylike/api-docs#create-an-app
-func (c Client) CreateApp() (*App, error) {
-	return c.createApp(nil)
+func (c Client) CreateApp(opts ...CallOption) (*App, error) {
+	return getWrapped[App](c.with(opts), OpCreateApp, nil, "app")
 }
 
 // CreateAppWithName creates a new application with the given name
 // https://github.com/paylike/api-docs#create-an-app
-func (c Client) CreateAppWithName(name string) (*App, error) {
-	return c.createApp(
-		bytes.NewBuffer([]byte(fmt.Sprintf(`{"name":"%s"}`, name))),
-	)
+func (c Client) CreateAppWithName(name string, opts ...CallOption) (*App, error) {
+	return getWrapped[App](c.with(opts), OpCreateApp, map[string]string{"name": name}, "app")
 }
 
 // FetchApp is to fetch information about the current application
 // https://api.paylike.io/me
-func (c Client) FetchApp() (*Identity, error) {
-	return c.fetchApp()
+func (c Client) FetchApp(opts ...CallOption) (*Identity, error) {
+	return getWrapped[Identity](c.with(opts), OpFetchApp, nil, "identity")
 }
 
 // CreateMerchant creates a new merchant under a given app
 // https://github.com/paylike/api-docs#create-a-merchant
-func (c Client) CreateMerchant(dto MerchantCreateDTO) (*Merchant, error) {
-	b, err := json.Marshal(dto)
+// The email, if any, is normalized (see NormalizeEmail), the descriptor, if
+// any, validated (see ValidateDescriptor) and the website, if any, validated
+// (see ValidateWebsite and WithWebsiteCheck) before sending
+// The currency, descriptor and company echoed by the API are checked against
+// the request, differences (e.g. normalized values) are listed in Warnings
+func (c Client) CreateMerchant(dto MerchantCreateDTO, opts ...CallOption) (*Merchant, error) {
+	c = c.with(opts)
+	if dto.Email != "" {
+		email, err := NormalizeEmail(dto.Email)
+		if err != nil {
+			return nil, err
+		}
+		dto.Email = email
+	}
+	if dto.Descriptor != "" {
+		if err := ValidateDescriptor(dto.Descriptor); err != nil {
+			return nil, err
+		}
+	}
+	if dto.Website != "" {
+		if err := ValidateWebsite(dto.Website); err != nil {
+			return nil, err
+		}
+	}
+	if c.websiteCheck {
+		ctx := c.call.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if err := CheckWebsite(ctx, nil, dto.Website); err != nil {
+			return nil, err
+		}
+	}
+	merchant, err := getWrapped[Merchant](c, OpCreateMerchant, dto, "merchant")
 	if err != nil {
 		return nil, err
 	}
-	return c.createMerchant(bytes.NewBuffer(b))
+	merchant.Warnings = merchantWarnings(dto, merchant)
+	return merchant, nil
 }
 
 // GetMerchant gets a merchant based on it's ID
 // https://github.com/paylike/api-docs#fetch-a-merchant
-func (c Client) GetMerchant(id string) (*Merchant, error) {
-	return c.getMerchant(id)
+func (c Client) GetMerchant(id MerchantID, opts ...CallOption) (*Merchant, error) {
+	c = c.with(opts)
+	merchant, err := getWrapped[Merchant](c, OpGetMerchant, nil, "merchant", string(id))
+	return withLastKnownGood(c, c.callContext(OpGetMerchant, string(id)), "merchants/"+string(id), merchant, err)
 }
 
 // FetchMerchants fetches all merchants for given app ID
 // https://github.com/paylike/api-docs#fetch-all-merchants
-func (c Client) FetchMerchants(appID string, limit int) ([]*Merchant, error) {
-	return c.fetchMerchants(appID, limit)
+func (c Client) FetchMerchants(appID AppID, limit int, opts ...CallOption) ([]*Merchant, error) {
+	return list[Merchant](c.with(opts), OpFetchMerchants, limit, string(appID))
 }
 
 // UpdateMerchant updates a merchant with given parameters
 // https://github.com/paylike/api-docs#update-a-merchant
-func (c Client) UpdateMerchant(id string, dto MerchantUpdateDTO) error {
-	b, err := json.Marshal(dto)
-	if err != nil {
+// The email, if any, is normalized (see NormalizeEmail) before sending
+func (c Client) UpdateMerchant(id MerchantID, dto MerchantUpdateDTO, opts ...CallOption) error {
+	if err := dto.normalize(); err != nil {
 		return err
 	}
-	return c.updateMerchant(id, bytes.NewBuffer(b))
+	return c.with(opts).execute(OpUpdateMerchant, dto, nil, string(id))
+}
+
+// UpdateMerchantAndFetch updates a merchant with given parameters and returns
+// the updated merchant, as returned by the API or fetched right after otherwise
+// https://github.com/paylike/api-docs#update-a-merchant
+func (c Client) UpdateMerchantAndFetch(id MerchantID, dto MerchantUpdateDTO, opts ...CallOption) (*Merchant, error) {
+	if err := dto.normalize(); err != nil {
+		return nil, err
+	}
+	merchant, err := getWrapped[Merchant](c.with(opts), OpUpdateMerchant, dto, "merchant", string(id))
+	if err != nil || merchant != nil {
+		return merchant, err
+	}
+	return c.GetMerchant(id, opts...)
+}
+
+// UpdateMerchantTDS switches the 3-D Secure mode of a merchant to either
+// TDSModeAttempt or TDSModeFull
+// https://github.com/paylike/api-docs#update-a-merchant
+func (c Client) UpdateMerchantTDS(id MerchantID, mode string, opts ...CallOption) error {
+	if mode != TDSModeAttempt && mode != TDSModeFull {
+		return fmt.Errorf("paylike: invalid 3-D Secure mode %q", mode)
+	}
+	return c.UpdateMerchant(id, MerchantUpdateDTO{TDS: &MerchantTDS{Mode: mode}}, opts...)
 }
 
 // InviteUserToMerchant invites given user to use the given merchant account
 // https://github.com/paylike/api-docs#invite-user-to-a-merchant
-func (c Client) InviteUserToMerchant(merchantID string, email string) (*InviteUserToMerchantResponse, error) {
-	return c.inviteUserToMerchant(merchantID, email)
+// The email is normalized (see NormalizeEmail) before sending
+func (c Client) InviteUserToMerchant(merchantID MerchantID, email string, opts ...CallOption) (*InviteUserToMerchantResponse, error) {
+	email, err := NormalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	var response InviteUserToMerchantResponse
+	err = c.with(opts).execute(OpInviteUserToMerchant, map[string]string{"email": email}, &response, string(merchantID))
+	return &response, err
+}
+
+// ResendInvite repeats the invitation of the user with the given email to
+// the given merchant, e.g. for a user whose invitation is still pending
+// https://github.com/paylike/api-docs#invite-user-to-a-merchant
+func (c Client) ResendInvite(merchantID MerchantID, email string, opts ...CallOption) error {
+	_, err := c.InviteUserToMerchant(merchantID, email, opts...)
+	return err
 }
 
 // FetchUsersToMerchant fetches users for a given merchant
 // https://github.com/paylike/api-docs#fetch-all-users-on-a-merchant
-func (c Client) FetchUsersToMerchant(merchantID string, limit int) ([]*User, error) {
-	return c.fetchUsersToMerchant(merchantID, limit)
+func (c Client) FetchUsersToMerchant(merchantID MerchantID, limit int, opts ...CallOption) ([]*User, error) {
+	return list[User](c.with(opts), OpFetchUsersToMerchant, limit, string(merchantID))
 }
 
 // RevokeUserFromMerchant revokes a given user from a given merchant
 // https://github.com/paylike/api-docs#revoke-user-from-a-merchant
-func (c Client) RevokeUserFromMerchant(merchantID string, userID string) error {
-	return c.revokeUserFromMerchant(merchantID, userID)
+func (c Client) RevokeUserFromMerchant(merchantID MerchantID, userID UserID, opts ...CallOption) error {
+	return c.with(opts).execute(OpRevokeUserFromMerchant, nil, nil, string(merchantID), string(userID))
 }
 
 // AddAppToMerchant revokes a given user from a given merchant
 // https://github.com/paylike/api-docs#add-app-to-a-merchant
-func (c Client) AddAppToMerchant(merchantID string, appID string) error {
-	return c.addAppToMerchant(merchantID, appID)
+func (c Client) AddAppToMerchant(merchantID MerchantID, appID AppID, opts ...CallOption) error {
+	return c.with(opts).execute(OpAddAppToMerchant, map[string]AppID{"appId": appID}, nil, string(merchantID))
 }
 
 // FetchAppsToMerchant fetches apps for a given merchant
 // https://github.com/paylike/api-docs#fetch-all-apps-on-a-merchant
-func (c Client) FetchAppsToMerchant(merchantID string, limit int) ([]*App, error) {
-	return c.fetchAppsToMerchant(merchantID, limit)
+func (c Client) FetchAppsToMerchant(merchantID MerchantID, limit int, opts ...CallOption) ([]*App, error) {
+	return list[App](c.with(opts), OpFetchAppsToMerchant, limit, string(merchantID))
+}
+
+// HasApp reports whether the app the client authenticates as has been
+// added to the given merchant, looking through all apps of the merchant
+func (c Client) HasApp(merchantID MerchantID, opts ...CallOption) (bool, error) {
+	identity, err := c.FetchApp(opts...)
+	if err != nil {
+		return false, err
+	}
+	if identity == nil {
+		return false, errors.New("paylike: no identity in response")
+	}
+	found := false
+	err = c.EachAppToMerchant(merchantID, Pagination{}, func(app *App) error {
+		if app.ID == identity.ID {
+			found = true
+			return ErrStopPagination
+		}
+		return nil
+	}, opts...)
+	return found, err
 }
 
 // RevokeAppFromMerchant revokes a given app from a given merchant
 // https://github.com/paylike/api-docs#revoke-app-from-a-merchant
-func (c Client) RevokeAppFromMerchant(merchantID string, appID string) error {
-	return c.revokeAppFromMerchant(merchantID, appID)
+func (c Client) RevokeAppFromMerchant(merchantID MerchantID, appID AppID, opts ...CallOption) error {
+	return c.with(opts).execute(OpRevokeAppFromMerchant, nil, nil, string(merchantID), string(appID))
 }
 
 // FetchLinesToMerchant fetches the history that makes up a given merchant's balance
 // https://github.com/paylike/api-docs#merchants-lines
-func (c Client) FetchLinesToMerchant(merchantID string, limit int) ([]*Line, error) {
-	return c.fetchLinesToMerchant(merchantID, limit)
+func (c Client) FetchLinesToMerchant(merchantID MerchantID, limit int, opts ...CallOption) ([]*Line, error) {
+	return list[Line](c.with(opts), OpFetchLinesToMerchant, limit, string(merchantID))
 }
 
 // CreateTransaction creates a new transaction based on previous transaction informations
 // https://github.com/paylike/api-docs#using-a-previous-transaction
-func (c Client) CreateTransaction(merchantID string, dto TransactionDTO) (*TransactionID, error) {
-	b, err := json.Marshal(dto)
-	if err != nil {
+func (c Client) CreateTransaction(merchantID MerchantID, dto TransactionDTO, opts ...CallOption) (*TransactionID, error) {
+	if err := dto.Validate(); err != nil {
 		return nil, err
 	}
-	return c.createTransaction(merchantID, bytes.NewBuffer(b))
+	return getWrapped[TransactionID](c.with(opts), OpCreateTransaction, dto, "transaction", string(merchantID))
 }
 
 // ListTransactions lists all transactions available under the given merchantID
 // https://github.com/paylike/api-docs#fetch-all-transactions
-func (c Client) ListTransactions(merchantID string, limit int) ([]*Transaction, error) {
-	return c.listTransactions(merchantID, limit)
+func (c Client) ListTransactions(merchantID MerchantID, limit int, opts ...CallOption) ([]*Transaction, error) {
+	return list[Transaction](c.with(opts), OpListTransactions, limit, string(merchantID))
 }
 
 // CaptureTransaction captures a new amount for the given transaction
+// Soft declines are retried if enabled with WithSoftDeclineRetry, voided or
+// disputed transactions refused if enabled with WithCaptureGuard
+// Captures exceeding the amount left to capture fail with an
+// AmountExceededError, matching ErrAmountExceeded
 // https://github.com/paylike/api-docs#capture-a-transaction
-func (c Client) CaptureTransaction(transactionID string, dto TransactionTrailDTO) (*Transaction, error) {
-	b, err := json.Marshal(dto)
-	if err != nil {
+func (c Client) CaptureTransaction(transactionID TxID, dto TransactionTrailDTO, opts ...CallOption) (*Transaction, error) {
+	if err := dto.Validate(); err != nil {
 		return nil, err
 	}
-	return c.captureTransaction(transactionID, bytes.NewBuffer(b))
+	c = c.with(opts)
+	if c.captureGuard {
+		if err := c.guardCapture(transactionID); err != nil {
+			return nil, err
+		}
+	}
+	transaction, err := retrySoftDeclines(c, func() (*Transaction, error) {
+		return getWrapped[Transaction](c, OpCaptureTransaction, dto, "transaction", string(transactionID))
+	})
+	if err != nil {
+		return nil, c.explainCaptureError(transactionID, dto.Amount, err)
+	}
+	return transaction, nil
 }
 
 // RefundTransaction refunds a given amount for the given transaction
 // https://github.com/paylike/api-docs#refund-a-transaction
-func (c Client) RefundTransaction(transactionID string, dto TransactionTrailDTO) (*Transaction, error) {
-	b, err := json.Marshal(dto)
-	if err != nil {
+func (c Client) RefundTransaction(transactionID TxID, dto TransactionTrailDTO, opts ...CallOption) (*Transaction, error) {
+	if err := dto.Validate(); err != nil {
 		return nil, err
 	}
-	return c.refundTransaction(transactionID, bytes.NewBuffer(b))
+	return getWrapped[Transaction](c.with(opts), OpRefundTransaction, dto, "transaction", string(transactionID))
 }
 
 // VoidTransaction cancels a given amount completely or partially
 // https://github.com/paylike/api-docs#void-a-transaction
-func (c Client) VoidTransaction(transactionID string, dto TransactionTrailDTO) (*Transaction, error) {
-	b, err := json.Marshal(dto)
-	if err != nil {
+func (c Client) VoidTransaction(transactionID TxID, dto TransactionTrailDTO, opts ...CallOption) (*Transaction, error) {
+	if err := dto.Validate(); err != nil {
 		return nil, err
 	}
-	return c.voidTransaction(transactionID, bytes.NewBuffer(b))
+	return getWrapped[Transaction](c.with(opts), OpVoidTransaction, dto, "transaction", string(transactionID))
 }
 
 // FindTransaction finds the given transaction by ID
 // https://github.com/paylike/api-docs#fetch-a-transaction
-func (c Client) FindTransaction(transactionID string) (*Transaction, error) {
-	return c.findTransaction(transactionID)
+func (c Client) FindTransaction(transactionID TxID, opts ...CallOption) (*Transaction, error) {
+	c = c.with(opts)
+	transaction, err := getWrapped[Transaction](c, OpFindTransaction, nil, "transaction", string(transactionID))
+	return withLastKnownGood(c, c.callContext(OpFindTransaction, string(transactionID)), "transactions/"+string(transactionID), transaction, err)
 }
 
 // FetchCard finds the given card by ID
 // https://github.com/paylike/api-docs#fetch-a-card
-func (c Client) FetchCard(cardID string) (*Card, error) {
-	return c.fetchCard(cardID)
+func (c Client) FetchCard(cardID CardToken, opts ...CallOption) (*Card, error) {
+	return getWrapped[Card](c.with(opts), OpFetchCard, nil, "card", string(cardID))
 }
 
 // CreateCard saves a new record for a given card
 // https://github.com/paylike/api-docs#save-a-card
-func (c Client) CreateCard(merchantID string, dto CardDTO) (*CardID, error) {
-	b, err := json.Marshal(dto)
-	if err != nil {
-		return nil, err
-	}
-	return c.createCard(merchantID, bytes.NewBuffer(b))
+func (c Client) CreateCard(merchantID MerchantID, dto CardDTO, opts ...CallOption) (*CardID, error) {
+	return getWrapped[CardID](c.with(opts), OpCreateCard, dto, "card", string(merchantID))
+}
+
+// UpdateCard updates the notes or custom data of the given card
+func (c Client) UpdateCard(cardID CardToken, dto CardUpdateDTO, opts ...CallOption) error {
+	return c.with(opts).execute(OpUpdateCard, dto, nil, string(cardID))
 }
 
 // getURL is to build the base API url along with the given dynamic route path
@@ -429,275 +642,205 @@ func (c Client) getURL(url string) string {
 	return fmt.Sprintf("%s%s", c.baseAPI, url)
 }
 
-// createApp handles the underlying logic of executing the API requests
-// towards the app creation API
-func (c Client) createApp(body io.Reader) (*App, error) {
-	req, err := http.NewRequest("POST", c.getURL("/apps"), body)
-	if err != nil {
-		return nil, err
+// newRequest creates a new request performing the given operation, filling
+// the path template with the given params
+func (c Client) newRequest(op Operation, body io.Reader, params ...string) (*http.Request, error) {
+	ctx := c.callContext(op, params...)
+	if url, ok := BaseURLFromContext(ctx); ok {
+		c.baseAPI = url
 	}
-	var marshalled map[string]*App
-	err = c.executeRequestAndMarshal(req, &marshalled)
-	return marshalled["app"], err
+	return http.NewRequestWithContext(context.WithValue(ctx, operationKey{}, op), op.Method, c.getURL(op.expand(params...)), body)
 }
 
-// fetchApp handles the underlying logic of executing the API requests
-// towards the app API to get the currently used app
-func (c Client) fetchApp() (*Identity, error) {
-	req, err := http.NewRequest("GET", c.getURL("/me"), nil)
-	if err != nil {
-		return nil, err
+// callContext returns the context of a call performing the given operation,
+// carrying the merchant in the path of the call, if any
+func (c Client) callContext(op Operation, params ...string) context.Context {
+	ctx := c.call.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if merchantID := op.param("merchantId", params...); merchantID != "" {
+		ctx = ContextWithMerchant(ctx, MerchantID(merchantID))
 	}
-	var marshalled map[string]*Identity
-	err = c.executeRequestAndMarshal(req, &marshalled)
-	return marshalled["identity"], err
+	return ctx
 }
 
-// createMerchant handles the underlying logic of executing the API requests
-// towards the merchant creation API
-func (c Client) createMerchant(body io.Reader) (*Merchant, error) {
-	req, err := http.NewRequest("POST", c.getURL("/merchants"), body)
-	if err != nil {
+// getWrapped handles the underlying logic of executing the API requests
+// towards endpoints responding with a single value wrapped under the given key
+func getWrapped[T any](c Client, op Operation, body interface{}, key string, params ...string) (*T, error) {
+	var wrapped map[string]*T
+	if err := c.execute(op, body, &wrapped, params...); err != nil {
 		return nil, err
 	}
-	var marshalled map[string]*Merchant
-	err = c.executeRequestAndMarshal(req, &marshalled)
-	return marshalled["merchant"], err
+	return wrapped[key], nil
 }
 
-// fetchMerchants handles the underlying logic of executing the API requests
-// towards the merchant fetching API
-func (c Client) fetchMerchants(appID string, limit int) ([]*Merchant, error) {
-	path := fmt.Sprintf("/identities/%s/merchants?limit=%d", appID, limit)
-	req, err := http.NewRequest("GET", c.getURL(path), nil)
-	if err != nil {
-		return nil, err
-	}
-	var marshalled []*Merchant
-	return marshalled, c.executeRequestAndMarshal(req, &marshalled)
+// list handles the underlying logic of executing the API requests
+// towards endpoints listing values
+func list[T any](c Client, op Operation, limit int, params ...string) ([]*T, error) {
+	return listQuery[T](c, op, url.Values{"limit": {strconv.Itoa(limit)}}, params...)
 }
 
-// getMerchant handles the underlying logic of executing the API requests
-// towards the merchant API and gets a merchant based on it's ID
-func (c Client) getMerchant(id string) (*Merchant, error) {
-	path := fmt.Sprintf("/merchants/%s", id)
-	req, err := http.NewRequest("GET", c.getURL(path), nil)
+// listQuery fetches a page of the list endpoint performing the given
+// operation using the given query parameters
+func listQuery[T any](c Client, op Operation, query url.Values, params ...string) ([]*T, error) {
+	req, err := c.newRequest(op, nil, params...)
 	if err != nil {
 		return nil, err
 	}
-	var marshalled map[string]*Merchant
-	err = c.executeRequestAndMarshal(req, &marshalled)
-	return marshalled["merchant"], err
-}
-
-// updateMerchant handles the underlying logic of executing the API requests
-// towards the merchant API and updates a given merchant
-func (c Client) updateMerchant(id string, body io.Reader) error {
-	path := fmt.Sprintf("/merchants/%s", id)
-	req, err := http.NewRequest("PUT", c.getURL(path), body)
-	if err != nil {
-		return nil
+	req.URL.RawQuery = query.Encode()
+	var values []*T
+	if err := c.executeRequestAndMarshal(req, &values); err != nil {
+		return nil, err
 	}
-	return c.executeRequestAndMarshal(req, nil)
+	return values, nil
 }
 
-// inviteUserToMerchant handles the underlying logic of executing the API requests
-// towards the merchant API and invites a given user in the system
-// to use the given merchant
-func (c Client) inviteUserToMerchant(id string, email string) (*InviteUserToMerchantResponse, error) {
-	data := []byte(fmt.Sprintf(`{"email":"%s"}`, email))
-	path := fmt.Sprintf("/merchants/%s/users", id)
-	req, err := http.NewRequest("POST", c.getURL(path), bytes.NewBuffer(data))
-	if err != nil {
-		return nil, nil
+// execute handles the underlying logic of executing the API requests performing
+// the given operation, sending the given body as JSON (if any) and decoding
+// the response into the given value (if any)
+func (c Client) execute(op Operation, body interface{}, value interface{}, params ...string) error {
+	if c.dryRun && dryRunOperations[op.Name] {
+		return c.simulate(op, body, value, params...)
 	}
-	var marshalled InviteUserToMerchantResponse
-	err = c.executeRequestAndMarshal(req, &marshalled)
-	return &marshalled, err
-}
-
-// fetchUsersToMerchant handles the underlying logic of executing the API requests
-// towards the merchant API and lists all users that are related for the given merchant
-func (c Client) fetchUsersToMerchant(id string, limit int) ([]*User, error) {
-	path := fmt.Sprintf("/merchants/%s/users?limit=%d", id, limit)
-	req, err := http.NewRequest("GET", c.getURL(path), nil)
+	req, err := c.newRequest(op, nil, params...)
 	if err != nil {
-		return nil, nil
+		return err
 	}
-	var marshalled []*User
-	return marshalled, c.executeRequestAndMarshal(req, &marshalled)
+	if body != nil {
+		release, err := c.setBody(req, body)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+	return c.executeRequestAndMarshal(req, value)
 }
 
-// revokeUserFromMerchant handles the underlying logic of executing the API requests
-// towards the merchant API and revokes a given user from a given merchant
-func (c Client) revokeUserFromMerchant(merchantID string, userID string) error {
-	path := fmt.Sprintf("/merchants/%s/users/%s", merchantID, userID)
-	req, err := http.NewRequest("DELETE", c.getURL(path), nil)
+// executeRequestAndMarshal sets the correct headers, then executes the request and tries to decode
+// the response directly from the body into the given interface{} value
+// Failed attempts are retried according to the retry policy of the client
+func (c Client) executeRequestAndMarshal(req *http.Request, value interface{}) (err error) {
+	done, err := c.track()
 	if err != nil {
 		return err
 	}
-	return c.executeRequestAndMarshal(req, nil)
-}
-
-// addAppToMerchant handles the underlying logic of executing the API requests
-// towards the merchant API and adds the given app to the given merchant
-func (c Client) addAppToMerchant(merchantID string, appID string) error {
-	data := []byte(fmt.Sprintf(`{"appId":"%s"}`, appID))
-	path := fmt.Sprintf("/merchants/%s/apps", merchantID)
-	req, err := http.NewRequest("POST", c.getURL(path), bytes.NewBuffer(data))
-	if err != nil {
+	defer done()
+	if c.Key, err = c.resolveKey(req.Context()); err != nil {
 		return err
 	}
-	return c.executeRequestAndMarshal(req, nil)
-}
-
-// fetchAppsToMerchant handles the underlying logic of executing the API requests
-// towards the merchant API and lists all apps related to the merchant
-func (c Client) fetchAppsToMerchant(merchantID string, limit int) ([]*App, error) {
-	path := fmt.Sprintf("/merchants/%s/apps?limit=%d", merchantID, limit)
-	req, err := http.NewRequest("GET", c.getURL(path), nil)
-	if err != nil {
-		return nil, err
+	op, _ := OperationFromContext(req.Context())
+	req.SetBasicAuth("", c.Key)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.endpointHeader != "" {
+		req.Header.Set(c.endpointHeader, op.Endpoint())
 	}
-	var marshalled []*App
-	return marshalled, c.executeRequestAndMarshal(req, &marshalled)
-}
-
-// revokeAppFromMerchant handles the underlying logic of executing the API requests
-// towards the merchant API and revokes a given app from a given merchant
-func (c Client) revokeAppFromMerchant(merchantID string, appID string) error {
-	path := fmt.Sprintf("/merchants/%s/apps/%s", merchantID, appID)
-	req, err := http.NewRequest("DELETE", c.getURL(path), nil)
-	if err != nil {
+	if id := c.requestID(req.Context()); id != "" {
+		req.Header.Set(c.requestIDHeader, id)
+	}
+	if c.apiVersion != "" {
+		req.Header.Set(APIVersionHeader, c.apiVersion)
+	}
+	for _, header := range []http.Header{c.header, c.call.header} {
+		for name, values := range header {
+			req.Header[name] = append([]string(nil), values...)
+		}
+	}
+	if c.requestDump {
+		defer func() {
+			if err != nil {
+				err = &RequestError{Err: err, Request: dumpRequest(req)}
+			}
+		}()
+	}
+	var statusCode, attempts int
+	if record := c.newAuditRecord(req, op); record != nil {
+		defer func() {
+			c.audit(req.Context(), record, statusCode, attempts, err)
+		}()
+	}
+	if hit, err := c.fromCache(req, op, value); hit {
 		return err
 	}
-	return c.executeRequestAndMarshal(req, nil)
-}
-
-// fetchLinesToMerchant handles the underlying logic of executing the API requests
-// towards the merchant API and fetches all lines related to a merchant's history
-func (c Client) fetchLinesToMerchant(merchantID string, limit int) ([]*Line, error) {
-	path := fmt.Sprintf("/merchants/%s/lines?limit=%d", merchantID, limit)
-	req, err := http.NewRequest("GET", c.getURL(path), nil)
-	if err != nil {
-		return nil, err
+	ctx := req.Context()
+	if timeout := c.requestTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
-	var marshalled []*Line
-	return marshalled, c.executeRequestAndMarshal(req, &marshalled)
-}
-
-// createTransaction handles the underlying logic of executing the API requests
-// towards the merchant API and creates a new transaction
-func (c Client) createTransaction(merchantID string, body io.Reader) (*TransactionID, error) {
-	path := fmt.Sprintf("/merchants/%s/transactions", merchantID)
-	req, err := http.NewRequest("POST", c.getURL(path), body)
-	if err != nil {
-		return nil, err
+	req = req.WithContext(ctx)
+	for attempt := 1; ; attempt++ {
+		resp, err := c.executeAttempt(req, op, attempt, value)
+		attempts = attempt
+		if resp != nil {
+			statusCode = resp.StatusCode
+		}
+		if err == nil || !c.shouldRetry(ctx, op, attempt, err, resp) {
+			return err
+		}
+		if err := sleep(ctx, c.retryPolicy.NextDelay(attempt)); err != nil {
+			return err
+		}
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return err
+			}
+		}
 	}
-	var marshalled map[string]*TransactionID
-	return marshalled["transaction"], c.executeRequestAndMarshal(req, &marshalled)
 }
 
-// listTransactions handles the underlying logic of executing the API requests
-// towards the merchant API and lists all related transactions
-func (c Client) listTransactions(merchantID string, limit int) ([]*Transaction, error) {
-	path := fmt.Sprintf("/merchants/%s/transactions?limit=%d", merchantID, limit)
-	req, err := http.NewRequest("GET", c.getURL(path), nil)
+// executeAttempt executes a single attempt of the request and decodes the
+// response into the given value
+func (c Client) executeAttempt(req *http.Request, op Operation, attempt int, value interface{}) (resp *http.Response, err error) {
+	release, err := c.acquireSlot(req.Context())
 	if err != nil {
 		return nil, err
 	}
-	var marshalled []*Transaction
-	return marshalled, c.executeRequestAndMarshal(req, &marshalled)
-}
-
-// captureTransaction handles the underlying logic of executing the API requests
-// towards the merchant API and captures a new amount for a given transaction
-func (c Client) captureTransaction(transactionID string, body io.Reader) (*Transaction, error) {
-	path := fmt.Sprintf("/transactions/%s/captures", transactionID)
-	req, err := http.NewRequest("POST", c.getURL(path), body)
-	if err != nil {
-		return nil, err
+	defer release()
+	if c.rateLimiter != nil {
+		if err := c.rateLimiter.Wait(req.Context(), c.Key); err != nil {
+			return nil, err
+		}
 	}
-	var marshalled map[string]*Transaction
-	return marshalled["transaction"], c.executeRequestAndMarshal(req, &marshalled)
-}
-
-// refundTransaction handles the underlying logic of executing the API requests
-// towards the merchant API and refunds a given amount for a given transaction
-func (c Client) refundTransaction(transactionID string, body io.Reader) (*Transaction, error) {
-	path := fmt.Sprintf("/transactions/%s/refunds", transactionID)
-	req, err := http.NewRequest("POST", c.getURL(path), body)
-	if err != nil {
+	if err := c.sign(req); err != nil {
 		return nil, err
 	}
-	var marshalled map[string]*Transaction
-	return marshalled["transaction"], c.executeRequestAndMarshal(req, &marshalled)
-}
-
-// voidTransaction handles the underlying logic of executing the API requests
-// towards the merchant API and cancels a given amount payment partially or completely
-func (c Client) voidTransaction(transactionID string, body io.Reader) (*Transaction, error) {
-	path := fmt.Sprintf("/transactions/%s/voids", transactionID)
-	req, err := http.NewRequest("POST", c.getURL(path), body)
-	if err != nil {
-		return nil, err
+	if c.breaker != nil {
+		if !c.breaker.Allow() {
+			return nil, ErrCircuitOpen
+		}
+		defer func() {
+			c.recordOutcome(err)
+		}()
 	}
-	var marshalled map[string]*Transaction
-	return marshalled["transaction"], c.executeRequestAndMarshal(req, &marshalled)
-}
-
-// findTransaction handles the underlying logic of executing the API requests
-// towards the merchant API and tries to search for a given transaction
-func (c Client) findTransaction(transactionID string) (*Transaction, error) {
-	path := fmt.Sprintf("/transactions/%s", transactionID)
-	req, err := http.NewRequest("GET", c.getURL(path), nil)
+	start := time.Now()
+	resp, err = c.client.Do(req)
+	defer func() {
+		c.reportMetrics(req, op, attempt, start, resp, err)
+	}()
 	if err != nil {
 		return nil, err
 	}
-	var marshalled map[string]*Transaction
-	return marshalled["transaction"], c.executeRequestAndMarshal(req, &marshalled)
-}
-
-// fetchCard handles the underlying logic of executing the API requests
-// towards the cards API and tries to find a given card by ID
-func (c Client) fetchCard(cardID string) (*Card, error) {
-	path := fmt.Sprintf("/cards/%s", cardID)
-	req, err := http.NewRequest("GET", c.getURL(path), nil)
-	if err != nil {
-		return nil, err
+	defer drainAndClose(resp.Body)
+	c.reportDeprecation(op, resp)
+	revalidated := resp.StatusCode == http.StatusNotModified && req.Header.Get("If-None-Match") != ""
+	if (resp.StatusCode < 200 || resp.StatusCode > 299) && !revalidated {
+		err = newAPIError(op, resp)
+		c.checkAuth(req, op, resp, err)
+		return resp, err
 	}
-	var marshalled map[string]*Card
-	return marshalled["card"], c.executeRequestAndMarshal(req, &marshalled)
-}
-
-// createCard handles the underlying logic of executing the API requests
-// towards the cards API and tries to find a given card by ID
-func (c Client) createCard(merchantID string, body io.Reader) (*CardID, error) {
-	path := fmt.Sprintf("/merchants/%s/cards", merchantID)
-	req, err := http.NewRequest("POST", c.getURL(path), body)
-	if err != nil {
-		return nil, err
+	c.checkAuth(req, op, resp, nil)
+	body, err := c.updateCache(req, op, resp)
+	if err != nil || value == nil {
+		return resp, err
 	}
-	var marshalled map[string]*CardID
-	return marshalled["card"], c.executeRequestAndMarshal(req, &marshalled)
+	return resp, c.decode(op, body, value)
 }
 
-// executeRequestAndMarshal sets the correct headers, then executes the request and tries to marshal
-// the response from the body into the given interface{} value
-func (c Client) executeRequestAndMarshal(req *http.Request, value interface{}) error {
-	req.SetBasicAuth("", c.Key)
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	b, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
-	if len(b) == 0 {
-		return nil
-	}
-	return json.Unmarshal(b, &value)
+// drainAndClose reads the rest of the body before closing it, so the
+// underlying connection can be reused
+func drainAndClose(body io.ReadCloser) {
+	io.Copy(ioutil.Discard, body)
+	body.Close()
 }
@@ -2,8 +2,16 @@ package paylike
 
 import (
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
+	"github.com/paylike/go-api/vcr"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -13,7 +21,7 @@ const TestSite = "https://example.com"
 const TestMerchant = "55006bdfe0308c4cbfdbd0e1"
 
 func TestCreateApp(t *testing.T) {
-	client := NewClient("")
+	client := newLiveClient(t, "")
 	app, err := client.CreateApp()
 	assert.Nil(t, err)
 	assert.NotEmpty(t, app)
@@ -21,7 +29,7 @@ func TestCreateApp(t *testing.T) {
 }
 
 func TestCreateAppWithName(t *testing.T) {
-	client := NewClient("")
+	client := newLiveClient(t, "")
 	app, err := client.CreateAppWithName("Macilaci")
 	assert.Nil(t, err)
 	assert.NotEmpty(t, app)
@@ -29,7 +37,7 @@ func TestCreateAppWithName(t *testing.T) {
 }
 
 func TestGetApp(t *testing.T) {
-	client := NewClient("")
+	client := newLiveClient(t, "")
 	app, err := client.CreateApp()
 	assert.Nil(t, err)
 	assert.NotEmpty(t, app)
@@ -40,7 +48,7 @@ func TestGetApp(t *testing.T) {
 }
 
 func TestCreateMerchant(t *testing.T) {
-	client := NewClient("")
+	client := newLiveClient(t, "")
 	app, err := client.CreateApp()
 	assert.Nil(t, err)
 	assert.NotEmpty(t, app)
@@ -61,7 +69,7 @@ func TestCreateMerchant(t *testing.T) {
 }
 
 func TestFetchMerchants(t *testing.T) {
-	client := NewClient("")
+	client := newLiveClient(t, "")
 	app, err := client.CreateApp()
 	assert.Nil(t, err)
 	assert.NotEmpty(t, app)
@@ -87,7 +95,7 @@ func TestFetchMerchants(t *testing.T) {
 }
 
 func TestGetMerchant(t *testing.T) {
-	client := NewClient("")
+	client := newLiveClient(t, "")
 	app, err := client.CreateApp()
 	assert.Nil(t, err)
 	assert.NotEmpty(t, app)
@@ -113,7 +121,7 @@ func TestGetMerchant(t *testing.T) {
 }
 
 func TestUpdateMerchant(t *testing.T) {
-	client := NewClient("")
+	client := newLiveClient(t, "")
 	app, err := client.CreateApp()
 	assert.Nil(t, err)
 	assert.NotEmpty(t, app)
@@ -149,7 +157,7 @@ func TestUpdateMerchant(t *testing.T) {
 }
 
 func TestInviteUserToMerchant(t *testing.T) {
-	client := NewClient("")
+	client := newLiveClient(t, "")
 	app, err := client.CreateApp()
 	assert.Nil(t, err)
 	assert.NotEmpty(t, app)
@@ -175,7 +183,7 @@ func TestInviteUserToMerchant(t *testing.T) {
 }
 
 func TestFetchUsersToMerchant(t *testing.T) {
-	client := NewClient("")
+	client := newLiveClient(t, "")
 	app, err := client.CreateApp()
 	assert.Nil(t, err)
 	assert.NotEmpty(t, app)
@@ -206,7 +214,7 @@ func TestFetchUsersToMerchant(t *testing.T) {
 }
 
 func TestRevokeUserFromMerchant(t *testing.T) {
-	client := NewClient("")
+	client := newLiveClient(t, "")
 	app, err := client.CreateApp()
 	assert.Nil(t, err)
 	assert.NotEmpty(t, app)
@@ -244,7 +252,7 @@ func TestRevokeUserFromMerchant(t *testing.T) {
 }
 
 func TestAddAppToMerchant(t *testing.T) {
-	client := NewClient("")
+	client := newLiveClient(t, "")
 	app, err := client.CreateApp()
 	assert.Nil(t, err)
 	assert.NotEmpty(t, app)
@@ -269,7 +277,7 @@ func TestAddAppToMerchant(t *testing.T) {
 }
 
 func TestFetchAppsToMerchant(t *testing.T) {
-	client := NewClient("")
+	client := newLiveClient(t, "")
 	app, err := client.CreateApp()
 	assert.Nil(t, err)
 	assert.NotEmpty(t, app)
@@ -299,7 +307,7 @@ func TestFetchAppsToMerchant(t *testing.T) {
 }
 
 func TestRevokeAppFromMerchant(t *testing.T) {
-	client := NewClient("")
+	client := newLiveClient(t, "")
 	app, err := client.CreateApp()
 	assert.Nil(t, err)
 	assert.NotEmpty(t, app)
@@ -331,7 +339,7 @@ func TestRevokeAppFromMerchant(t *testing.T) {
 }
 
 func TestFetchLinesToMerchant(t *testing.T) {
-	client := NewClient(TestKey)
+	client := newLiveClient(t, TestKey)
 	lines, err := client.FetchLinesToMerchant(TestMerchant, 1)
 	assert.Nil(t, err)
 	assert.NotEmpty(t, lines)
@@ -339,7 +347,7 @@ func TestFetchLinesToMerchant(t *testing.T) {
 }
 
 func TestCreateTransaction(t *testing.T) {
-	client := NewClient(TestKey)
+	client := newLiveClient(t, TestKey)
 	dto := TransactionDTO{
 		TransactionID: "560fd96b7973ff3d2362a78c",
 		Currency:      "EUR",
@@ -352,7 +360,7 @@ func TestCreateTransaction(t *testing.T) {
 }
 
 func TestListTransactions(t *testing.T) {
-	client := NewClient(TestKey)
+	client := newLiveClient(t, TestKey)
 	transactions, err := client.ListTransactions(TestMerchant, 20)
 	assert.Nil(t, err)
 	assert.NotEmpty(t, transactions)
@@ -360,7 +368,7 @@ func TestListTransactions(t *testing.T) {
 }
 
 func TestCaptureTransaction(t *testing.T) {
-	client := NewClient(TestKey)
+	client := newLiveClient(t, TestKey)
 	transactionDTO := TransactionDTO{
 		TransactionID: "560fd96b7973ff3d2362a78c",
 		Currency:      "EUR",
@@ -385,7 +393,7 @@ func TestCaptureTransaction(t *testing.T) {
 }
 
 func TestRefundTransaction(t *testing.T) {
-	client := NewClient(TestKey)
+	client := newLiveClient(t, TestKey)
 	transactionDTO := TransactionDTO{
 		TransactionID: "560fd96b7973ff3d2362a78c",
 		Currency:      "EUR",
@@ -418,7 +426,7 @@ func TestRefundTransaction(t *testing.T) {
 }
 
 func TestVoidTransaction(t *testing.T) {
-	client := NewClient(TestKey)
+	client := newLiveClient(t, TestKey)
 	transactionDTO := TransactionDTO{
 		TransactionID: "560fd96b7973ff3d2362a78c",
 		Currency:      "EUR",
@@ -449,7 +457,7 @@ func TestVoidTransaction(t *testing.T) {
 }
 
 func TestFindTransaction(t *testing.T) {
-	client := NewClient(TestKey)
+	client := newLiveClient(t, TestKey)
 	transactionDTO := TransactionDTO{
 		TransactionID: "560fd96b7973ff3d2362a78c",
 		Currency:      "EUR",
@@ -478,7 +486,7 @@ func TestFindTransaction(t *testing.T) {
 }
 
 func TestFetchCard(t *testing.T) {
-	client := NewClient(TestKey)
+	client := newLiveClient(t, TestKey)
 	dto := CardDTO{
 		TransactionID: "560fd96b7973ff3d2362a78c",
 	}
@@ -491,3 +499,202 @@ func TestFetchCard(t *testing.T) {
 	assert.NotEmpty(t, card)
 	assert.Equal(t, card.ID, data.ID)
 }
+
+func TestUserAgent(t *testing.T) {
+	var userAgent string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.UserAgent()
+	}))
+	_, err := client.FetchApp()
+	assert.Nil(t, err)
+	assert.Equal(t, fmt.Sprintf("paylike-go/%s (%s)", Version, runtime.Version()), userAgent)
+
+	_, err = client.SetAppIdentifier("myshop/2.1").FetchApp()
+	assert.Nil(t, err)
+	assert.True(t, strings.HasSuffix(userAgent, ") myshop/2.1"))
+}
+
+func TestListTransactionsDecoding(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("["))
+		for i := 0; i < 500; i++ {
+			if i > 0 {
+				w.Write([]byte(","))
+			}
+			fmt.Fprintf(w, `{"id":"tx%d","amount":%d,"currency":"EUR"}`, i, i)
+		}
+		w.Write([]byte("]"))
+	}))
+	transactions, err := client.ListTransactions(TestMerchant, 500)
+	assert.Nil(t, err)
+	assert.Len(t, transactions, 500)
+	assert.Equal(t, TxID("tx499"), transactions[499].ID)
+	assert.Equal(t, int64(499), transactions[499].Amount)
+}
+
+func TestEmptyResponseBody(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	merchant, err := client.GetMerchant(TestMerchant)
+	assert.Nil(t, err)
+	assert.Nil(t, merchant)
+	assert.Nil(t, client.RevokeAppFromMerchant(TestMerchant, "app"))
+}
+
+func TestGenericHelpers(t *testing.T) {
+	var uris []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uris = append(uris, r.Method+" "+r.URL.RequestURI())
+		if r.Method == "GET" && strings.HasSuffix(r.URL.Path, "/widgets") {
+			w.Write([]byte(`[{"id":"w1"},{"id":"w2"}]`))
+			return
+		}
+		w.Write([]byte(`{"widget":{"id":"w1"}}`))
+	}))
+	type widget struct {
+		ID string `json:"id"`
+	}
+	opGet := Operation{Name: "GetWidget", Method: "GET", Path: "/merchants/{merchantId}/widgets/{widgetId}"}
+	opList := Operation{Name: "ListWidgets", Method: "GET", Path: "/merchants/{merchantId}/widgets"}
+
+	w, err := getWrapped[widget](*client, opGet, nil, "widget", "m1", "w1")
+	assert.Nil(t, err)
+	assert.Equal(t, "w1", w.ID)
+
+	missing, err := getWrapped[widget](*client, opGet, nil, "other", "m1", "w1")
+	assert.Nil(t, err)
+	assert.Nil(t, missing)
+
+	widgets, err := list[widget](*client, opList, 2, "m1")
+	assert.Nil(t, err)
+	assert.Len(t, widgets, 2)
+	assert.Equal(t, "w2", widgets[1].ID)
+	assert.Equal(t, []string{
+		"GET /merchants/m1/widgets/w1",
+		"GET /merchants/m1/widgets/w1",
+		"GET /merchants/m1/widgets?limit=2",
+	}, uris)
+}
+
+func TestRequestBodies(t *testing.T) {
+	var bodies []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+	}))
+	_, err := client.CreateApp()
+	assert.Nil(t, err)
+	_, err = client.CreateAppWithName(`my "app"`)
+	assert.Nil(t, err)
+	_, err = client.InviteUserToMerchant(TestMerchant, TestEmail)
+	assert.Nil(t, err)
+	assert.Nil(t, client.AddAppToMerchant(TestMerchant, "app1"))
+	assert.Equal(t, []string{``, `{"name":"my \"app\""}`, `{"email":"john@example.com"}`, `{"appId":"app1"}`}, bodies)
+}
+
+func TestMerchantUsers(t *testing.T) {
+	var bodies []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			b, _ := ioutil.ReadAll(r.Body)
+			bodies = append(bodies, string(b))
+			w.Write([]byte(`{"isMember":false}`))
+			return
+		}
+		w.Write([]byte(`[{"id":"u1","email":"a@example.com","name":"Ann","created":"2024-01-01T00:00:00.000Z","role":"admin"},{"id":"u2","email":"b@example.com","pending":true}]`))
+	}))
+	users, err := client.FetchUsersToMerchant(TestMerchant, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, "Ann", users[0].Name)
+	assert.Equal(t, "admin", users[0].Role)
+	assert.False(t, users[0].Pending)
+	assert.True(t, users[1].Pending)
+
+	assert.Nil(t, client.ResendInvite(TestMerchant, users[1].Email))
+	assert.Equal(t, []string{`{"email":"b@example.com"}`}, bodies)
+}
+
+func TestHasApp(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/me":
+			w.Write([]byte(`{"identity":{"id":"a2","name":"shop"}}`))
+		case "/merchants/m1/apps":
+			w.Write([]byte(`[{"id":"a1","created":"2024-01-01T00:00:00.000Z","scopes":["transactions"]},{"id":"a2"}]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	apps, err := client.FetchAppsToMerchant("m1", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-01-01T00:00:00.000Z", apps[0].Created)
+	assert.Equal(t, []string{"transactions"}, apps[0].Scopes)
+
+	attached, err := client.HasApp("m1")
+	assert.Nil(t, err)
+	assert.True(t, attached)
+	attached, err = client.HasApp("m2")
+	assert.Nil(t, err)
+	assert.False(t, attached)
+}
+
+func TestUpdateMerchantAndFetch(t *testing.T) {
+	var requests []string
+	respond := true
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == "PUT" && respond:
+			w.Write([]byte(`{"merchant":{"id":"m1","name":"Updated"}}`))
+		case r.Method == "PUT":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Write([]byte(`{"merchant":{"id":"m1","name":"Fetched"}}`))
+		}
+	}))
+	merchant, err := client.UpdateMerchantAndFetch("m1", MerchantUpdateDTO{Name: "Updated"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Updated", merchant.Name)
+
+	respond = false
+	merchant, err = client.UpdateMerchantAndFetch("m1", MerchantUpdateDTO{Name: "Updated"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Fetched", merchant.Name)
+	assert.Equal(t, []string{"PUT /merchants/m1", "PUT /merchants/m1", "GET /merchants/m1"}, requests)
+}
+
+// newTestClient creates a client with the given options pointing to an
+// in-process server serving the given handler instead of the live API
+func newTestClient(t testing.TB, handler http.Handler, opts ...Option) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client := NewClient(TestKey, opts...)
+	client.baseAPI = server.URL
+	return client
+}
+
+// newLiveClient creates a client talking to the live API, replaying the
+// cassette of the test from testdata/cassettes if one has been recorded
+// Set PAYLIKE_RECORD=1 to record the cassette using the key in PAYLIKE_KEY,
+// if any, instead of the given one
+func newLiveClient(t *testing.T, key string) *Client {
+	path := filepath.Join("testdata", "cassettes", t.Name()+".json")
+	mode := vcr.ModeReplay
+	if os.Getenv("PAYLIKE_RECORD") != "" {
+		mode = vcr.ModeRecord
+		if envKey := os.Getenv("PAYLIKE_KEY"); envKey != "" && key != "" {
+			key = envKey
+		}
+	} else if _, err := os.Stat(path); err != nil {
+		return NewClient(key)
+	}
+	recorder, err := vcr.New(path, mode, vcr.WithRedacted(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := recorder.Save(); err != nil {
+			t.Error(err)
+		}
+	})
+	return NewClient(key, WithHTTPClient(recorder.Client()))
+}
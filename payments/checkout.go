@@ -0,0 +1,80 @@
+package payments
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// currencyExponents lists the ISO 4217 currencies whose minor unit isn't a
+// hundredth of the major unit
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0,
+	"XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// CurrencyExponent returns the number of digits of the minor unit of the
+// given currency, e.g. 2 for EUR and 0 for JPY
+func CurrencyExponent(currency string) int {
+	if exponent, ok := currencyExponents[strings.ToUpper(currency)]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// ParseAmount converts a decimal amount in the major unit of the given
+// currency, e.g. "10.5" EUR, to an amount in minor units, e.g. 1050
+func ParseAmount(currency, value string) (Amount, error) {
+	currency = strings.ToUpper(currency)
+	amount := Amount{Currency: currency, Exponent: CurrencyExponent(currency)}
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" || len(fraction) > amount.Exponent || len(whole)+amount.Exponent > 18 {
+		return Amount{}, ErrInvalidAmount
+	}
+	fraction += strings.Repeat("0", amount.Exponent-len(fraction))
+	for _, digit := range whole + fraction {
+		if digit < '0' || digit > '9' {
+			return Amount{}, ErrInvalidAmount
+		}
+		amount.Value = amount.Value*10 + int64(digit-'0')
+	}
+	return amount, amount.Validate()
+}
+
+// Checkout describes the configuration of a payment made with the popup or
+// hosted payment page of Paylike's front-end SDK, e.g.
+// paylike.pay(config) with config rendered by JSON
+type Checkout struct {
+	Test        bool                   `json:"test,omitempty"`
+	Title       string                 `json:"title,omitempty"`       // optional, shown in the popup
+	Description string                 `json:"description,omitempty"` // optional, shown in the popup
+	Locale      string                 `json:"locale,omitempty"`      // optional, e.g. "da", defaults to the browser's
+	Amount      *Amount                `json:"amount,omitempty"`      // optional, omitted to only save the card
+	Text        string                 `json:"text,omitempty"`        // optional, text on client bank statements
+	Custom      map[string]interface{} `json:"custom,omitempty"`
+	Plan        []PlanItem             `json:"plan,omitempty"`
+	Unplanned   *Unplanned             `json:"unplanned,omitempty"`
+}
+
+// ErrCheckoutEmpty is returned when a checkout neither charges an amount nor
+// plans charges, so there would be nothing to pay for
+var ErrCheckoutEmpty = errors.New("payments: checkout requires an amount, a plan or unplanned charges")
+
+// Validate checks whether the checkout can be rendered
+func (c Checkout) Validate() error {
+	if c.Amount == nil && len(c.Plan) == 0 && c.Unplanned == nil {
+		return ErrCheckoutEmpty
+	}
+	return Payment{Amount: c.Amount, Plan: c.Plan}.Validate()
+}
+
+// JSON validates the checkout and renders it as the configuration expected
+// by the front-end SDK
+func (c Checkout) JSON() ([]byte, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(c)
+}
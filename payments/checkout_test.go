@@ -0,0 +1,60 @@
+package payments
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAmount(t *testing.T) {
+	for value, expected := range map[string]Amount{
+		"10":     {Currency: "EUR", Exponent: 2, Value: 1000},
+		"10.5":   {Currency: "EUR", Exponent: 2, Value: 1050},
+		"0.01":   {Currency: "EUR", Exponent: 2, Value: 1},
+		"10.50":  {Currency: "EUR", Exponent: 2, Value: 1050},
+		"123456": {Currency: "EUR", Exponent: 2, Value: 12345600},
+	} {
+		amount, err := ParseAmount("eur", value)
+		assert.Nil(t, err, value)
+		assert.Equal(t, expected, amount, value)
+	}
+	amount, err := ParseAmount("JPY", "1000")
+	assert.Nil(t, err)
+	assert.Equal(t, Amount{Currency: "JPY", Exponent: 0, Value: 1000}, amount)
+	amount, err = ParseAmount("KWD", "1.234")
+	assert.Nil(t, err)
+	assert.Equal(t, Amount{Currency: "KWD", Exponent: 3, Value: 1234}, amount)
+
+	for _, value := range []string{"", ".5", "10.505", "-10", "1e3", "0", "0.00", "10,50", "99999999999999999999"} {
+		_, err := ParseAmount("EUR", value)
+		assert.Equal(t, ErrInvalidAmount, err, value)
+	}
+	_, err = ParseAmount("JPY", "10.5")
+	assert.Equal(t, ErrInvalidAmount, err)
+}
+
+func TestCheckoutJSON(t *testing.T) {
+	_, err := Checkout{Title: "Shop"}.JSON()
+	assert.Equal(t, ErrCheckoutEmpty, err)
+	_, err = Checkout{Amount: &Amount{Currency: "EUR", Exponent: 2}}.JSON()
+	assert.Equal(t, ErrInvalidAmount, err)
+
+	amount, _ := ParseAmount("EUR", "10.50")
+	b, err := Checkout{
+		Test:   true,
+		Title:  "Shop",
+		Amount: &amount,
+		Text:   "Order 123",
+		Custom: map[string]interface{}{"orderId": "123"},
+		Plan:   []PlanItem{{Repeat: &Repeat{Interval: Interval{Unit: Month}}}},
+	}.JSON()
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{
+		"test": true,
+		"title": "Shop",
+		"amount": {"currency": "EUR", "exponent": 2, "value": 1050},
+		"text": "Order 123",
+		"custom": {"orderId": "123"},
+		"plan": [{"repeat": {"interval": {"unit": "month"}}}]
+	}`, string(b))
+}
@@ -0,0 +1,326 @@
+// Package payments creates payments through the newer Paylike payments API,
+// which answers payment requests with challenges to solve (e.g. fetching
+// hints or a 3-D Secure authentication) until the payment is authorized
+// Card details are tokenized by the vault beforehand, so they never reach
+// the payments API in plain text
+package payments
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	paylike "github.com/paylike/go-api"
+)
+
+// Default locations of the payments API and the vault
+const (
+	DefaultURL      = "https://b.paylike.io"
+	DefaultVaultURL = "https://vault.paylike.io"
+)
+
+// DefaultMaxChallenges bounds the number of challenges solved for a payment
+const DefaultMaxChallenges = 20
+
+// maxErrorBodySize limits how much of an error response body is read
+const maxErrorBodySize = 64 << 10
+
+// TokenType describes the kind of value tokenized by the vault
+type TokenType string
+
+// Possible token types
+const (
+	CardNumber TokenType = "pcn"        // primary card number
+	CardCode   TokenType = "pcsc"       // card security code
+	ApplePay   TokenType = "apple-pay"  // payment token of an Apple Pay authorization
+	GooglePay  TokenType = "google-pay" // payment token of a Google Pay authorization
+)
+
+// Integration identifies the merchant receiving the payment by its public key
+type Integration struct {
+	Key string `json:"key"`
+}
+
+// Amount describes an amount in minor units, e.g. 1000 with exponent 2 for
+// 10.00
+type Amount struct {
+	Currency string `json:"currency"` // three letter ISO
+	Exponent int    `json:"exponent"` // number of digits of the minor unit
+	Value    int64  `json:"value"`
+}
+
+// Token references a value tokenized by the vault
+type Token struct {
+	Token string `json:"token"`
+}
+
+// Expiry describes the expiry of a card
+type Expiry struct {
+	Month int `json:"month"`
+	Year  int `json:"year"`
+}
+
+// Card describes the tokenized details of a card
+type Card struct {
+	Number Token  `json:"number"`
+	Code   Token  `json:"code"`
+	Expiry Expiry `json:"expiry"`
+}
+
+// Test marks a payment as made in test mode
+type Test struct{}
+
+// Payment describes a payment to create
+type Payment struct {
+	Integration Integration            `json:"integration"`
+	Amount      *Amount                `json:"amount,omitempty"`    // optional, omitted to only save the card
+	Card        *Card                  `json:"card,omitempty"`      // one of Card, ApplePay and GooglePay is required
+	ApplePay    *Token                 `json:"applepay,omitempty"`  // tokenized with the ApplePay type
+	GooglePay   *Token                 `json:"googlepay,omitempty"` // tokenized with the GooglePay type
+	Text        string                 `json:"text,omitempty"`      // optional, text on client bank statements
+	Custom      map[string]interface{} `json:"custom,omitempty"`
+	Plan        []PlanItem             `json:"plan,omitempty"`      // optional, charges planned after this payment, e.g. of a subscription
+	Unplanned   *Unplanned             `json:"unplanned,omitempty"` // optional, who may initiate charges outside of the plan
+	Test        *Test                  `json:"test,omitempty"`
+}
+
+// Challenge describes a step the payments API requires before authorizing
+// a payment
+type Challenge struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // e.g. "fetch", solved by the client itself
+	Path string `json:"path"`
+}
+
+// Result describes an authorized payment
+type Result struct {
+	AuthorizationID string       `json:"authorizationId"`
+	TransactionID   paylike.TxID `json:"transactionId"`
+}
+
+// ChallengeHandler solves a challenge that cannot be solved server-side, e.g.
+// by having the customer complete a 3-D Secure authentication, returning
+// the hints the solution yielded
+type ChallengeHandler func(ctx context.Context, challenge Challenge, hints []string) ([]string, error)
+
+// ChallengeError is returned when a payment requires a challenge the client
+// cannot solve, so it can be passed on to the customer's browser
+type ChallengeError struct {
+	Challenge Challenge
+	Hints     []string // hints collected so far, to resume the payment with
+}
+
+// Error returns the name and type of the unsolved challenge
+func (e *ChallengeError) Error() string {
+	return fmt.Sprintf("payments: unsolved challenge %q of type %q", e.Challenge.Name, e.Challenge.Type)
+}
+
+// ErrMultipleSources is returned when a payment is funded by more than one of
+// a card and wallets
+var ErrMultipleSources = errors.New("payments: more than one payment source")
+
+// ErrTooManyChallenges is returned when a payment still requires challenges
+// after the maximum number of them has been solved
+var ErrTooManyChallenges = errors.New("payments: too many challenges")
+
+// Client creates payments through the payments API
+type Client struct {
+	client        *http.Client
+	url           string
+	vaultURL      string
+	clientID      string
+	handler       ChallengeHandler
+	maxChallenges int
+}
+
+// Option configures a client
+type Option func(*Client)
+
+// WithHTTPClient sends the requests using the given HTTP client
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.client = client
+	}
+}
+
+// WithURLs sends the requests to the given payments API and vault instead of
+// the live ones, e.g. to fake servers in tests
+func WithURLs(url, vaultURL string) Option {
+	return func(c *Client) {
+		c.url = strings.TrimSuffix(url, "/")
+		c.vaultURL = strings.TrimSuffix(vaultURL, "/")
+	}
+}
+
+// WithChallengeHandler solves the challenges the client cannot solve itself
+// with the given handler, instead of failing with a ChallengeError
+func WithChallengeHandler(handler ChallengeHandler) Option {
+	return func(c *Client) {
+		c.handler = handler
+	}
+}
+
+// WithMaxChallenges changes how many challenges are solved for a payment,
+// defaults to DefaultMaxChallenges
+func WithMaxChallenges(n int) Option {
+	return func(c *Client) {
+		c.maxChallenges = n
+	}
+}
+
+// New creates a client for the live payments API
+func New(opts ...Option) *Client {
+	c := &Client{
+		client:        &http.Client{},
+		url:           DefaultURL,
+		vaultURL:      DefaultVaultURL,
+		clientID:      "paylike-go/" + paylike.Version,
+		maxChallenges: DefaultMaxChallenges,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Tokenize exchanges the given card number, security code or wallet payment
+// token for a token to be used in payments
+// Strings cannot be wiped from memory, see TokenizeBytes for sensitive values
+func (c *Client) Tokenize(ctx context.Context, kind TokenType, value string) (string, error) {
+	token, err := c.TokenizeBytes(ctx, kind, []byte(value))
+	return token.Token, err
+}
+
+// Create validates and creates the given payment, solving the challenges the payments API
+// responds with until the payment is authorized
+// Hints collected from an earlier attempt (see ChallengeError) can be passed
+// to resume a payment
+func (c *Client) Create(ctx context.Context, payment Payment, hints ...string) (*Result, error) {
+	if err := payment.Validate(); err != nil {
+		return nil, err
+	}
+	hints = append([]string(nil), hints...)
+	path := "/payments"
+	for round := 0; ; round++ {
+		if round > c.maxChallenges {
+			return nil, ErrTooManyChallenges
+		}
+		var resp paymentResponse
+		if err := c.post(ctx, c.url+path, paymentRequest{payment, hints}, &resp); err != nil {
+			return nil, err
+		}
+		if resp.AuthorizationID != "" || resp.TransactionID != "" {
+			return &Result{AuthorizationID: resp.AuthorizationID, TransactionID: resp.TransactionID}, nil
+		}
+		hints = append(hints, resp.Hints...)
+		path = "/payments"
+		if len(resp.Challenges) == 0 {
+			if len(resp.Hints) == 0 {
+				return nil, errors.New("payments: neither challenges nor result in response")
+			}
+			continue
+		}
+		challenge := resp.Challenges[0]
+		if challenge.Type == "fetch" {
+			path = challenge.Path
+			continue
+		}
+		if c.handler == nil {
+			return nil, &ChallengeError{Challenge: challenge, Hints: hints}
+		}
+		solution, err := c.handler(ctx, challenge, hints)
+		if err != nil {
+			return nil, err
+		}
+		hints = append(hints, solution...)
+	}
+}
+
+// sources returns the number of sources funding the payment
+func (p Payment) sources() int {
+	n := 0
+	if p.Card != nil {
+		n++
+	}
+	if p.ApplePay != nil {
+		n++
+	}
+	if p.GooglePay != nil {
+		n++
+	}
+	return n
+}
+
+// paymentRequest describes the body of a payment request
+type paymentRequest struct {
+	Payment
+	Hints []string `json:"hints"`
+}
+
+// paymentResponse describes the possible responses to a payment request
+type paymentResponse struct {
+	Result
+	Challenges []Challenge `json:"challenges"`
+	Hints      []string    `json:"hints"`
+}
+
+// post sends the given body as JSON to the given URL and decodes the
+// response into the given value
+func (c *Client) post(ctx context.Context, url string, body interface{}, value interface{}) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return c.postRaw(ctx, url, b, value)
+}
+
+// postRaw sends the given JSON body to the given URL and decodes the
+// response into the given value
+func (c *Client) postRaw(ctx context.Context, url string, b []byte, value interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Version", "1")
+	req.Header.Set("X-Client", c.clientID)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newAPIError(resp)
+	}
+	return json.NewDecoder(resp.Body).Decode(value)
+}
+
+// newAPIError builds a paylike.APIError from an error response, so the
+// error helpers of the paylike package apply to it
+func newAPIError(resp *http.Response) error {
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err != nil {
+		return err
+	}
+	apiErr := &paylike.APIError{StatusCode: resp.StatusCode, Body: b}
+	var body struct {
+		Code    json.RawMessage `json:"code"` // numeric or string
+		Message string          `json:"message"`
+	}
+	if json.Unmarshal(b, &body) == nil {
+		if len(body.Code) > 0 && string(body.Code) != "null" {
+			apiErr.Code = strings.Trim(string(body.Code), `"`)
+		}
+		apiErr.Message = body.Message
+	}
+	return apiErr
+}
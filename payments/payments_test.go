@@ -0,0 +1,148 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	paylike "github.com/paylike/go-api"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestClient creates a client sending its requests to a fake payments
+// API requiring a fetch challenge and a 3-D Secure challenge
+func newTestClient(t *testing.T, requests *[]string, opts ...Option) *Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Type  string   `json:"type"`
+			Value string   `json:"value"`
+			Hints []string `json:"hints"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		*requests = append(*requests, r.URL.Path)
+		assert.Equal(t, "1", r.Header.Get("Accept-Version"))
+		switch {
+		case r.URL.Path == "/vault":
+			w.Write([]byte(`{"token":"tok-` + body.Type + `"}`))
+		case r.URL.Path == "/challenges/fingerprint":
+			w.Write([]byte(`{"hints":["fingerprinted"]}`))
+		case len(body.Hints) == 0:
+			w.Write([]byte(`{"challenges":[{"name":"fingerprint","type":"fetch","path":"/challenges/fingerprint"}]}`))
+		case len(body.Hints) == 1:
+			w.Write([]byte(`{"challenges":[{"name":"tds","type":"iframe","path":"/challenges/tds"}]}`))
+		case body.Hints[1] == "tds-ok":
+			w.Write([]byte(`{"authorizationId":"auth1","transactionId":"tx1"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":"INVALID_HINTS","message":"invalid hints"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return New(append([]Option{WithURLs(server.URL, server.URL+"/vault")}, opts...)...)
+}
+
+func TestTokenize(t *testing.T) {
+	var requests []string
+	client := newTestClient(t, &requests)
+	token, err := client.Tokenize(context.Background(), CardNumber, "4100000000000000")
+	assert.Nil(t, err)
+	assert.Equal(t, "tok-pcn", token)
+}
+
+func TestCreate(t *testing.T) {
+	var requests []string
+	var challenges []string
+	client := newTestClient(t, &requests, WithChallengeHandler(func(ctx context.Context, challenge Challenge, hints []string) ([]string, error) {
+		challenges = append(challenges, challenge.Name)
+		assert.Equal(t, []string{"fingerprinted"}, hints)
+		return []string{"tds-ok"}, nil
+	}))
+	result, err := client.Create(context.Background(), Payment{
+		Integration: Integration{Key: "public"},
+		Amount:      &Amount{Currency: "EUR", Exponent: 2, Value: 1000},
+		Card:        &Card{Number: Token{"tok-pcn"}, Code: Token{"tok-pcsc"}, Expiry: Expiry{Month: 12, Year: 2030}},
+		Test:        &Test{},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, &Result{AuthorizationID: "auth1", TransactionID: "tx1"}, result)
+	assert.Equal(t, []string{"tds"}, challenges)
+	assert.Equal(t, []string{"/payments", "/challenges/fingerprint", "/payments", "/payments"}, requests)
+}
+
+func TestCreateUnsolvedChallenge(t *testing.T) {
+	var requests []string
+	client := newTestClient(t, &requests)
+	_, err := client.Create(context.Background(), Payment{Integration: Integration{Key: "public"}})
+	var challengeErr *ChallengeError
+	assert.True(t, errors.As(err, &challengeErr))
+	assert.Equal(t, "tds", challengeErr.Challenge.Name)
+	assert.Equal(t, []string{"fingerprinted"}, challengeErr.Hints)
+
+	_, err = client.Create(context.Background(), Payment{Integration: Integration{Key: "public"}}, append(challengeErr.Hints, "tds-failed")...)
+	var apiErr *paylike.APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "INVALID_HINTS", apiErr.Code)
+	assert.True(t, paylike.IsClientError(err))
+}
+
+func TestCreateNumericErrorCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":51,"message":"insufficient funds"}`))
+	}))
+	defer server.Close()
+	_, err := New(WithURLs(server.URL, server.URL)).Create(context.Background(), Payment{Integration: Integration{Key: "public"}})
+	var apiErr *paylike.APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "51", apiErr.Code)
+	assert.Equal(t, "insufficient funds", apiErr.Message)
+}
+
+func TestCreateTooManyChallenges(t *testing.T) {
+	var requests []string
+	client := newTestClient(t, &requests, WithMaxChallenges(1))
+	_, err := client.Create(context.Background(), Payment{Integration: Integration{Key: "public"}})
+	assert.Equal(t, ErrTooManyChallenges, err)
+}
+
+func TestCreateEndlessHints(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"hints":["again"]}`))
+	}))
+	defer server.Close()
+	client := New(WithURLs(server.URL, server.URL), WithMaxChallenges(3))
+	_, err := client.Create(context.Background(), Payment{Integration: Integration{Key: "public"}})
+	assert.Equal(t, ErrTooManyChallenges, err)
+	assert.Equal(t, 4, requests)
+}
+
+func TestCreateWithWallet(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"transactionId":"tx1"}`))
+	}))
+	defer server.Close()
+	client := New(WithURLs(server.URL, server.URL))
+	result, err := client.Create(context.Background(), Payment{
+		Integration: Integration{Key: "public"},
+		Amount:      &Amount{Currency: "EUR", Exponent: 2, Value: 1000},
+		ApplePay:    &Token{"tok-apple"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, paylike.TxID("tx1"), result.TransactionID)
+	assert.Equal(t, map[string]interface{}{"token": "tok-apple"}, body["applepay"])
+	assert.NotContains(t, body, "card")
+
+	_, err = client.Create(context.Background(), Payment{
+		Integration: Integration{Key: "public"},
+		Card:        &Card{},
+		GooglePay:   &Token{"tok-google"},
+	})
+	assert.Equal(t, ErrMultipleSources, err)
+}
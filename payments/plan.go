@@ -0,0 +1,109 @@
+package payments
+
+import (
+	"errors"
+	"time"
+)
+
+// Units of a repeat interval
+const (
+	Day   = "day"
+	Week  = "week"
+	Month = "month"
+	Year  = "year"
+)
+
+// PlanItem describes a planned charge, either once at a scheduled time or
+// repeated at an interval
+type PlanItem struct {
+	Amount    *Amount    `json:"amount,omitempty"`    // optional, defaults to the amount of the payment
+	Scheduled *time.Time `json:"scheduled,omitempty"` // required if no Repeat is present
+	Repeat    *Repeat    `json:"repeat,omitempty"`    // required if no Scheduled is present
+}
+
+// Repeat describes charges repeated at an interval
+type Repeat struct {
+	First    *time.Time `json:"first,omitempty"` // optional, time of the first charge
+	Count    int        `json:"count,omitempty"` // optional, number of charges, unlimited if zero
+	Interval Interval   `json:"interval"`
+}
+
+// Interval describes the time between repeated charges, e.g. every 3 months
+type Interval struct {
+	Unit  string `json:"unit"`            // one of Day, Week, Month and Year
+	Value int    `json:"value,omitempty"` // optional, number of units, defaults to 1
+}
+
+// Unplanned describes who may initiate charges outside of the plan, e.g. for
+// top-ups or usage based billing
+type Unplanned struct {
+	Customer bool `json:"costumer,omitempty"` // spelled as by the API
+	Merchant bool `json:"merchant,omitempty"`
+}
+
+// Errors returned when validating a plan
+var (
+	ErrPlanItemSchedule = errors.New("payments: plan item requires either scheduled or repeat")
+	ErrPlanInterval     = errors.New("payments: plan interval requires a unit of day, week, month or year and no negative value")
+	ErrPlanCount        = errors.New("payments: plan repeat count cannot be negative")
+	ErrInvalidAmount    = errors.New("payments: amount requires a three letter currency and a positive value")
+)
+
+// Validate checks whether the plan item can be registered
+func (p PlanItem) Validate() error {
+	if p.Amount != nil {
+		if err := p.Amount.Validate(); err != nil {
+			return err
+		}
+	}
+	if (p.Scheduled == nil) == (p.Repeat == nil) {
+		return ErrPlanItemSchedule
+	}
+	if p.Repeat == nil {
+		return nil
+	}
+	if p.Repeat.Count < 0 {
+		return ErrPlanCount
+	}
+	return p.Repeat.Interval.Validate()
+}
+
+// Validate checks whether the interval is known and positive
+func (i Interval) Validate() error {
+	switch i.Unit {
+	case Day, Week, Month, Year:
+	default:
+		return ErrPlanInterval
+	}
+	if i.Value < 0 {
+		return ErrPlanInterval
+	}
+	return nil
+}
+
+// Validate checks whether the amount has a currency and a positive value
+func (a Amount) Validate() error {
+	if len(a.Currency) != 3 || a.Value <= 0 || a.Exponent < 0 {
+		return ErrInvalidAmount
+	}
+	return nil
+}
+
+// Validate checks whether the payment can be created, being funded by at
+// most one source and having valid amounts and plan
+func (p Payment) Validate() error {
+	if p.sources() > 1 {
+		return ErrMultipleSources
+	}
+	if p.Amount != nil {
+		if err := p.Amount.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, item := range p.Plan {
+		if err := item.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
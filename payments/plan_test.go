@@ -0,0 +1,44 @@
+package payments
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanValidate(t *testing.T) {
+	at := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	monthly := Repeat{Interval: Interval{Unit: Month}}
+	assert.Equal(t, ErrPlanItemSchedule, PlanItem{}.Validate())
+	assert.Equal(t, ErrPlanItemSchedule, PlanItem{Scheduled: &at, Repeat: &monthly}.Validate())
+	assert.Nil(t, PlanItem{Scheduled: &at}.Validate())
+	assert.Nil(t, PlanItem{Repeat: &monthly}.Validate())
+	assert.Equal(t, ErrPlanInterval, PlanItem{Repeat: &Repeat{Interval: Interval{Unit: "fortnight"}}}.Validate())
+	assert.Equal(t, ErrPlanInterval, PlanItem{Repeat: &Repeat{Interval: Interval{Unit: Week, Value: -1}}}.Validate())
+	assert.Equal(t, ErrPlanCount, PlanItem{Repeat: &Repeat{Count: -1, Interval: Interval{Unit: Week}}}.Validate())
+	assert.Equal(t, ErrInvalidAmount, PlanItem{Amount: &Amount{Currency: "EUR"}, Scheduled: &at}.Validate())
+
+	payment := Payment{
+		Amount: &Amount{Currency: "EUR", Exponent: 2, Value: 1000},
+		Plan:   []PlanItem{{Repeat: &Repeat{First: &at, Count: 12, Interval: Interval{Unit: Month}}}},
+	}
+	assert.Nil(t, payment.Validate())
+	payment.Plan = append(payment.Plan, PlanItem{})
+	assert.Equal(t, ErrPlanItemSchedule, payment.Validate())
+}
+
+func TestPlanJSON(t *testing.T) {
+	at := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	b, err := json.Marshal(Payment{
+		Plan:      []PlanItem{{Repeat: &Repeat{First: &at, Interval: Interval{Unit: Month, Value: 3}}}},
+		Unplanned: &Unplanned{Customer: true},
+	})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{
+		"integration": {"key": ""},
+		"plan": [{"repeat": {"first": "2030-01-01T00:00:00Z", "interval": {"unit": "month", "value": 3}}}],
+		"unplanned": {"costumer": true}
+	}`, string(b))
+}
@@ -0,0 +1,84 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"unicode/utf8"
+)
+
+// TokenizeBytes exchanges the given card number, security code or wallet
+// payment token for a token to be used in payments
+// The value and the request body built from it are zeroed before returning,
+// whether the call succeeds or not, so the plain value doesn't linger in
+// memory owned by this package
+func (c *Client) TokenizeBytes(ctx context.Context, kind TokenType, value []byte) (Token, error) {
+	defer zero(value)
+	body := make([]byte, 0, len(value)+len(kind)+32)
+	body = append(body, `{"type":`...)
+	body = appendJSONString(body, []byte(kind))
+	body = append(body, `,"value":`...)
+	body = appendJSONString(body, value)
+	body = append(body, '}')
+	defer zero(body)
+
+	var token Token
+	if err := c.postRaw(ctx, c.vaultURL, body, &token); err != nil {
+		return Token{}, err
+	}
+	if token.Token == "" {
+		return Token{}, errors.New("payments: no token in response")
+	}
+	return token, nil
+}
+
+// TokenizeCard tokenizes the given card number and security code, returning
+// the card to be used in payments
+// Both number and code are zeroed before returning, see TokenizeBytes
+func (c *Client) TokenizeCard(ctx context.Context, number, code []byte, expiry Expiry) (*Card, error) {
+	defer zero(code)
+	numberToken, err := c.TokenizeBytes(ctx, CardNumber, number)
+	if err != nil {
+		return nil, err
+	}
+	codeToken, err := c.TokenizeBytes(ctx, CardCode, code)
+	if err != nil {
+		return nil, err
+	}
+	return &Card{Number: numberToken, Code: codeToken, Expiry: expiry}, nil
+}
+
+// zero overwrites the given buffer with zeros
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// appendJSONString appends the given value as a JSON string to the buffer
+// without copying it into intermediate strings, which couldn't be zeroed
+func appendJSONString(buf, value []byte) []byte {
+	const hex = "0123456789abcdef"
+	buf = append(buf, '"')
+	for i := 0; i < len(value); {
+		b := value[i]
+		switch {
+		case b == '"' || b == '\\':
+			buf = append(buf, '\\', b)
+		case b < 0x20:
+			buf = append(buf, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xf])
+		case b < utf8.RuneSelf:
+			buf = append(buf, b)
+		default:
+			r, size := utf8.DecodeRune(value[i:])
+			if r == utf8.RuneError && size == 1 {
+				buf = append(buf, "\ufffd"...)
+			} else {
+				buf = append(buf, value[i:i+size]...)
+			}
+			i += size
+			continue
+		}
+		i++
+	}
+	return append(buf, '"')
+}
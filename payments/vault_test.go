@@ -0,0 +1,46 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenizeCard(t *testing.T) {
+	var values []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Type  TokenType `json:"type"`
+			Value string    `json:"value"`
+		}
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&body))
+		values = append(values, body.Value)
+		w.Write([]byte(`{"token":"tok-` + string(body.Type) + `"}`))
+	}))
+	defer server.Close()
+	client := New(WithURLs(server.URL, server.URL))
+
+	number := []byte("4100000000000000")
+	code := []byte("111")
+	card, err := client.TokenizeCard(context.Background(), number, code, Expiry{Month: 12, Year: 2030})
+	assert.Nil(t, err)
+	assert.Equal(t, &Card{Number: Token{"tok-pcn"}, Code: Token{"tok-pcsc"}, Expiry: Expiry{Month: 12, Year: 2030}}, card)
+	assert.Equal(t, []string{"4100000000000000", "111"}, values)
+	assert.Equal(t, make([]byte, 16), number)
+	assert.Equal(t, make([]byte, 3), code)
+}
+
+func TestAppendJSONString(t *testing.T) {
+	for _, value := range []string{"", "4100000000000000", `a"b\c`, "tab\tnew\nline\x00", "æøå €", "\xff"} {
+		var decoded string
+		assert.Nil(t, json.Unmarshal(appendJSONString(nil, []byte(value)), &decoded))
+		expected, _ := json.Marshal(value)
+		var expectedDecoded string
+		json.Unmarshal(expected, &expectedDecoded)
+		assert.Equal(t, expectedDecoded, decoded, value)
+	}
+}
@@ -0,0 +1,395 @@
+// Package provision converges the merchants of an app to a declared
+// configuration, creating apps and merchants, linking apps and inviting
+// users as needed, for platforms onboarding many merchants programmatically
+//
+// Like Terraform, a plan of the actions is computed by diffing the
+// configuration against the API first, and can be reviewed before applying it
+package provision
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	paylike "github.com/paylike/go-api"
+)
+
+// Config describes the desired state of the merchants of the app
+type Config struct {
+	Apps      []string // names of apps to create, unless linked to a merchant already, each linked to a configured merchant
+	Merchants []MerchantConfig
+	Prune     bool // revoke apps and users of configured merchants missing from the configuration
+}
+
+// MerchantConfig describes the desired state of a merchant, identified by its
+// name among the merchants of the app
+type MerchantConfig struct {
+	paylike.MerchantCreateDTO          // used to create the merchant, email and descriptor are kept up to date
+	Apps                      []string // IDs of existing apps or names of apps of Config.Apps to link
+	Users                     []string // emails of users to invite
+}
+
+// ActionKind describes what an action does
+type ActionKind string
+
+// Possible action kinds
+const (
+	CreateApp      ActionKind = "create app"
+	CreateMerchant ActionKind = "create merchant"
+	UpdateMerchant ActionKind = "update merchant"
+	LinkApp        ActionKind = "link app"
+	UnlinkApp      ActionKind = "unlink app"
+	InviteUser     ActionKind = "invite user"
+	RevokeUser     ActionKind = "revoke user"
+)
+
+// Action describes a change made to converge to the configuration
+type Action struct {
+	Kind     ActionKind
+	Merchant string                     // name of the merchant, if any
+	App      string                     // ID or name of the app, if any
+	Email    string                     // email of the user, if any
+	UserID   paylike.UserID             // ID of the revoked user
+	Update   *paylike.MerchantUpdateDTO // changes of an updated merchant
+}
+
+// String describes the action, e.g. "link app shop-app to Shop"
+func (a Action) String() string {
+	switch a.Kind {
+	case CreateApp:
+		return fmt.Sprintf("%s %s", a.Kind, a.App)
+	case LinkApp:
+		return fmt.Sprintf("%s %s to %s", a.Kind, a.App, a.Merchant)
+	case UnlinkApp:
+		return fmt.Sprintf("%s %s from %s", a.Kind, a.App, a.Merchant)
+	case InviteUser:
+		return fmt.Sprintf("%s %s to %s", a.Kind, a.Email, a.Merchant)
+	case RevokeUser:
+		return fmt.Sprintf("%s %s from %s", a.Kind, a.Email, a.Merchant)
+	}
+	return fmt.Sprintf("%s %s", a.Kind, a.Merchant)
+}
+
+// API is the part of the Paylike API used to provision, implemented by
+// paylike.Client
+type API interface {
+	FetchApp(opts ...paylike.CallOption) (*paylike.Identity, error)
+	CreateAppWithName(name string, opts ...paylike.CallOption) (*paylike.App, error)
+	FetchAllMerchants(appID paylike.AppID, p paylike.Pagination, opts ...paylike.CallOption) ([]*paylike.Merchant, error)
+	CreateMerchant(dto paylike.MerchantCreateDTO, opts ...paylike.CallOption) (*paylike.Merchant, error)
+	UpdateMerchant(id paylike.MerchantID, dto paylike.MerchantUpdateDTO, opts ...paylike.CallOption) error
+	FetchAllAppsToMerchant(merchantID paylike.MerchantID, p paylike.Pagination, opts ...paylike.CallOption) ([]*paylike.App, error)
+	AddAppToMerchant(merchantID paylike.MerchantID, appID paylike.AppID, opts ...paylike.CallOption) error
+	RevokeAppFromMerchant(merchantID paylike.MerchantID, appID paylike.AppID, opts ...paylike.CallOption) error
+	FetchAllUsersToMerchant(merchantID paylike.MerchantID, p paylike.Pagination, opts ...paylike.CallOption) ([]*paylike.User, error)
+	InviteUserToMerchant(merchantID paylike.MerchantID, email string, opts ...paylike.CallOption) (*paylike.InviteUserToMerchantResponse, error)
+	RevokeUserFromMerchant(merchantID paylike.MerchantID, userID paylike.UserID, opts ...paylike.CallOption) error
+}
+
+// Plan describes the actions converging to a configuration
+type Plan struct {
+	Actions   []Action
+	config    map[string]MerchantConfig
+	merchants map[string]paylike.MerchantID // IDs of the existing merchants by name
+	apps      map[string]paylike.AppID      // IDs of the existing apps of Config.Apps by name
+}
+
+// Result describes the applied actions of a plan
+type Result struct {
+	Applied   []Action
+	Apps      map[string]*paylike.App       // created apps by name, along with their keys
+	Merchants map[string]paylike.MerchantID // IDs of the configured merchants by name
+}
+
+// NewPlan computes the actions converging the merchants of the app to the
+// given configuration, without changing anything
+func NewPlan(ctx context.Context, api API, config Config) (*Plan, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	opt := paylike.WithContext(ctx)
+	identity, err := api.FetchApp(opt)
+	if err != nil {
+		return nil, err
+	}
+	if identity == nil {
+		return nil, errors.New("provision: no app identity in response")
+	}
+	existing, err := api.FetchAllMerchants(identity.ID, paylike.Pagination{}, opt)
+	if err != nil {
+		return nil, err
+	}
+	byName := map[string]*paylike.Merchant{}
+	for _, merchant := range existing {
+		byName[merchant.Name] = merchant
+	}
+
+	plan := &Plan{config: map[string]MerchantConfig{}, merchants: map[string]paylike.MerchantID{}, apps: map[string]paylike.AppID{}}
+	linked := map[string][]*paylike.App{}
+	users := map[string][]*paylike.User{}
+	for _, mc := range config.Merchants {
+		plan.config[mc.Name] = mc
+		merchant, ok := byName[mc.Name]
+		if !ok {
+			continue
+		}
+		plan.merchants[mc.Name] = merchant.ID
+		if linked[mc.Name], err = api.FetchAllAppsToMerchant(merchant.ID, paylike.Pagination{}, opt); err != nil {
+			return nil, err
+		}
+		if users[mc.Name], err = api.FetchAllUsersToMerchant(merchant.ID, paylike.Pagination{}, opt); err != nil {
+			return nil, err
+		}
+		plan.resolveApps(linked[mc.Name], config.Apps)
+	}
+	// apps are only found through the merchants they are linked to, so look
+	// further among the other merchants for apps not linked to configured ones
+	for _, merchant := range existing {
+		if len(plan.apps) == len(config.Apps) {
+			break
+		}
+		if _, ok := plan.config[merchant.Name]; ok {
+			continue
+		}
+		apps, err := api.FetchAllAppsToMerchant(merchant.ID, paylike.Pagination{}, opt)
+		if err != nil {
+			return nil, err
+		}
+		plan.resolveApps(apps, config.Apps)
+	}
+
+	for _, name := range config.Apps {
+		if _, ok := plan.apps[name]; !ok {
+			plan.Actions = append(plan.Actions, Action{Kind: CreateApp, App: name})
+		}
+	}
+	for _, mc := range config.Merchants {
+		merchant, ok := byName[mc.Name]
+		if !ok {
+			plan.Actions = append(plan.Actions, Action{Kind: CreateMerchant, Merchant: mc.Name})
+			for _, app := range mc.Apps {
+				plan.Actions = append(plan.Actions, Action{Kind: LinkApp, Merchant: mc.Name, App: app})
+			}
+			for _, email := range mc.Users {
+				plan.Actions = append(plan.Actions, Action{Kind: InviteUser, Merchant: mc.Name, Email: email})
+			}
+			continue
+		}
+		if update := mergeUpdate(merchant, mc); update != nil {
+			plan.Actions = append(plan.Actions, Action{Kind: UpdateMerchant, Merchant: mc.Name, Update: update})
+		}
+		plan.diffApps(mc, identity.ID, linked[mc.Name], config.Prune)
+		plan.diffUsers(mc, users[mc.Name], config.Prune)
+	}
+	return plan, nil
+}
+
+// resolveApps remembers the IDs of the given apps named in the configuration
+func (p *Plan) resolveApps(apps []*paylike.App, names []string) {
+	for _, app := range apps {
+		for _, name := range names {
+			if app.Name == name {
+				p.apps[name] = app.ID
+			}
+		}
+	}
+}
+
+// diffApps plans the links of apps to an existing merchant
+func (p *Plan) diffApps(mc MerchantConfig, self paylike.AppID, linked []*paylike.App, prune bool) {
+	wanted := map[paylike.AppID]bool{}
+	for _, app := range mc.Apps {
+		id, ok := p.apps[app]
+		if !ok {
+			if p.createsApp(app) {
+				p.Actions = append(p.Actions, Action{Kind: LinkApp, Merchant: mc.Name, App: app})
+				continue
+			}
+			id = paylike.AppID(app)
+		}
+		wanted[id] = true
+		if !containsApp(linked, id) {
+			p.Actions = append(p.Actions, Action{Kind: LinkApp, Merchant: mc.Name, App: app})
+		}
+	}
+	if !prune {
+		return
+	}
+	for _, app := range linked {
+		if !wanted[app.ID] && app.ID != self {
+			p.Actions = append(p.Actions, Action{Kind: UnlinkApp, Merchant: mc.Name, App: string(app.ID)})
+		}
+	}
+}
+
+// diffUsers plans the invitations of users to an existing merchant
+func (p *Plan) diffUsers(mc MerchantConfig, users []*paylike.User, prune bool) {
+	wanted := map[string]bool{}
+	for _, email := range mc.Users {
+		wanted[strings.ToLower(email)] = true
+		if !containsUser(users, email) {
+			p.Actions = append(p.Actions, Action{Kind: InviteUser, Merchant: mc.Name, Email: email})
+		}
+	}
+	if !prune {
+		return
+	}
+	for _, user := range users {
+		if !wanted[strings.ToLower(user.Email)] {
+			p.Actions = append(p.Actions, Action{Kind: RevokeUser, Merchant: mc.Name, Email: user.Email, UserID: user.ID})
+		}
+	}
+}
+
+// createsApp returns whether the plan creates the app of the given name
+func (p *Plan) createsApp(app string) bool {
+	for _, action := range p.Actions {
+		if action.Kind == CreateApp && action.App == app {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply performs the actions of the plan in order, stopping at the first
+// failure, in which case the result holds the actions applied so far
+func (p *Plan) Apply(ctx context.Context, api API) (*Result, error) {
+	opt := paylike.WithContext(ctx)
+	result := &Result{Apps: map[string]*paylike.App{}, Merchants: map[string]paylike.MerchantID{}}
+	apps := map[string]paylike.AppID{}
+	for name, id := range p.apps {
+		apps[name] = id
+	}
+	for name, id := range p.merchants {
+		result.Merchants[name] = id
+	}
+	appID := func(app string) paylike.AppID {
+		if id, ok := apps[app]; ok {
+			return id
+		}
+		return paylike.AppID(app)
+	}
+	for _, action := range p.Actions {
+		merchantID := result.Merchants[action.Merchant]
+		var err error
+		switch action.Kind {
+		case CreateApp:
+			var app *paylike.App
+			if app, err = api.CreateAppWithName(action.App, opt); err == nil && app == nil {
+				err = errors.New("no app in response")
+			}
+			if err == nil {
+				apps[action.App] = app.ID
+				result.Apps[action.App] = app
+			}
+		case CreateMerchant:
+			var merchant *paylike.Merchant
+			if merchant, err = api.CreateMerchant(p.config[action.Merchant].MerchantCreateDTO, opt); err == nil && merchant == nil {
+				err = errors.New("no merchant in response")
+			}
+			if err == nil {
+				result.Merchants[action.Merchant] = merchant.ID
+			}
+		case UpdateMerchant:
+			err = api.UpdateMerchant(merchantID, *action.Update, opt)
+		case LinkApp:
+			err = api.AddAppToMerchant(merchantID, appID(action.App), opt)
+		case UnlinkApp:
+			err = api.RevokeAppFromMerchant(merchantID, appID(action.App), opt)
+		case InviteUser:
+			_, err = api.InviteUserToMerchant(merchantID, action.Email, opt)
+		case RevokeUser:
+			err = api.RevokeUserFromMerchant(merchantID, action.UserID, opt)
+		}
+		if err != nil {
+			return result, fmt.Errorf("provision: %s: %w", action, err)
+		}
+		result.Applied = append(result.Applied, action)
+	}
+	return result, nil
+}
+
+// Apply converges the merchants of the app to the given configuration,
+// see NewPlan and Plan.Apply
+func Apply(ctx context.Context, api API, config Config) (*Result, error) {
+	plan, err := NewPlan(ctx, api, config)
+	if err != nil {
+		return nil, err
+	}
+	return plan.Apply(ctx, api)
+}
+
+// validate checks whether the configuration identifies its merchants and
+// apps, and links every app to a merchant: apps cannot be listed, so one
+// created but linked to no merchant would be created again by the next plan
+func (c Config) validate() error {
+	names := map[string]bool{}
+	for _, app := range c.Apps {
+		if app == "" || names["app "+app] {
+			return errors.New("provision: apps require unique names")
+		}
+		names["app "+app] = true
+	}
+	for _, mc := range c.Merchants {
+		if mc.Name == "" || names[mc.Name] {
+			return errors.New("provision: merchants require unique names")
+		}
+		names[mc.Name] = true
+	}
+	for _, app := range c.Apps {
+		if !c.links(app) {
+			return fmt.Errorf("provision: app %s is not linked to any merchant", app)
+		}
+	}
+	return nil
+}
+
+// links reports whether a configured merchant links the app of the given name
+func (c Config) links(app string) bool {
+	for _, mc := range c.Merchants {
+		for _, name := range mc.Apps {
+			if name == app {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// mergeUpdate returns the update bringing the email and descriptor of the
+// merchant up to date, if any
+func mergeUpdate(merchant *paylike.Merchant, mc MerchantConfig) *paylike.MerchantUpdateDTO {
+	var update paylike.MerchantUpdateDTO
+	if mc.Email != "" && !strings.EqualFold(mc.Email, merchant.Email) {
+		update.Email = mc.Email
+	}
+	if mc.Descriptor != "" && mc.Descriptor != merchant.Descriptor {
+		update.Descriptor = mc.Descriptor
+	}
+	if update == (paylike.MerchantUpdateDTO{}) {
+		return nil
+	}
+	return &update
+}
+
+// containsApp reports whether the given apps include the app with the given ID
+func containsApp(apps []*paylike.App, id paylike.AppID) bool {
+	for _, app := range apps {
+		if app.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// containsUser reports whether the given users include one with the given
+// email, compared case-insensitively as emails are
+func containsUser(users []*paylike.User, email string) bool {
+	for _, user := range users {
+		if strings.EqualFold(user.Email, email) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,239 @@
+package provision
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	paylike "github.com/paylike/go-api"
+	"github.com/stretchr/testify/assert"
+)
+
+// the client is the API provisioning in production
+var _ API = paylike.Client{}
+
+// fakeAPI keeps merchants, their apps and users in memory
+type fakeAPI struct {
+	merchants []*paylike.Merchant
+	apps      map[paylike.MerchantID][]*paylike.App
+	users     map[paylike.MerchantID][]*paylike.User
+	failOn    string
+	ids       int
+	anonymous bool // FetchApp responds without an identity
+	empty     bool // creations respond without the created app or merchant
+}
+
+func newFakeAPI() *fakeAPI {
+	return &fakeAPI{apps: map[paylike.MerchantID][]*paylike.App{}, users: map[paylike.MerchantID][]*paylike.User{}}
+}
+
+func (f *fakeAPI) id(prefix string) string {
+	f.ids++
+	return fmt.Sprintf("%s%d", prefix, f.ids)
+}
+
+func (f *fakeAPI) FetchApp(opts ...paylike.CallOption) (*paylike.Identity, error) {
+	if f.anonymous {
+		return nil, nil
+	}
+	return &paylike.Identity{ID: "platform"}, nil
+}
+
+func (f *fakeAPI) CreateAppWithName(name string, opts ...paylike.CallOption) (*paylike.App, error) {
+	if f.empty {
+		return nil, nil
+	}
+	return &paylike.App{ID: paylike.AppID(f.id("app")), Name: name, Key: "secret"}, nil
+}
+
+func (f *fakeAPI) FetchAllMerchants(appID paylike.AppID, p paylike.Pagination, opts ...paylike.CallOption) ([]*paylike.Merchant, error) {
+	return f.merchants, nil
+}
+
+func (f *fakeAPI) CreateMerchant(dto paylike.MerchantCreateDTO, opts ...paylike.CallOption) (*paylike.Merchant, error) {
+	if f.failOn == dto.Name {
+		return nil, errors.New("rejected")
+	}
+	if f.empty {
+		return nil, nil
+	}
+	merchant := &paylike.Merchant{ID: paylike.MerchantID(f.id("m")), Name: dto.Name, Email: dto.Email, Descriptor: dto.Descriptor}
+	f.merchants = append(f.merchants, merchant)
+	f.apps[merchant.ID] = []*paylike.App{{ID: "platform"}}
+	return merchant, nil
+}
+
+func (f *fakeAPI) UpdateMerchant(id paylike.MerchantID, dto paylike.MerchantUpdateDTO, opts ...paylike.CallOption) error {
+	for _, merchant := range f.merchants {
+		if merchant.ID == id {
+			if dto.Email != "" {
+				merchant.Email = dto.Email
+			}
+			if dto.Descriptor != "" {
+				merchant.Descriptor = dto.Descriptor
+			}
+		}
+	}
+	return nil
+}
+
+func (f *fakeAPI) FetchAllAppsToMerchant(merchantID paylike.MerchantID, p paylike.Pagination, opts ...paylike.CallOption) ([]*paylike.App, error) {
+	return f.apps[merchantID], nil
+}
+
+func (f *fakeAPI) AddAppToMerchant(merchantID paylike.MerchantID, appID paylike.AppID, opts ...paylike.CallOption) error {
+	name := ""
+	if appID == "app1" {
+		name = "shop-app"
+	}
+	f.apps[merchantID] = append(f.apps[merchantID], &paylike.App{ID: appID, Name: name})
+	return nil
+}
+
+func (f *fakeAPI) RevokeAppFromMerchant(merchantID paylike.MerchantID, appID paylike.AppID, opts ...paylike.CallOption) error {
+	var apps []*paylike.App
+	for _, app := range f.apps[merchantID] {
+		if app.ID != appID {
+			apps = append(apps, app)
+		}
+	}
+	f.apps[merchantID] = apps
+	return nil
+}
+
+func (f *fakeAPI) FetchAllUsersToMerchant(merchantID paylike.MerchantID, p paylike.Pagination, opts ...paylike.CallOption) ([]*paylike.User, error) {
+	return f.users[merchantID], nil
+}
+
+func (f *fakeAPI) InviteUserToMerchant(merchantID paylike.MerchantID, email string, opts ...paylike.CallOption) (*paylike.InviteUserToMerchantResponse, error) {
+	f.users[merchantID] = append(f.users[merchantID], &paylike.User{ID: paylike.UserID(f.id("u")), Email: email, Pending: true})
+	return &paylike.InviteUserToMerchantResponse{}, nil
+}
+
+func (f *fakeAPI) RevokeUserFromMerchant(merchantID paylike.MerchantID, userID paylike.UserID, opts ...paylike.CallOption) error {
+	var users []*paylike.User
+	for _, user := range f.users[merchantID] {
+		if user.ID != userID {
+			users = append(users, user)
+		}
+	}
+	f.users[merchantID] = users
+	return nil
+}
+
+func testConfig() Config {
+	return Config{
+		Apps: []string{"shop-app"},
+		Merchants: []MerchantConfig{{
+			MerchantCreateDTO: paylike.MerchantCreateDTO{Name: "Shop", Currency: "EUR", Email: "shop@example.com", Descriptor: "SHOP"},
+			Apps:              []string{"shop-app"},
+			Users:             []string{"owner@example.com"},
+		}},
+	}
+}
+
+func TestApply(t *testing.T) {
+	api := newFakeAPI()
+	result, err := Apply(context.Background(), api, testConfig())
+	assert.Nil(t, err)
+	var applied []string
+	for _, action := range result.Applied {
+		applied = append(applied, action.String())
+	}
+	assert.Equal(t, []string{
+		"create app shop-app",
+		"create merchant Shop",
+		"link app shop-app to Shop",
+		"invite user owner@example.com to Shop",
+	}, applied)
+	assert.Equal(t, "secret", result.Apps["shop-app"].Key)
+	assert.Equal(t, paylike.MerchantID("m2"), result.Merchants["Shop"])
+
+	plan, err := NewPlan(context.Background(), api, testConfig())
+	assert.Nil(t, err)
+	assert.Empty(t, plan.Actions)
+}
+
+func TestPlanDrift(t *testing.T) {
+	api := newFakeAPI()
+	_, err := Apply(context.Background(), api, testConfig())
+	assert.Nil(t, err)
+	api.users["m2"] = append(api.users["m2"], &paylike.User{ID: "u9", Email: "former@example.com"})
+	api.apps["m2"] = append(api.apps["m2"], &paylike.App{ID: "other"})
+
+	config := testConfig()
+	config.Merchants[0].Email = "billing@example.com"
+	config.Merchants[0].Users = []string{"OWNER@example.com", "new@example.com"}
+	plan, err := NewPlan(context.Background(), api, config)
+	assert.Nil(t, err)
+	assert.Equal(t, []Action{
+		{Kind: UpdateMerchant, Merchant: "Shop", Update: &paylike.MerchantUpdateDTO{Email: "billing@example.com"}},
+		{Kind: InviteUser, Merchant: "Shop", Email: "new@example.com"},
+	}, plan.Actions)
+
+	config.Prune = true
+	plan, err = NewPlan(context.Background(), api, config)
+	assert.Nil(t, err)
+	assert.Equal(t, []Action{
+		{Kind: UpdateMerchant, Merchant: "Shop", Update: &paylike.MerchantUpdateDTO{Email: "billing@example.com"}},
+		{Kind: UnlinkApp, Merchant: "Shop", App: "other"},
+		{Kind: InviteUser, Merchant: "Shop", Email: "new@example.com"},
+		{Kind: RevokeUser, Merchant: "Shop", Email: "former@example.com", UserID: "u9"},
+	}, plan.Actions)
+	_, err = plan.Apply(context.Background(), api)
+	assert.Nil(t, err)
+	plan, err = NewPlan(context.Background(), api, config)
+	assert.Nil(t, err)
+	assert.Empty(t, plan.Actions)
+}
+
+func TestPlanFindsAppsOfOtherMerchants(t *testing.T) {
+	api := newFakeAPI()
+	_, err := Apply(context.Background(), api, testConfig())
+	assert.Nil(t, err)
+
+	config := testConfig()
+	config.Merchants[0].Name = "Outlet"
+	plan, err := NewPlan(context.Background(), api, config)
+	assert.Nil(t, err)
+	assert.Equal(t, []Action{
+		{Kind: CreateMerchant, Merchant: "Outlet"},
+		{Kind: LinkApp, Merchant: "Outlet", App: "shop-app"},
+		{Kind: InviteUser, Merchant: "Outlet", Email: "owner@example.com"},
+	}, plan.Actions)
+	result, err := plan.Apply(context.Background(), api)
+	assert.Nil(t, err)
+	assert.Equal(t, api.apps["m2"][1].ID, api.apps[result.Merchants["Outlet"]][1].ID)
+
+	config.Apps = append(config.Apps, "orphan-app")
+	_, err = NewPlan(context.Background(), api, config)
+	assert.EqualError(t, err, "provision: app orphan-app is not linked to any merchant")
+}
+
+func TestApplyFailure(t *testing.T) {
+	api := newFakeAPI()
+	api.failOn = "Shop"
+	result, err := Apply(context.Background(), api, testConfig())
+	assert.EqualError(t, err, "provision: create merchant Shop: rejected")
+	assert.Equal(t, []Action{{Kind: CreateApp, App: "shop-app"}}, result.Applied)
+
+	config := testConfig()
+	config.Merchants = append(config.Merchants, config.Merchants[0])
+	_, err = Apply(context.Background(), api, config)
+	assert.EqualError(t, err, "provision: merchants require unique names")
+
+	api = newFakeAPI()
+	api.empty = true
+	_, err = Apply(context.Background(), api, testConfig())
+	assert.EqualError(t, err, "provision: create app shop-app: no app in response")
+	config = testConfig()
+	config.Apps = nil
+	config.Merchants[0].Apps = nil
+	_, err = Apply(context.Background(), api, config)
+	assert.EqualError(t, err, "provision: create merchant Shop: no merchant in response")
+
+	api.anonymous = true
+	_, err = NewPlan(context.Background(), api, testConfig())
+	assert.EqualError(t, err, "provision: no app identity in response")
+}
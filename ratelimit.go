@@ -0,0 +1,104 @@
+package paylike
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// RateLimiter is consulted before each request and blocks until the request
+// fits into the API budget of the given key, or the context is done
+// Implementations backed by a shared store let multiple processes using the
+// same app key respect one budget collectively
+type RateLimiter interface {
+	Wait(ctx context.Context, key string) error
+}
+
+// WithRateLimiter makes the client wait for the given limiter before each request
+func WithRateLimiter(limiter RateLimiter) Option {
+	return func(c *Client) {
+		c.rateLimiter = limiter
+	}
+}
+
+// RedisEvaler evaluates a Lua script against Redis and returns its result
+// For go-redis this can be implemented with a RedisEvalFunc wrapping
+// rdb.Eval(ctx, script, keys, args...).Result()
+type RedisEvaler interface {
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+}
+
+// RedisEvalFunc is an adapter to allow the use of ordinary functions as RedisEvaler
+type RedisEvalFunc func(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+
+// Eval calls f(ctx, script, keys, args...)
+func (f RedisEvalFunc) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	return f(ctx, script, keys, args...)
+}
+
+// redisRateLimitScript counts requests in a fixed window and returns the
+// milliseconds to wait if the window is exhausted, 0 otherwise
+const redisRateLimitScript = `
+local current = redis.call("INCR", KEYS[1])
+if current == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+if current > tonumber(ARGV[1]) then
+	local ttl = redis.call("PTTL", KEYS[1])
+	if ttl < 1 then
+		ttl = 1
+	end
+	return ttl
+end
+return 0
+`
+
+// RedisRateLimiter is a RateLimiter sharing a fixed-window budget between
+// all processes connected to the same Redis
+type RedisRateLimiter struct {
+	redis    RedisEvaler
+	prefix   string
+	limit    int
+	interval time.Duration
+}
+
+// NewRedisRateLimiter creates a limiter allowing at most limit requests
+// per interval for each API key
+func NewRedisRateLimiter(redis RedisEvaler, limit int, interval time.Duration) *RedisRateLimiter {
+	return &RedisRateLimiter{redis: redis, prefix: "paylike:ratelimit", limit: limit, interval: interval}
+}
+
+// SetPrefix changes the prefix of the Redis keys used by the limiter
+func (r *RedisRateLimiter) SetPrefix(prefix string) *RedisRateLimiter {
+	r.prefix = prefix
+	return r
+}
+
+// Wait blocks until the request fits into the budget of the given key
+// The key is hashed, so no secrets are stored in Redis
+func (r *RedisRateLimiter) Wait(ctx context.Context, key string) error {
+	sum := sha256.Sum256([]byte(key))
+	redisKey := fmt.Sprintf("%s:%s", r.prefix, hex.EncodeToString(sum[:8]))
+	for {
+		result, err := r.redis.Eval(ctx, redisRateLimitScript, []string{redisKey}, r.limit, r.interval.Milliseconds())
+		if err != nil {
+			return err
+		}
+		wait, ok := result.(int64)
+		if !ok {
+			return fmt.Errorf("paylike: unexpected rate limit script result %v", result)
+		}
+		if wait <= 0 {
+			return nil
+		}
+		timer := time.NewTimer(time.Duration(wait) * time.Millisecond)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
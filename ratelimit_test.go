@@ -0,0 +1,67 @@
+package paylike
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRedis mimics the rate limit script on top of an in-memory counter
+type fakeRedis struct {
+	mu      sync.Mutex
+	keys    []string
+	counts  map[string]int64
+	expires map[string]time.Time
+}
+
+func (f *fakeRedis) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := keys[0]
+	f.keys = append(f.keys, key)
+	if time.Now().After(f.expires[key]) {
+		f.counts[key] = 0
+	}
+	f.counts[key]++
+	if f.counts[key] == 1 {
+		f.expires[key] = time.Now().Add(time.Duration(args[1].(int64)) * time.Millisecond)
+	}
+	if f.counts[key] > int64(args[0].(int)) {
+		return int64(time.Until(f.expires[key])/time.Millisecond) + 1, nil
+	}
+	return int64(0), nil
+}
+
+func TestRedisRateLimiter(t *testing.T) {
+	redis := &fakeRedis{counts: map[string]int64{}, expires: map[string]time.Time{}}
+	limiter := NewRedisRateLimiter(redis, 2, 50*time.Millisecond)
+	requests := 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}), WithRateLimiter(limiter))
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := client.FetchApp()
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 3, requests)
+	assert.True(t, time.Since(start) >= 40*time.Millisecond)
+	assert.True(t, strings.HasPrefix(redis.keys[0], "paylike:ratelimit:"))
+	assert.NotContains(t, redis.keys[0], TestKey)
+}
+
+func TestRedisRateLimiterContext(t *testing.T) {
+	redis := RedisEvalFunc(func(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+		return int64(time.Hour / time.Millisecond), nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := NewRedisRateLimiter(redis, 1, time.Second).Wait(ctx, TestKey)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
@@ -0,0 +1,83 @@
+package paylike
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// rawFields keeps the raw JSON of response fields a model doesn't capture
+type rawFields struct {
+	fields map[string]json.RawMessage
+}
+
+// Raw returns the raw JSON of the given response field if the model doesn't
+// capture it (yet), letting new API fields be read before the SDK models them
+func (r rawFields) Raw(name string) json.RawMessage {
+	return r.fields[name]
+}
+
+// unmarshalWithRaw decodes the given JSON object into the given value and keeps
+// the fields the value has no counterpart for
+func unmarshalWithRaw(b []byte, value interface{}, raw *rawFields) error {
+	if err := json.Unmarshal(b, value); err != nil {
+		return err
+	}
+	var all map[string]json.RawMessage
+	if json.Unmarshal(b, &all) != nil {
+		return nil
+	}
+	known := jsonFields(reflect.TypeOf(value).Elem())
+	for k := range all {
+		if _, ok := known[strings.ToLower(k)]; ok {
+			delete(all, k)
+		}
+	}
+	raw.fields = nil
+	if len(all) > 0 {
+		raw.fields = all
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes the app and keeps the fields it doesn't capture
+func (a *App) UnmarshalJSON(b []byte) error {
+	type app App
+	return unmarshalWithRaw(b, (*app)(a), &a.rawFields)
+}
+
+// UnmarshalJSON decodes the identity and keeps the fields it doesn't capture
+func (i *Identity) UnmarshalJSON(b []byte) error {
+	type identity Identity
+	return unmarshalWithRaw(b, (*identity)(i), &i.rawFields)
+}
+
+// UnmarshalJSON decodes the merchant and keeps the fields it doesn't capture
+func (m *Merchant) UnmarshalJSON(b []byte) error {
+	type merchant Merchant
+	return unmarshalWithRaw(b, (*merchant)(m), &m.rawFields)
+}
+
+// UnmarshalJSON decodes the user and keeps the fields it doesn't capture
+func (u *User) UnmarshalJSON(b []byte) error {
+	type user User
+	return unmarshalWithRaw(b, (*user)(u), &u.rawFields)
+}
+
+// UnmarshalJSON decodes the line and keeps the fields it doesn't capture
+func (l *Line) UnmarshalJSON(b []byte) error {
+	type line Line
+	return unmarshalWithRaw(b, (*line)(l), &l.rawFields)
+}
+
+// UnmarshalJSON decodes the transaction and keeps the fields it doesn't capture
+func (t *Transaction) UnmarshalJSON(b []byte) error {
+	type transaction Transaction
+	return unmarshalWithRaw(b, (*transaction)(t), &t.rawFields)
+}
+
+// UnmarshalJSON decodes the card and keeps the fields it doesn't capture
+func (c *Card) UnmarshalJSON(b []byte) error {
+	type card Card
+	return unmarshalWithRaw(b, (*card)(c), &c.rawFields)
+}
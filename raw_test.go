@@ -0,0 +1,51 @@
+package paylike
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRawFields(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"transaction":{"id":"tx1","amount":100,"card":{"bin":"410000"},"fraud":{"score":12}}}`))
+	}))
+	transaction, err := client.FindTransaction("tx1")
+	assert.Nil(t, err)
+	assert.Equal(t, TxID("tx1"), transaction.ID)
+	assert.Equal(t, int64(100), transaction.Amount)
+	assert.Equal(t, "410000", transaction.Card.Bin)
+	assert.Equal(t, json.RawMessage(`{"score":12}`), transaction.Raw("fraud"))
+	assert.Nil(t, transaction.Raw("amount"))
+	assert.Nil(t, transaction.Raw("id"))
+}
+
+func TestRawFieldsCaseInsensitive(t *testing.T) {
+	var merchants []*Merchant
+	err := json.Unmarshal([]byte(`[{"id":"m1","balance":10,"tds":{"mode":"full"},"country":"DK"},{"id":"m2"}]`), &merchants)
+	assert.Nil(t, err)
+	assert.Equal(t, MerchantID("m1"), merchants[0].ID)
+	assert.Equal(t, int64(10), merchants[0].Balance)
+	assert.Equal(t, "full", merchants[0].TDS.Mode)
+	assert.Equal(t, json.RawMessage(`"DK"`), merchants[0].Raw("country"))
+	assert.Nil(t, merchants[0].Raw("balance"))
+	assert.Nil(t, merchants[1].Raw("country"))
+
+	var card Card
+	assert.Nil(t, json.Unmarshal([]byte(`{"id":"c1","last4":"0000","merchantId":"m1","notes":"vip","label":"work"}`), &card))
+	assert.Equal(t, CardToken("c1"), card.ID)
+	assert.Equal(t, "0000", card.Last4)
+	assert.Equal(t, "vip", card.Notes)
+	assert.Nil(t, card.Raw("notes"))
+	assert.Equal(t, json.RawMessage(`"work"`), card.Raw("label"))
+}
+
+func TestRawFieldsEncoding(t *testing.T) {
+	var user User
+	assert.Nil(t, json.Unmarshal([]byte(`{"id":"u1","email":"a@example.com","nickname":"A"}`), &user))
+	b, err := json.Marshal(user)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"id":"u1","email":"a@example.com","name":"","created":"","role":"","pending":false}`, string(b))
+}
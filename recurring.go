@@ -0,0 +1,184 @@
+package paylike
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// DeclineKind classifies why a charge failed
+type DeclineKind int
+
+// Possible decline kinds
+const (
+	DeclineNone DeclineKind = iota // the charge succeeded
+	DeclineSoft                    // temporary, the charge may succeed later (e.g. insufficient funds)
+	DeclineHard                    // permanent, the card should not be charged again (e.g. expired or stolen)
+)
+
+// String returns the name of the decline kind
+func (k DeclineKind) String() string {
+	switch k {
+	case DeclineNone:
+		return "none"
+	case DeclineSoft:
+		return "soft"
+	case DeclineHard:
+		return "hard"
+	}
+	return "unknown"
+}
+
+// DeclineCodes maps the decline codes reported by the API to their kind
+// Codes missing here are treated as hard declines
+var DeclineCodes = map[string]DeclineKind{
+	"05": DeclineSoft, // do not honor
+	"51": DeclineSoft, // insufficient funds
+	"61": DeclineSoft, // exceeds withdrawal limit
+	"65": DeclineSoft, // exceeds withdrawal frequency
+	"91": DeclineSoft, // issuer unavailable
+	"96": DeclineSoft, // system malfunction
+	"14": DeclineHard, // invalid card number
+	"41": DeclineHard, // lost card
+	"43": DeclineHard, // stolen card
+	"54": DeclineHard, // expired card
+	"57": DeclineHard, // transaction not permitted to cardholder
+	"62": DeclineHard, // restricted card
+}
+
+// ClassifyDecline classifies the error of a failed charge, treating
+// temporary failures of the API as soft and unknown rejections as hard
+func ClassifyDecline(err error) DeclineKind {
+	if err == nil {
+		return DeclineNone
+	}
+	if kind, ok := DeclineCodes[DeclineCode(err)]; ok {
+		return kind
+	}
+	if IsRetryable(err) {
+		return DeclineSoft
+	}
+	return DeclineHard
+}
+
+// DeclineCode returns the decline code reported by the API for a failed
+// charge or capture, or an empty string if there is none
+// Processor declines reported as field errors are recognized by their code
+// being one of DeclineCodes
+func DeclineCode(err error) string {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return ""
+	}
+	if apiErr.Code != "" {
+		return apiErr.Code
+	}
+	for _, detail := range apiErr.Details {
+		if _, ok := DeclineCodes[detail.Code]; ok {
+			return detail.Code
+		}
+	}
+	return ""
+}
+
+// ErrorCode describes an error or decline code reported by the API, decoded
+// from either a number or a string
+type ErrorCode string
+
+// UnmarshalJSON decodes a numeric or string code
+func (c *ErrorCode) UnmarshalJSON(b []byte) error {
+	*c = ErrorCode(rawCode(b))
+	return nil
+}
+
+// Decline classifies why the transaction failed by its error code, treating
+// unknown codes as hard declines and returning DeclineNone if it has not failed
+func (t Transaction) Decline() DeclineKind {
+	if !t.Error {
+		return DeclineNone
+	}
+	if kind, ok := DeclineCodes[string(t.ErrorCode)]; ok {
+		return kind
+	}
+	return DeclineHard
+}
+
+// isSoftDecline reports whether the error reports a soft decline code,
+// meaning the request has been processed and declined without any effect
+func isSoftDecline(err error) bool {
+	code := DeclineCode(err)
+	return code != "" && DeclineCodes[code] == DeclineSoft
+}
+
+// WithSoftDeclineRetry retries captures declined with a soft decline code
+// (see DeclineCodes), e.g. insufficient funds, waiting the given spacing
+// between attempts, up to the given maximum number of attempts per capture
+// Declined captures have no effect, so retrying them cannot capture twice;
+// temporary failures of the API are left to the retry policy of the client
+func WithSoftDeclineRetry(maxAttempts int, spacing time.Duration) Option {
+	return func(c *Client) {
+		c.softDeclineAttempts = maxAttempts
+		c.softDeclineSpacing = spacing
+	}
+}
+
+// retrySoftDeclines performs the given call, repeating it as configured by
+// WithSoftDeclineRetry while it is declined with a soft decline code
+func retrySoftDeclines[T any](c Client, call func() (*T, error)) (*T, error) {
+	ctx := c.call.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for attempt := 1; ; attempt++ {
+		value, err := call()
+		if err == nil || attempt >= c.softDeclineAttempts || !isSoftDecline(err) {
+			return value, err
+		}
+		if err := sleep(ctx, c.softDeclineSpacing); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// IsCardExpired reports whether a charge failed because the card has expired
+func IsCardExpired(err error) bool {
+	return DeclineCode(err) == "54"
+}
+
+// RecurringCharge describes a follow-up charge of a subscription, based on
+// either a saved card or a previous transaction of the customer
+type RecurringCharge struct {
+	CardID        CardToken              // required if no TransactionID is present
+	TransactionID TxID                   // required if no CardID is present
+	Currency      string                 // required, three letter ISO
+	Amount        int64                  // required, amount in minor units
+	Descriptor    string                 // optional, will fallback to merchant descriptor
+	Custom        map[string]interface{} // optional, any custom data
+}
+
+// ErrRecurringSource is returned when a recurring charge does not name
+// exactly one of a card or a previous transaction to charge
+var ErrRecurringSource = errors.New("paylike: recurring charge requires either a card or a previous transaction")
+
+// ChargeRecurring creates the follow-up transaction of a subscription,
+// flagged as recurring and as a merchant-initiated charge of a stored credential
+// Use ClassifyDecline on the returned error to decide whether to retry later
+// https://github.com/paylike/api-docs#using-a-previous-transaction
+func (c Client) ChargeRecurring(merchantID MerchantID, charge RecurringCharge, opts ...CallOption) (*TransactionID, error) {
+	if (charge.CardID == "") == (charge.TransactionID == "") {
+		return nil, ErrRecurringSource
+	}
+	return c.CreateTransaction(merchantID, TransactionDTO{
+		CardID:        charge.CardID,
+		TransactionID: charge.TransactionID,
+		Currency:      charge.Currency,
+		Amount:        charge.Amount,
+		Descriptor:    charge.Descriptor,
+		Custom:        charge.Custom,
+		Recurring:     true,
+		StoredCredential: &StoredCredential{
+			Initiator: InitiatorMerchant,
+			Reason:    CredentialRecurring,
+		},
+	}, opts...)
+}
@@ -0,0 +1,106 @@
+package paylike
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChargeRecurring(t *testing.T) {
+	var body string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{"transaction":{"id":"tx2"}}`))
+	}))
+	transaction, err := client.ChargeRecurring(TestMerchant, RecurringCharge{CardID: "c1", Currency: "EUR", Amount: 999})
+	assert.Nil(t, err)
+	assert.Equal(t, TxID("tx2"), transaction.ID)
+	assert.Equal(t, `{"cardId":"c1","currency":"EUR","amount":999,"recurring":true,"storedCredential":{"initiator":"merchant","reason":"recurring"}}`, body)
+
+	_, err = client.ChargeRecurring(TestMerchant, RecurringCharge{Currency: "EUR", Amount: 999})
+	assert.Equal(t, ErrRecurringSource, err)
+	_, err = client.ChargeRecurring(TestMerchant, RecurringCharge{CardID: "c1", TransactionID: "tx1"})
+	assert.Equal(t, ErrRecurringSource, err)
+}
+
+func TestClassifyDecline(t *testing.T) {
+	assert.Equal(t, DeclineNone, ClassifyDecline(nil))
+	assert.Equal(t, DeclineSoft, ClassifyDecline(&APIError{StatusCode: 400, Code: "51"}))
+	assert.Equal(t, DeclineHard, ClassifyDecline(&APIError{StatusCode: 400, Code: "54"}))
+	assert.Equal(t, DeclineHard, ClassifyDecline(&APIError{StatusCode: 400, Code: "unknown"}))
+	assert.Equal(t, DeclineSoft, ClassifyDecline(&APIError{StatusCode: 503}))
+	assert.Equal(t, DeclineHard, ClassifyDecline(errors.New("paylike: other")))
+	assert.Equal(t, "soft", DeclineSoft.String())
+}
+
+func TestDeclineCode(t *testing.T) {
+	assert.Equal(t, "", DeclineCode(nil))
+	assert.Equal(t, "51", DeclineCode(fmt.Errorf("charge: %w", &APIError{StatusCode: 400, Code: "51"})))
+	assert.True(t, IsCardExpired(&APIError{StatusCode: 400, Code: "54"}))
+	assert.False(t, IsCardExpired(&APIError{StatusCode: 400, Code: "51"}))
+}
+
+func TestSoftDeclineRetry(t *testing.T) {
+	var codes []string
+	attempts := 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if len(codes) > 0 {
+			code := codes[0]
+			codes = codes[1:]
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`[{"field":"amount","message":"declined","code":` + code + `}]`))
+			return
+		}
+		w.Write([]byte(`{"transaction":{"id":"tx1","capturedAmount":100}}`))
+	}), WithSoftDeclineRetry(3, 0))
+
+	codes = []string{"51", `"51"`}
+	transaction, err := client.CaptureTransaction("tx1", TransactionTrailDTO{Amount: 100})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(100), transaction.CapturedAmount)
+	assert.Equal(t, 3, attempts)
+
+	attempts = 0
+	codes = []string{"51", "51", "51", "51"}
+	_, err = client.CaptureTransaction("tx1", TransactionTrailDTO{Amount: 100})
+	assert.Equal(t, "51", DeclineCode(err))
+	assert.Equal(t, DeclineSoft, ClassifyDecline(err))
+	assert.Equal(t, 3, attempts)
+
+	attempts = 0
+	codes = []string{"54"}
+	_, err = client.CaptureTransaction("tx1", TransactionTrailDTO{Amount: 100})
+	assert.True(t, IsCardExpired(err))
+	assert.Equal(t, 1, attempts)
+
+	attempts = 0
+	codes = []string{"51"}
+	client.softDeclineAttempts = 0
+	_, err = client.CaptureTransaction("tx1", TransactionTrailDTO{Amount: 100})
+	assert.Equal(t, DeclineSoft, ClassifyDecline(err))
+	assert.Equal(t, 1, attempts)
+}
+
+func TestTransactionDecline(t *testing.T) {
+	var transaction Transaction
+	err := json.Unmarshal([]byte(`{"id":"tx1","error":true,"errorCode":51,"errorMessage":"Insufficient funds"}`), &transaction)
+	assert.Nil(t, err)
+	assert.Equal(t, ErrorCode("51"), transaction.ErrorCode)
+	assert.Equal(t, "Insufficient funds", transaction.ErrorMessage)
+	assert.Equal(t, DeclineSoft, transaction.Decline())
+	assert.Nil(t, transaction.Raw("errorCode"))
+
+	err = json.Unmarshal([]byte(`{"id":"tx2","error":true,"errorCode":"54"}`), &transaction)
+	assert.Nil(t, err)
+	assert.Equal(t, DeclineHard, transaction.Decline())
+	transaction.ErrorCode = "unknown"
+	assert.Equal(t, DeclineHard, transaction.Decline())
+	assert.Equal(t, DeclineNone, Transaction{Successful: true}.Decline())
+}
@@ -0,0 +1,62 @@
+package paylike
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RedactKey masks the given API key for logs and error messages, keeping
+// only its last four characters when it is long enough to stay secret
+func RedactKey(key string) string {
+	if key == "" {
+		return ""
+	}
+	if len(key) < 16 {
+		return "****"
+	}
+	return "****" + key[len(key)-4:]
+}
+
+// redactKeys masks every occurrence of the given keys in the given string
+func redactKeys(s string, keys ...string) string {
+	for _, key := range keys {
+		if key != "" {
+			s = strings.ReplaceAll(s, key, RedactKey(key))
+		}
+	}
+	return s
+}
+
+// String describes the client with its key masked
+func (c Client) String() string {
+	return fmt.Sprintf("paylike.Client{Key:%s BaseURL:%s}", RedactKey(c.Key), c.baseAPI)
+}
+
+// GoString describes the client with its key masked
+func (c Client) GoString() string {
+	return c.String()
+}
+
+// String formats the app with its key masked
+func (a App) String() string {
+	type app App
+	a.Key = RedactKey(a.Key)
+	return fmt.Sprintf("%+v", app(a))
+}
+
+// GoString formats the app with its key masked
+func (a App) GoString() string {
+	return "paylike.App" + a.String()
+}
+
+// String formats the merchant with its key masked
+func (m Merchant) String() string {
+	type merchant Merchant
+	m.Key = RedactKey(m.Key)
+	return fmt.Sprintf("%+v", merchant(m))
+}
+
+// GoString formats the merchant with its key masked
+func (m Merchant) GoString() string {
+	return "paylike.Merchant" + m.String()
+}
@@ -0,0 +1,35 @@
+//go:build go1.21
+
+package paylike
+
+import (
+	"log/slog"
+)
+
+// LogValue logs the client with its key masked
+func (c Client) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("key", RedactKey(c.Key)),
+		slog.String("baseURL", c.baseAPI),
+	)
+}
+
+// LogValue logs the app with its key masked
+func (a App) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", string(a.ID)),
+		slog.String("name", a.Name),
+		slog.String("key", RedactKey(a.Key)),
+	)
+}
+
+// LogValue logs the merchant with its key masked
+func (m Merchant) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", string(m.ID)),
+		slog.String("name", m.Name),
+		slog.String("currency", m.Currency),
+		slog.Bool("test", m.Test),
+		slog.String("key", RedactKey(m.Key)),
+	)
+}
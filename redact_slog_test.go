@@ -0,0 +1,21 @@
+//go:build go1.21
+
+package paylike
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogValueMasksKeys(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	logger.Info("configured", "client", NewClient(TestKey), "app", App{ID: "app1", Key: TestKey}, "merchant", Merchant{ID: "m1", Key: TestKey})
+	assert.False(t, strings.Contains(buf.String(), TestKey))
+	assert.True(t, strings.Contains(buf.String(), "app.id=app1"))
+	assert.True(t, strings.Contains(buf.String(), "merchant.key=****2923"))
+}
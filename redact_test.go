@@ -0,0 +1,40 @@
+package paylike
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactKey(t *testing.T) {
+	assert.Equal(t, "****2923", RedactKey(TestKey))
+	assert.Equal(t, "****", RedactKey("short"))
+	assert.Equal(t, "", RedactKey(""))
+}
+
+func TestFormattingMasksKeys(t *testing.T) {
+	client := NewClient(TestKey)
+	app := App{ID: "app1", Name: "shop", Key: TestKey}
+	merchant := Merchant{ID: "m1", Key: TestKey}
+	for _, format := range []string{"%v", "%+v", "%#v", "%s"} {
+		for _, value := range []interface{}{client, *client, app, &app, merchant, &merchant} {
+			formatted := fmt.Sprintf(format, value)
+			assert.False(t, strings.Contains(formatted, TestKey), formatted)
+			assert.True(t, strings.Contains(formatted, "****2923"), formatted)
+		}
+	}
+	assert.True(t, strings.Contains(fmt.Sprint(app), "Name:shop"))
+}
+
+func TestRequestDumpMasksKey(t *testing.T) {
+	client := newErrorTestClient(t, http.StatusBadRequest, `{}`, WithRequestDump())
+	_, err := client.CreateTransaction(TestMerchant, TransactionDTO{Currency: "EUR", Amount: 1, Custom: map[string]interface{}{"note": TestKey}})
+	var reqErr *RequestError
+	assert.True(t, errors.As(err, &reqErr))
+	assert.False(t, strings.Contains(err.Error(), TestKey))
+	assert.True(t, strings.Contains(reqErr.Request.Body, "****2923"))
+}
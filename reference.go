@@ -0,0 +1,34 @@
+package paylike
+
+import (
+	"net/url"
+)
+
+// Country describes a country merchants can be registered in
+type Country struct {
+	Code string `json:"code"` // ISO 3166 code (e.g. DK)
+	Name string `json:"name"`
+}
+
+// Currency describes a currency transactions can be made in
+type Currency struct {
+	Code     string `json:"code"` // three letter ISO 4217 code (e.g. DKK)
+	Name     string `json:"name"`
+	Exponent int    `json:"exponent"` // number of digits of the minor unit
+}
+
+// FetchCountries fetches the countries merchants can be registered in, e.g.
+// to populate onboarding forms
+// Where the API doesn't expose the reference data, an *APIError with status
+// 404 is returned so callers can fall back to a bundled list
+func (c Client) FetchCountries(opts ...CallOption) ([]*Country, error) {
+	return listQuery[Country](c.with(opts), OpFetchCountries, url.Values{})
+}
+
+// FetchCurrencies fetches the currencies transactions can be made in, along
+// with the exponent of their minor unit
+// Where the API doesn't expose the reference data, an *APIError with status
+// 404 is returned so callers can fall back to a bundled list
+func (c Client) FetchCurrencies(opts ...CallOption) ([]*Currency, error) {
+	return listQuery[Currency](c.with(opts), OpFetchCurrencies, url.Values{})
+}
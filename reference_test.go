@@ -0,0 +1,37 @@
+package paylike
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchReferenceData(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/countries":
+			w.Write([]byte(`[{"code":"DK","name":"Denmark"},{"code":"HU","name":"Hungary"}]`))
+		case "/currencies":
+			w.Write([]byte(`[{"code":"DKK","name":"Danish krone","exponent":2},{"code":"JPY","name":"Japanese yen","exponent":0}]`))
+		}
+	}))
+
+	countries, err := client.FetchCountries()
+	assert.Nil(t, err)
+	assert.Equal(t, []*Country{{Code: "DK", Name: "Denmark"}, {Code: "HU", Name: "Hungary"}}, countries)
+
+	currencies, err := client.FetchCurrencies()
+	assert.Nil(t, err)
+	assert.Len(t, currencies, 2)
+	assert.Equal(t, 0, currencies[1].Exponent)
+}
+
+func TestFetchReferenceDataUnavailable(t *testing.T) {
+	client := newErrorTestClient(t, http.StatusNotFound, `{"message":"not found"}`)
+	_, err := client.FetchCurrencies()
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+}
@@ -0,0 +1,37 @@
+package paylike
+
+import (
+	"context"
+	"fmt"
+)
+
+// RequestIDHeader is the default header carrying the request ID
+const RequestIDHeader = "X-Request-Id"
+
+// WithRequestIDFromContext reads the request ID of the originating user
+// request from the context of each call under the given key, sends it in
+// the given header (RequestIDHeader if empty) and reports it in RequestMetrics
+// The value must be a string or a fmt.Stringer
+func WithRequestIDFromContext(key interface{}, header string) Option {
+	return func(c *Client) {
+		if header == "" {
+			header = RequestIDHeader
+		}
+		c.requestIDKey = key
+		c.requestIDHeader = header
+	}
+}
+
+// requestID returns the request ID found in the given context, if any
+func (c Client) requestID(ctx context.Context) string {
+	if c.requestIDKey == nil {
+		return ""
+	}
+	switch id := ctx.Value(c.requestIDKey).(type) {
+	case string:
+		return id
+	case fmt.Stringer:
+		return id.String()
+	}
+	return ""
+}
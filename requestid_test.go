@@ -0,0 +1,31 @@
+package paylike
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type requestIDKey struct{}
+
+func TestRequestIDFromContext(t *testing.T) {
+	var headers []string
+	var metrics []RequestMetrics
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Get(RequestIDHeader))
+	}), WithRequestIDFromContext(requestIDKey{}, ""), WithMetricsHook(func(m RequestMetrics) {
+		metrics = append(metrics, m)
+	}))
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-1")
+	_, err := client.FindTransaction("tx1", WithContext(ctx))
+	assert.Nil(t, err)
+	_, err = client.FindTransaction("tx1")
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{"req-1", ""}, headers)
+	assert.Equal(t, "req-1", metrics[0].RequestID)
+	assert.Equal(t, "", metrics[1].RequestID)
+}
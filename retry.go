@@ -0,0 +1,101 @@
+package paylike
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy decides whether and when a failed request is retried
+// resp is nil if no response has been received
+type RetryPolicy interface {
+	ShouldRetry(attempt int, err error, resp *http.Response) bool
+	NextDelay(attempt int) time.Duration
+}
+
+// BackoffPolicy is the built-in RetryPolicy retrying retryable errors
+// with exponentially growing, jittered delays
+type BackoffPolicy struct {
+	MaxAttempts int           // including the first attempt
+	BaseDelay   time.Duration // delay before the first retry
+	MaxDelay    time.Duration // upper bound of the delays
+}
+
+// ShouldRetry retries errors deemed retryable by IsRetryable until the
+// maximum number of attempts is reached
+func (p BackoffPolicy) ShouldRetry(attempt int, err error, resp *http.Response) bool {
+	return attempt < p.MaxAttempts && IsRetryable(err)
+}
+
+// NextDelay doubles the base delay on every attempt, up to the maximum delay,
+// and randomizes the upper half of it to avoid synchronized retries
+func (p BackoffPolicy) NextDelay(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// WithRetries enables the built-in retry policy with the given maximum
+// number of attempts per call
+func WithRetries(maxAttempts int) Option {
+	return WithRetryPolicy(BackoffPolicy{
+		MaxAttempts: maxAttempts,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+	})
+}
+
+// WithRetryPolicy makes the client retry failed requests according to the
+// given policy
+// Operations that are not idempotent (e.g. captures) are only retried when
+// the request has certainly not been processed, regardless of the policy
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// shouldRetry consults the retry policy about a failed attempt of the given operation
+func (c Client) shouldRetry(ctx context.Context, op Operation, attempt int, err error, resp *http.Response) bool {
+	if c.retryPolicy == nil || ctx.Err() != nil {
+		return false
+	}
+	if !op.Idempotent && !notProcessed(err, resp) {
+		return false
+	}
+	return c.retryPolicy.ShouldRetry(attempt, err, resp)
+}
+
+// notProcessed returns whether a failed request has certainly not been
+// processed by the API, either because the connection could not be
+// established or because the API refused to handle it
+func notProcessed(err error, resp *http.Response) bool {
+	if resp != nil {
+		return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// sleep waits for the given duration or until the context is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
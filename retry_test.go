@@ -0,0 +1,102 @@
+package paylike
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingPolicy retries up to a given number of attempts without delay
+type countingPolicy struct {
+	max      int
+	consults int
+}
+
+func (p *countingPolicy) ShouldRetry(attempt int, err error, resp *http.Response) bool {
+	p.consults++
+	return attempt < p.max
+}
+
+func (p *countingPolicy) NextDelay(attempt int) time.Duration {
+	return 0
+}
+
+func newStatusSequenceClient(t *testing.T, statuses []int, bodies *[]string, opts ...Option) *Client {
+	i := 0
+	return newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		*bodies = append(*bodies, string(b))
+		status := statuses[len(statuses)-1]
+		if i < len(statuses) {
+			status = statuses[i]
+		}
+		i++
+		w.WriteHeader(status)
+		w.Write([]byte(`{"transaction":{"id":"tx1"},"merchant":{"id":"m1"}}`))
+	}), opts...)
+}
+
+func TestRetryPolicy(t *testing.T) {
+	var bodies []string
+	policy := &countingPolicy{max: 3}
+	client := newStatusSequenceClient(t, []int{502, 500, 200}, &bodies, WithRetryPolicy(policy))
+	merchant, err := client.GetMerchant("m1")
+	assert.Nil(t, err)
+	assert.Equal(t, MerchantID("m1"), merchant.ID)
+	assert.Len(t, bodies, 3)
+	assert.Equal(t, 2, policy.consults)
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	var bodies []string
+	client := newStatusSequenceClient(t, []int{500}, &bodies, WithRetryPolicy(&countingPolicy{max: 2}))
+	_, err := client.GetMerchant("m1")
+	assert.True(t, IsServerError(err))
+	assert.Len(t, bodies, 2)
+}
+
+func TestRetryNonIdempotent(t *testing.T) {
+	var bodies []string
+	client := newStatusSequenceClient(t, []int{500, 200}, &bodies, WithRetryPolicy(&countingPolicy{max: 3}))
+	_, err := client.CaptureTransaction("tx1", TransactionTrailDTO{Amount: 1})
+	assert.True(t, IsServerError(err))
+	assert.Len(t, bodies, 1)
+
+	bodies = nil
+	client = newStatusSequenceClient(t, []int{429, 200}, &bodies, WithRetryPolicy(&countingPolicy{max: 3}))
+	_, err = client.CaptureTransaction("tx1", TransactionTrailDTO{Amount: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{`{"amount":1}`, `{"amount":1}`}, bodies)
+}
+
+func TestRetriesDisabledByDefault(t *testing.T) {
+	var bodies []string
+	client := newStatusSequenceClient(t, []int{503, 200}, &bodies)
+	_, err := client.GetMerchant("m1")
+	assert.True(t, IsRetryable(err))
+	assert.Len(t, bodies, 1)
+}
+
+func TestBackoffPolicy(t *testing.T) {
+	policy := BackoffPolicy{MaxAttempts: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+	assert.True(t, policy.ShouldRetry(1, &APIError{StatusCode: 503}, nil))
+	assert.False(t, policy.ShouldRetry(3, &APIError{StatusCode: 503}, nil))
+	assert.False(t, policy.ShouldRetry(1, &APIError{StatusCode: 400}, nil))
+	for attempt, max := range map[int]time.Duration{1: 100, 2: 200, 3: 300, 10: 300} {
+		delay := policy.NextDelay(attempt)
+		assert.True(t, delay >= max*time.Millisecond/2)
+		assert.True(t, delay <= max*time.Millisecond)
+	}
+}
+
+func TestWithRetries(t *testing.T) {
+	var bodies []string
+	client := newStatusSequenceClient(t, []int{503, 200}, &bodies, WithRetries(2))
+	assert.Equal(t, BackoffPolicy{MaxAttempts: 2, BaseDelay: 100 * time.Millisecond, MaxDelay: 5 * time.Second}, client.retryPolicy)
+	_, err := client.FindTransaction("tx1")
+	assert.Nil(t, err)
+	assert.Len(t, bodies, 2)
+}
@@ -0,0 +1,306 @@
+package paylike
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// SagaStatus describes where a given saga is in its lifecycle
+type SagaStatus string
+
+// Possible saga statuses
+const (
+	SagaPending     SagaStatus = "pending"
+	SagaCompleted   SagaStatus = "completed"
+	SagaCompensated SagaStatus = "compensated"
+	SagaFailed      SagaStatus = "failed"
+)
+
+// SagaState describes the persisted progress of a given saga
+type SagaState struct {
+	ID             string     `json:"id"`
+	Status         SagaStatus `json:"status"`
+	TransactionID  TxID       `json:"transactionId,omitempty"`
+	CapturedAmount int64      `json:"capturedAmount,omitempty"`
+	Started        string     `json:"started,omitempty"` // step whose action began but whose outcome is not persisted yet
+	Completed      []string   `json:"completed,omitempty"`
+	Compensated    []string   `json:"compensated,omitempty"`
+	FailedStep     string     `json:"failedStep,omitempty"`
+	Error          string     `json:"error,omitempty"`
+}
+
+// SagaStore persists saga states so an interrupted saga can be resumed
+// Load should return nil, nil if no state exists for the given ID
+type SagaStore interface {
+	Load(ctx context.Context, id string) (*SagaState, error)
+	Save(ctx context.Context, state *SagaState) error
+}
+
+// SagaStep describes a single step of a saga with its optional compensation
+// Reconcile is called on resume for a step whose action was interrupted
+// before its outcome was persisted, and reports whether the action took
+// effect, updating the state accordingly; steps without Reconcile are run
+// again, so their Action must be safe to repeat
+type SagaStep struct {
+	Name       string
+	Action     func(ctx context.Context, state *SagaState) error
+	Compensate func(ctx context.Context, state *SagaState) error
+	Reconcile  func(ctx context.Context, state *SagaState) (bool, error)
+}
+
+// SagaError describes a failed saga along with the compensation errors, if any
+type SagaError struct {
+	Step               string
+	Err                error
+	CompensationErrors map[string]error
+}
+
+// Error returns the error message of the failed step
+func (e *SagaError) Error() string {
+	msg := fmt.Sprintf("paylike: saga step %q failed: %v", e.Step, e.Err)
+	if len(e.CompensationErrors) > 0 {
+		msg += fmt.Sprintf(" (%d compensation(s) failed)", len(e.CompensationErrors))
+	}
+	return msg
+}
+
+// Unwrap returns the error of the failed step
+func (e *SagaError) Unwrap() error {
+	return e.Err
+}
+
+// ErrSagaFinished is returned when running a saga that has already
+// completed or has been compensated
+var ErrSagaFinished = errors.New("paylike: saga already finished")
+
+// Saga chains steps and compensates the completed ones in reverse
+// order when a step fails
+type Saga struct {
+	store SagaStore
+	steps []SagaStep
+}
+
+// NewSaga creates a new saga persisting its state in the given store
+func NewSaga(store SagaStore, steps ...SagaStep) *Saga {
+	return &Saga{store: store, steps: steps}
+}
+
+// Then appends a new step to the saga
+func (s *Saga) Then(step SagaStep) *Saga {
+	s.steps = append(s.steps, step)
+	return s
+}
+
+// Run executes the saga identified by the given ID, resuming from the
+// last persisted step if the saga has been started before
+// Each step is marked as started before its action runs, so an action
+// interrupted before its outcome was persisted is reconciled on resume (see
+// SagaStep) rather than blindly run again
+// A failed saga with compensations left to do has them retried
+func (s *Saga) Run(ctx context.Context, id string) (*SagaState, error) {
+	state, err := s.store.Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		state = &SagaState{ID: id, Status: SagaPending}
+	}
+	if len(state.Completed) > len(s.steps) {
+		return state, fmt.Errorf("paylike: saga %s has completed %d steps but only %d are defined", id, len(state.Completed), len(s.steps))
+	}
+	switch state.Status {
+	case SagaPending:
+	case SagaFailed:
+		return state, s.compensate(ctx, state, state.FailedStep, errors.New(state.Error))
+	default:
+		return state, ErrSagaFinished
+	}
+	for _, step := range s.steps[len(state.Completed):] {
+		if err := ctx.Err(); err != nil {
+			return state, err
+		}
+		done := false
+		if state.Started != "" {
+			if state.Started != step.Name {
+				return state, fmt.Errorf("paylike: saga %s has started step %q but %q is next", id, state.Started, step.Name)
+			}
+			if step.Reconcile != nil {
+				if done, err = step.Reconcile(ctx, state); err != nil {
+					return state, err
+				}
+			}
+		}
+		if !done {
+			state.Started = step.Name
+			if err := s.store.Save(ctx, state); err != nil {
+				return state, err
+			}
+			if err := step.Action(ctx, state); err != nil {
+				state.Started = ""
+				return state, s.compensate(ctx, state, step.Name, err)
+			}
+		}
+		state.Started = ""
+		state.Completed = append(state.Completed, step.Name)
+		if err := s.store.Save(ctx, state); err != nil {
+			return state, err
+		}
+	}
+	state.Status = SagaCompleted
+	return state, s.store.Save(ctx, state)
+}
+
+// compensate runs the compensations of all completed steps not compensated
+// yet in reverse order and persists the outcome
+func (s *Saga) compensate(ctx context.Context, state *SagaState, failed string, cause error) error {
+	sagaErr := &SagaError{Step: failed, Err: cause}
+	state.FailedStep = failed
+	state.Error = cause.Error()
+	compensated := map[string]bool{}
+	for _, name := range state.Compensated {
+		compensated[name] = true
+	}
+	for i := len(state.Completed) - 1; i >= 0; i-- {
+		step := s.steps[i]
+		if step.Compensate == nil || compensated[step.Name] {
+			continue
+		}
+		if err := step.Compensate(ctx, state); err != nil {
+			if sagaErr.CompensationErrors == nil {
+				sagaErr.CompensationErrors = map[string]error{}
+			}
+			sagaErr.CompensationErrors[step.Name] = err
+			continue
+		}
+		state.Compensated = append(state.Compensated, step.Name)
+	}
+	state.Status = SagaCompensated
+	if len(sagaErr.CompensationErrors) > 0 {
+		state.Status = SagaFailed
+	}
+	if err := s.store.Save(ctx, state); err != nil {
+		return err
+	}
+	return sagaErr
+}
+
+// sagaCustomKey is the custom field PaymentSaga tags its transaction with
+const sagaCustomKey = "sagaId"
+
+// PaymentSaga creates a saga that authorizes a new transaction and captures
+// the given amount, voiding or refunding the money on failure
+// Further steps (e.g. order fulfilment) can be chained with Then
+// The transaction is tagged with the saga ID in its "sagaId" custom field, so
+// an interrupted authorization is looked up rather than made again on resume;
+// an interrupted capture is checked against the captured amount
+func (c Client) PaymentSaga(store SagaStore, merchantID MerchantID, dto TransactionDTO, capture TransactionTrailDTO) *Saga {
+	return NewSaga(store,
+		SagaStep{
+			Name: "authorize",
+			Action: func(ctx context.Context, state *SagaState) error {
+				tagged := dto
+				tagged.Custom = make(map[string]interface{}, len(dto.Custom)+1)
+				for key, value := range dto.Custom {
+					tagged.Custom[key] = value
+				}
+				tagged.Custom[sagaCustomKey] = state.ID
+				transaction, err := c.CreateTransaction(merchantID, tagged, WithContext(ctx))
+				if err != nil {
+					return err
+				}
+				if transaction == nil {
+					return errors.New("paylike: no transaction in response")
+				}
+				state.TransactionID = transaction.ID
+				return nil
+			},
+			Compensate: func(ctx context.Context, state *SagaState) error {
+				remaining := dto.Amount - state.CapturedAmount
+				if remaining <= 0 {
+					return nil
+				}
+				_, err := c.VoidTransaction(state.TransactionID, TransactionTrailDTO{Amount: remaining}, WithContext(ctx))
+				return err
+			},
+			Reconcile: func(ctx context.Context, state *SagaState) (bool, error) {
+				found, err := c.FindTransactionsByCustom(merchantID, sagaCustomKey, state.ID, CustomSearch{FirstOnly: true}, WithContext(ctx))
+				if err != nil {
+					return false, err
+				}
+				if len(found) == 0 {
+					return false, nil
+				}
+				state.TransactionID = found[0].ID
+				return true, nil
+			},
+		},
+		SagaStep{
+			Name: "capture",
+			Action: func(ctx context.Context, state *SagaState) error {
+				if _, err := c.CaptureTransaction(state.TransactionID, capture, WithContext(ctx)); err != nil {
+					return err
+				}
+				state.CapturedAmount = capture.Amount
+				return nil
+			},
+			Compensate: func(ctx context.Context, state *SagaState) error {
+				_, err := c.RefundTransaction(state.TransactionID, TransactionTrailDTO{Amount: state.CapturedAmount}, WithContext(ctx))
+				return err
+			},
+			Reconcile: func(ctx context.Context, state *SagaState) (bool, error) {
+				transaction, err := c.with([]CallOption{WithContext(ctx)}).fetchFreshTransaction(state.TransactionID)
+				if err != nil {
+					return false, err
+				}
+				if transaction == nil {
+					return false, ErrNoTransaction
+				}
+				switch {
+				case transaction.CapturedAmount >= capture.Amount:
+					state.CapturedAmount = capture.Amount
+					return true, nil
+				case transaction.CapturedAmount == 0:
+					return false, nil
+				}
+				return false, fmt.Errorf("paylike: saga %s found %d of %d captured on transaction %s", state.ID, transaction.CapturedAmount, capture.Amount, state.TransactionID)
+			},
+		},
+	)
+}
+
+// MemorySagaStore is an in-memory SagaStore, mostly useful for testing
+type MemorySagaStore struct {
+	mu     sync.Mutex
+	states map[string]SagaState
+}
+
+// NewMemorySagaStore creates a new empty in-memory saga store
+func NewMemorySagaStore() *MemorySagaStore {
+	return &MemorySagaStore{states: map[string]SagaState{}}
+}
+
+// Load returns a copy of the stored state for the given ID
+func (m *MemorySagaStore) Load(ctx context.Context, id string) (*SagaState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state, ok := m.states[id]
+	if !ok {
+		return nil, nil
+	}
+	state.Completed = append([]string(nil), state.Completed...)
+	state.Compensated = append([]string(nil), state.Compensated...)
+	return &state, nil
+}
+
+// Save stores a copy of the given state
+func (m *MemorySagaStore) Save(ctx context.Context, state *SagaState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored := *state
+	stored.Completed = append([]string(nil), state.Completed...)
+	stored.Compensated = append([]string(nil), state.Compensated...)
+	m.states[state.ID] = stored
+	return nil
+}
@@ -0,0 +1,204 @@
+package paylike
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newSagaTestClient(t *testing.T, calls *[]string, failCapture bool) *Client {
+	return newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls = append(*calls, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/merchants/" + TestMerchant + "/transactions":
+			w.Write([]byte(`{"transaction":{"id":"tx1"}}`))
+		case "/transactions/tx1/captures":
+			if failCapture {
+				hj, _ := w.(http.Hijacker)
+				conn, _, _ := hj.Hijack()
+				conn.Close()
+				return
+			}
+			fallthrough
+		default:
+			w.Write([]byte(`{"transaction":{"id":"tx1"}}`))
+		}
+	}))
+}
+
+func TestPaymentSaga(t *testing.T) {
+	var calls []string
+	client := newSagaTestClient(t, &calls, false)
+	store := NewMemorySagaStore()
+	saga := client.PaymentSaga(store, TestMerchant, TransactionDTO{Currency: "EUR", Amount: 200}, TransactionTrailDTO{Amount: 200})
+	state, err := saga.Run(context.Background(), "order-1")
+	assert.Nil(t, err)
+	assert.Equal(t, SagaCompleted, state.Status)
+	assert.Equal(t, TxID("tx1"), state.TransactionID)
+	assert.Equal(t, []string{"authorize", "capture"}, state.Completed)
+
+	_, err = saga.Run(context.Background(), "order-1")
+	assert.Equal(t, ErrSagaFinished, err)
+	assert.Len(t, calls, 2)
+}
+
+func TestPaymentSagaVoidsOnCaptureFailure(t *testing.T) {
+	var calls []string
+	client := newSagaTestClient(t, &calls, true)
+	saga := client.PaymentSaga(NewMemorySagaStore(), TestMerchant, TransactionDTO{Currency: "EUR", Amount: 200}, TransactionTrailDTO{Amount: 200})
+	state, err := saga.Run(context.Background(), "order-1")
+	var sagaErr *SagaError
+	assert.True(t, errors.As(err, &sagaErr))
+	assert.Equal(t, "capture", sagaErr.Step)
+	assert.Equal(t, SagaCompensated, state.Status)
+	assert.Equal(t, []string{"authorize"}, state.Compensated)
+	assert.Equal(t, "POST /transactions/tx1/voids", calls[len(calls)-1])
+}
+
+func TestPaymentSagaRefundsOnLaterFailure(t *testing.T) {
+	var calls []string
+	client := newSagaTestClient(t, &calls, false)
+	saga := client.PaymentSaga(NewMemorySagaStore(), TestMerchant, TransactionDTO{Currency: "EUR", Amount: 200}, TransactionTrailDTO{Amount: 150}).
+		Then(SagaStep{
+			Name: "fulfil",
+			Action: func(ctx context.Context, state *SagaState) error {
+				return errors.New("out of stock")
+			},
+		})
+	state, err := saga.Run(context.Background(), "order-1")
+	assert.NotNil(t, err)
+	assert.Equal(t, SagaCompensated, state.Status)
+	assert.Equal(t, "fulfil", state.FailedStep)
+	assert.Equal(t, []string{"capture", "authorize"}, state.Compensated)
+	assert.Equal(t, []string{
+		"POST /merchants/" + TestMerchant + "/transactions",
+		"POST /transactions/tx1/captures",
+		"POST /transactions/tx1/refunds",
+		"POST /transactions/tx1/voids",
+	}, calls)
+}
+
+func TestPaymentSagaContext(t *testing.T) {
+	var calls []string
+	client := newSagaTestClient(t, &calls, false)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	saga := client.PaymentSaga(NewMemorySagaStore(), TestMerchant, TransactionDTO{Currency: "EUR", Amount: 200}, TransactionTrailDTO{Amount: 150}).
+		Then(SagaStep{
+			Name: "fulfil",
+			Action: func(ctx context.Context, state *SagaState) error {
+				cancel()
+				return errors.New("shutting down")
+			},
+		})
+	state, err := saga.Run(ctx, "order-1")
+	var sagaErr *SagaError
+	assert.True(t, errors.As(err, &sagaErr))
+	assert.True(t, errors.Is(sagaErr.CompensationErrors["capture"], context.Canceled))
+	assert.Equal(t, SagaFailed, state.Status)
+	assert.Len(t, calls, 2)
+}
+
+func TestSagaCompletedBeyondSteps(t *testing.T) {
+	store := NewMemorySagaStore()
+	store.Save(context.Background(), &SagaState{ID: "order-1", Status: SagaPending, Completed: []string{"authorize", "capture", "fulfil"}})
+	var calls []string
+	saga := newSagaTestClient(t, &calls, false).PaymentSaga(store, TestMerchant, TransactionDTO{Currency: "EUR", Amount: 200}, TransactionTrailDTO{Amount: 200})
+	_, err := saga.Run(context.Background(), "order-1")
+	assert.Equal(t, "paylike: saga order-1 has completed 3 steps but only 2 are defined", err.Error())
+	assert.Empty(t, calls)
+}
+
+func TestPaymentSagaTagsTransaction(t *testing.T) {
+	var custom map[string]interface{}
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/merchants/"+TestMerchant+"/transactions" {
+			var dto TransactionDTO
+			json.NewDecoder(r.Body).Decode(&dto)
+			custom = dto.Custom
+		}
+		w.Write([]byte(`{"transaction":{"id":"tx1"}}`))
+	}))
+	dto := TransactionDTO{Currency: "EUR", Amount: 200, Custom: map[string]interface{}{"orderId": "o-1"}}
+	_, err := client.PaymentSaga(NewMemorySagaStore(), TestMerchant, dto, TransactionTrailDTO{Amount: 200}).Run(context.Background(), "order-1")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"orderId": "o-1", "sagaId": "order-1"}, custom)
+	assert.Equal(t, map[string]interface{}{"orderId": "o-1"}, dto.Custom)
+}
+
+func TestPaymentSagaReconcilesInterruptedSteps(t *testing.T) {
+	var calls []string
+	found := true
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/merchants/"+TestMerchant+"/transactions":
+			if found {
+				w.Write([]byte(`[{"id":"tx2","custom":{"sagaId":"other"}},{"id":"tx1","custom":{"sagaId":"order-1"}}]`))
+				return
+			}
+			w.Write([]byte(`[]`))
+		case r.Method == http.MethodGet:
+			w.Write([]byte(`{"transaction":{"id":"tx1","amount":200,"capturedAmount":200}}`))
+		default:
+			w.Write([]byte(`{"transaction":{"id":"tx1"}}`))
+		}
+	}))
+	run := func(state SagaState) *SagaState {
+		store := NewMemorySagaStore()
+		store.Save(context.Background(), &state)
+		calls = nil
+		result, err := client.PaymentSaga(store, TestMerchant, TransactionDTO{Currency: "EUR", Amount: 200}, TransactionTrailDTO{Amount: 200}).Run(context.Background(), state.ID)
+		assert.Nil(t, err)
+		return result
+	}
+
+	state := run(SagaState{ID: "order-1", Status: SagaPending, Started: "authorize"})
+	assert.Equal(t, SagaCompleted, state.Status)
+	assert.Equal(t, TxID("tx1"), state.TransactionID)
+	assert.Equal(t, []string{"GET /merchants/" + TestMerchant + "/transactions", "POST /transactions/tx1/captures"}, calls)
+
+	found = false
+	run(SagaState{ID: "order-1", Status: SagaPending, Started: "authorize"})
+	assert.Equal(t, []string{
+		"GET /merchants/" + TestMerchant + "/transactions",
+		"POST /merchants/" + TestMerchant + "/transactions",
+		"POST /transactions/tx1/captures",
+	}, calls)
+
+	state = run(SagaState{ID: "order-1", Status: SagaPending, TransactionID: "tx1", Completed: []string{"authorize"}, Started: "capture"})
+	assert.Equal(t, SagaCompleted, state.Status)
+	assert.Equal(t, int64(200), state.CapturedAmount)
+	assert.Equal(t, []string{"GET /transactions/tx1"}, calls)
+}
+
+func TestSagaResumesCompensation(t *testing.T) {
+	var calls []string
+	client := newSagaTestClient(t, &calls, false)
+	store := NewMemorySagaStore()
+	store.Save(context.Background(), &SagaState{
+		ID:             "order-1",
+		Status:         SagaFailed,
+		TransactionID:  "tx1",
+		CapturedAmount: 150,
+		Completed:      []string{"authorize", "capture"},
+		Compensated:    []string{"capture"},
+		FailedStep:     "fulfil",
+		Error:          "out of stock",
+	})
+	state, err := client.PaymentSaga(store, TestMerchant, TransactionDTO{Currency: "EUR", Amount: 200}, TransactionTrailDTO{Amount: 150}).Run(context.Background(), "order-1")
+	var sagaErr *SagaError
+	assert.True(t, errors.As(err, &sagaErr))
+	assert.Equal(t, "fulfil", sagaErr.Step)
+	assert.Empty(t, sagaErr.CompensationErrors)
+	assert.Equal(t, SagaCompensated, state.Status)
+	assert.Equal(t, []string{"capture", "authorize"}, state.Compensated)
+	assert.Equal(t, []string{"POST /transactions/tx1/voids"}, calls)
+
+	_, err = client.PaymentSaga(store, TestMerchant, TransactionDTO{Currency: "EUR", Amount: 200}, TransactionTrailDTO{Amount: 150}).Run(context.Background(), "order-1")
+	assert.Equal(t, ErrSagaFinished, err)
+}
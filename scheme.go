@@ -0,0 +1,98 @@
+package paylike
+
+// CardScheme describes the network of a card as reported by the API
+// Schemes without a constant below are kept as reported
+type CardScheme string
+
+// Known card schemes
+const (
+	SchemeVisa         CardScheme = "visa"
+	SchemeVisaElectron CardScheme = "visa-electron"
+	SchemeMasterCard   CardScheme = "mastercard"
+	SchemeMaestro      CardScheme = "maestro"
+	SchemeAmex         CardScheme = "amex"
+	SchemeDiners       CardScheme = "diners"
+	SchemeDiscover     CardScheme = "discover"
+	SchemeJCB          CardScheme = "jcb"
+	SchemeDankort      CardScheme = "dankort"
+)
+
+var schemeNames = map[CardScheme]string{
+	SchemeVisa:         "Visa",
+	SchemeVisaElectron: "Visa Electron",
+	SchemeMasterCard:   "Mastercard",
+	SchemeMaestro:      "Maestro",
+	SchemeAmex:         "American Express",
+	SchemeDiners:       "Diners Club",
+	SchemeDiscover:     "Discover",
+	SchemeJCB:          "JCB",
+	SchemeDankort:      "Dankort",
+}
+
+// Known reports whether the scheme is one of the known card schemes
+func (s CardScheme) Known() bool {
+	_, ok := schemeNames[s]
+	return ok
+}
+
+// DisplayName returns the name of the scheme suitable for display,
+// falling back to the reported value for unknown schemes
+func (s CardScheme) DisplayName() string {
+	if name, ok := schemeNames[s]; ok {
+		return name
+	}
+	return string(s)
+}
+
+// IsDebit reports whether the scheme only issues debit cards
+// Debit cards of other schemes (e.g. Visa Debit) cannot be told apart
+// by the scheme alone
+func (s CardScheme) IsDebit() bool {
+	return s == SchemeVisaElectron || s == SchemeMaestro || s == SchemeDankort
+}
+
+// binRanges maps BIN prefixes to the scheme issuing them, most specific first
+var binRanges = []struct {
+	from, to string
+	scheme   CardScheme
+}{
+	{"5019", "5019", SchemeDankort},
+	{"4026", "4026", SchemeVisaElectron},
+	{"4175", "4175", SchemeVisaElectron},
+	{"4405", "4405", SchemeVisaElectron},
+	{"4508", "4508", SchemeVisaElectron},
+	{"4844", "4844", SchemeVisaElectron},
+	{"4913", "4913", SchemeVisaElectron},
+	{"4917", "4917", SchemeVisaElectron},
+	{"4", "4", SchemeVisa},
+	{"2221", "2720", SchemeMasterCard},
+	{"51", "55", SchemeMasterCard},
+	{"34", "34", SchemeAmex},
+	{"37", "37", SchemeAmex},
+	{"300", "305", SchemeDiners},
+	{"36", "36", SchemeDiners},
+	{"38", "39", SchemeDiners},
+	{"6011", "6011", SchemeDiscover},
+	{"644", "649", SchemeDiscover},
+	{"65", "65", SchemeDiscover},
+	{"3528", "3589", SchemeJCB},
+	{"50", "50", SchemeMaestro},
+	{"56", "58", SchemeMaestro},
+	{"63", "63", SchemeMaestro},
+	{"67", "67", SchemeMaestro},
+}
+
+// SchemeFromBin guesses the scheme of a card from its BIN (the first
+// digits of the card number), returning an empty scheme if unknown
+func SchemeFromBin(bin string) CardScheme {
+	for _, r := range binRanges {
+		if len(bin) < len(r.from) {
+			continue
+		}
+		prefix := bin[:len(r.from)]
+		if prefix >= r.from && prefix <= r.to {
+			return r.scheme
+		}
+	}
+	return ""
+}
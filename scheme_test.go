@@ -0,0 +1,47 @@
+package paylike
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCardScheme(t *testing.T) {
+	var card Card
+	assert.Nil(t, json.Unmarshal([]byte(`{"id":"c1","scheme":"mastercard"}`), &card))
+	assert.Equal(t, SchemeMasterCard, card.Scheme)
+	assert.True(t, card.Scheme.Known())
+	assert.Equal(t, "Mastercard", card.Scheme.DisplayName())
+	assert.False(t, card.Scheme.IsDebit())
+	assert.True(t, SchemeMaestro.IsDebit())
+
+	assert.Nil(t, json.Unmarshal([]byte(`{"id":"c1","scheme":"unionpay"}`), &card))
+	assert.False(t, card.Scheme.Known())
+	assert.Equal(t, "unionpay", card.Scheme.DisplayName())
+}
+
+func TestSchemeFromBin(t *testing.T) {
+	for bin, expected := range map[string]CardScheme{
+		"411111": SchemeVisa,
+		"491733": SchemeVisaElectron,
+		"555555": SchemeMasterCard,
+		"222100": SchemeMasterCard,
+		"272099": SchemeMasterCard,
+		"378282": SchemeAmex,
+		"601111": SchemeDiscover,
+		"650000": SchemeDiscover,
+		"353011": SchemeJCB,
+		"676770": SchemeMaestro,
+		"580000": SchemeMaestro,
+		"630490": SchemeMaestro,
+		"622126": "",
+		"690000": "",
+		"501912": SchemeDankort,
+		"305693": SchemeDiners,
+		"900000": "",
+		"":       "",
+	} {
+		assert.Equal(t, expected, SchemeFromBin(bin), bin)
+	}
+}
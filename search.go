@@ -0,0 +1,33 @@
+package paylike
+
+import "strings"
+
+// FindMerchantsByName lists the merchants of the given app whose name
+// contains the given name, ignoring case, following the pages
+func (c Client) FindMerchantsByName(appID AppID, name string, p Pagination, opts ...CallOption) ([]*Merchant, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return c.findMerchants(appID, p, func(merchant *Merchant) bool {
+		return strings.Contains(strings.ToLower(merchant.Name), name)
+	}, opts...)
+}
+
+// FindMerchantsByEmail lists the merchants of the given app whose contact
+// email equals the given email, ignoring case, following the pages
+func (c Client) FindMerchantsByEmail(appID AppID, email string, p Pagination, opts ...CallOption) ([]*Merchant, error) {
+	email = strings.TrimSpace(email)
+	return c.findMerchants(appID, p, func(merchant *Merchant) bool {
+		return strings.EqualFold(strings.TrimSpace(merchant.Email), email)
+	}, opts...)
+}
+
+// findMerchants lists the merchants of the given app matching the given filter
+func (c Client) findMerchants(appID AppID, p Pagination, match func(*Merchant) bool, opts ...CallOption) ([]*Merchant, error) {
+	var matching []*Merchant
+	err := c.EachMerchant(appID, p, func(merchant *Merchant) error {
+		if match(merchant) {
+			matching = append(matching, merchant)
+		}
+		return nil
+	}, opts...)
+	return matching, err
+}
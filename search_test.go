@@ -0,0 +1,43 @@
+package paylike
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newMerchantSearchClient(t *testing.T, requests *int) *Client {
+	return newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if r.URL.Query().Get("before") == "" {
+			w.Write([]byte(`[{"id":"m1","name":"Coffee Shop","email":"coffee@example.com"},{"id":"m2","name":"Book Store","email":"Books@Example.com"}]`))
+			return
+		}
+		w.Write([]byte(`[{"id":"m3","name":"COFFEE ROASTERS","email":"roasters@example.com"}]`))
+	}))
+}
+
+func TestFindMerchantsByName(t *testing.T) {
+	var requests int
+	client := newMerchantSearchClient(t, &requests)
+	merchants, err := client.FindMerchantsByName("app1", " coffee", Pagination{PageSize: 2})
+	assert.Nil(t, err)
+	assert.Len(t, merchants, 2)
+	assert.Equal(t, MerchantID("m1"), merchants[0].ID)
+	assert.Equal(t, MerchantID("m3"), merchants[1].ID)
+	assert.Equal(t, 2, requests)
+}
+
+func TestFindMerchantsByEmail(t *testing.T) {
+	var requests int
+	client := newMerchantSearchClient(t, &requests)
+	merchants, err := client.FindMerchantsByEmail("app1", "books@example.com", Pagination{PageSize: 2})
+	assert.Nil(t, err)
+	assert.Len(t, merchants, 1)
+	assert.Equal(t, MerchantID("m2"), merchants[0].ID)
+
+	merchants, err = client.FindMerchantsByEmail("app1", "nobody@example.com", Pagination{PageSize: 2})
+	assert.Nil(t, err)
+	assert.Empty(t, merchants)
+}
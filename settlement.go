@@ -0,0 +1,46 @@
+package paylike
+
+import "sort"
+
+// IsPayout reports whether the line pays the balance out to the bank account
+// of the merchant, being a debit of the balance unrelated to a transaction
+func (l Line) IsPayout() bool {
+	return l.TransactionID == "" && !l.Refund && l.Amount.Amount < 0
+}
+
+// SettlementPeriod describes the lines settled by a single payout
+type SettlementPeriod struct {
+	Payout *Line        // nil for the open period after the last payout
+	Paid   int64        // amount paid out, as landing in the bank account
+	Lines  []*Line      // lines settled by the payout, oldest first, excluding the payout
+	Totals []LineTotals // totals of the lines per currency
+}
+
+// SettlementPeriods slices the given lines into the periods settled by the
+// payouts among them, oldest first, with the lines after the last payout
+// forming a last, open period (omitted if there are no such lines)
+func SettlementPeriods(lines []*Line) []SettlementPeriod {
+	sorted := append([]*Line(nil), lines...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return createdBefore(sorted[i], sorted[j])
+	})
+	var periods []SettlementPeriod
+	var current []*Line
+	for _, line := range sorted {
+		if !line.IsPayout() {
+			current = append(current, line)
+			continue
+		}
+		periods = append(periods, SettlementPeriod{
+			Payout: line,
+			Paid:   abs(line.Amount.Amount),
+			Lines:  current,
+			Totals: TotalsByCurrency(current),
+		})
+		current = nil
+	}
+	if len(current) > 0 {
+		periods = append(periods, SettlementPeriod{Lines: current, Totals: TotalsByCurrency(current)})
+	}
+	return periods
+}
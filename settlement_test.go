@@ -0,0 +1,38 @@
+package paylike
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettlementPeriods(t *testing.T) {
+	lines := []*Line{
+		{ID: "l6", Created: "2024-01-06T00:00:00Z", TransactionID: "tx4", Amount: PricingAmount{Currency: "EUR", Amount: 50}, Balance: 50},
+		{ID: "l5", Created: "2024-01-05T00:00:00Z", Amount: PricingAmount{Currency: "EUR", Amount: -280}, Balance: 0},
+		{ID: "l4", Created: "2024-01-04T00:00:00Z", TransactionID: "tx3", Amount: PricingAmount{Currency: "EUR", Amount: 100}, Balance: 280, Fee: 2},
+		{ID: "l3", Created: "2024-01-03T00:00:00Z", TransactionID: "tx2", Amount: PricingAmount{Currency: "EUR", Amount: -20}, Balance: 180, Refund: true},
+		{ID: "l2", Created: "2024-01-02T00:00:00Z", Amount: PricingAmount{Currency: "EUR", Amount: -300}, Balance: 200},
+		{ID: "l1", Created: "2024-01-01T00:00:00Z", TransactionID: "tx1", Amount: PricingAmount{Currency: "EUR", Amount: 500}, Balance: 500, Fee: 5},
+	}
+	assert.True(t, lines[1].IsPayout())
+	assert.False(t, lines[3].IsPayout())
+	assert.False(t, lines[5].IsPayout())
+
+	periods := SettlementPeriods(lines)
+	assert.Len(t, periods, 3)
+	assert.Equal(t, "l2", periods[0].Payout.ID)
+	assert.Equal(t, int64(300), periods[0].Paid)
+	assert.Equal(t, []*Line{lines[5]}, periods[0].Lines)
+	assert.Equal(t, []LineTotals{{Currency: "EUR", Captures: 500, Fees: 5, Count: 1}}, periods[0].Totals)
+
+	assert.Equal(t, "l5", periods[1].Payout.ID)
+	assert.Equal(t, int64(280), periods[1].Paid)
+	assert.Equal(t, []*Line{lines[3], lines[2]}, periods[1].Lines)
+	assert.Equal(t, []LineTotals{{Currency: "EUR", Captures: 100, Refunds: 20, Fees: 2, Count: 2}}, periods[1].Totals)
+
+	assert.Nil(t, periods[2].Payout)
+	assert.Equal(t, []*Line{lines[0]}, periods[2].Lines)
+
+	assert.Empty(t, SettlementPeriods(nil))
+}
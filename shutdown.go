@@ -0,0 +1,76 @@
+package paylike
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrClientClosed is returned without performing the request when the
+// client has been closed
+var ErrClientClosed = errors.New("paylike: client closed")
+
+// lifecycle tracks the requests in flight of a client and all its copies
+type lifecycle struct {
+	mu       sync.Mutex
+	closed   bool
+	inFlight sync.WaitGroup
+	done     chan struct{} // closed once the client is closed
+}
+
+// newLifecycle creates the lifecycle of an open client
+func newLifecycle() *lifecycle {
+	return &lifecycle{done: make(chan struct{})}
+}
+
+// track registers a new request in flight, returning the function to call
+// once it has finished
+func (c Client) track() (func(), error) {
+	if c.lifecycle == nil {
+		return func() {}, nil
+	}
+	c.lifecycle.mu.Lock()
+	defer c.lifecycle.mu.Unlock()
+	if c.lifecycle.closed {
+		return nil, ErrClientClosed
+	}
+	c.lifecycle.inFlight.Add(1)
+	return c.lifecycle.inFlight.Done, nil
+}
+
+// closing returns a channel closed once the client is closed
+func (c Client) closing() <-chan struct{} {
+	if c.lifecycle == nil {
+		return nil
+	}
+	return c.lifecycle.done
+}
+
+// Close shuts the client down: new requests fail with ErrClientClosed,
+// streams stop, and once the requests in flight have finished the idle
+// connections are closed
+// If the context is done before the requests in flight have finished,
+// Close closes the idle connections and returns the context's error
+func (c *Client) Close(ctx context.Context) error {
+	if c.lifecycle != nil {
+		c.lifecycle.mu.Lock()
+		if !c.lifecycle.closed {
+			c.lifecycle.closed = true
+			close(c.lifecycle.done)
+		}
+		c.lifecycle.mu.Unlock()
+		drained := make(chan struct{})
+		go func() {
+			c.lifecycle.inFlight.Wait()
+			close(drained)
+		}()
+		select {
+		case <-drained:
+		case <-ctx.Done():
+			c.client.CloseIdleConnections()
+			return ctx.Err()
+		}
+	}
+	c.client.CloseIdleConnections()
+	return nil
+}
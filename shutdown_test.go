@@ -0,0 +1,51 @@
+package paylike
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloseDrainsInFlightRequests(t *testing.T) {
+	client := newSlowTestClient(t, 50*time.Millisecond)
+	errs := make(chan error, 1)
+	go func() {
+		_, err := client.FetchApp()
+		errs <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	assert.Nil(t, client.Close(context.Background()))
+	select {
+	case err := <-errs:
+		assert.Nil(t, err)
+	default:
+		t.Fatal("Close returned before the request in flight finished")
+	}
+
+	_, err := client.FetchApp()
+	assert.Equal(t, ErrClientClosed, err)
+	assert.Nil(t, client.Close(context.Background()))
+}
+
+func TestCloseDeadline(t *testing.T) {
+	client := newSlowTestClient(t, time.Second)
+	go client.FetchApp()
+	time.Sleep(10 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, client.Close(ctx))
+}
+
+func TestCloseStopsStreams(t *testing.T) {
+	requests := 0
+	client := newPagedTestClient(t, 5, &requests)
+	transactions, errs := client.StreamTransactions(context.Background(), TestMerchant, Pagination{PageSize: 2})
+	tx := <-transactions
+	assert.Equal(t, TxID("tx5"), tx.ID)
+	assert.Nil(t, client.Close(context.Background()))
+	for range transactions {
+	}
+	assert.Equal(t, ErrClientClosed, <-errs)
+}
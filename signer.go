@@ -0,0 +1,59 @@
+package paylike
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// RequestSigner signs requests once their authentication and other headers
+// are set, e.g. adding an HMAC signature required by an outbound payment
+// gateway
+// Sign is called before every attempt of a request, with the body of the
+// request (nil if none), and may set headers on the request
+type RequestSigner interface {
+	Sign(req *http.Request, body []byte) error
+}
+
+// RequestSignerFunc is an adapter to allow the use of ordinary functions as RequestSigner
+type RequestSignerFunc func(req *http.Request, body []byte) error
+
+// Sign calls f(req, body)
+func (f RequestSignerFunc) Sign(req *http.Request, body []byte) error {
+	return f(req, body)
+}
+
+// WithRequestSigner signs every request with the given signer
+func WithRequestSigner(signer RequestSigner) Option {
+	return func(c *Client) {
+		c.signer = signer
+	}
+}
+
+// sign signs the given request with the signer of the client, if any
+func (c Client) sign(req *http.Request) error {
+	if c.signer == nil {
+		return nil
+	}
+	body, err := requestBody(req)
+	if err != nil {
+		return err
+	}
+	if err := c.signer.Sign(req, body); err != nil {
+		return fmt.Errorf("paylike: signing request: %w", err)
+	}
+	return nil
+}
+
+// requestBody returns a copy of the body of the given request, nil if none
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.GetBody == nil {
+		return nil, nil
+	}
+	r, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
@@ -0,0 +1,62 @@
+package paylike
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestSigner(t *testing.T) {
+	secret := []byte("gateway secret")
+	signature := func(method, path string, body []byte, attempt int) string {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(method + " " + path + " " + strconv.Itoa(attempt) + "\n"))
+		mac.Write(body)
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	var bodies []string
+	signer := RequestSignerFunc(func(req *http.Request, body []byte) error {
+		assert.NotEmpty(t, req.Header.Get("Authorization"))
+		attempt := len(bodies) + 1
+		req.Header.Set("X-Attempt", strconv.Itoa(attempt))
+		req.Header.Set("X-Signature", signature(req.Method, req.URL.Path, body, attempt))
+		return nil
+	})
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		attempt, _ := strconv.Atoi(r.Header.Get("X-Attempt"))
+		if r.Header.Get("X-Signature") != signature(r.Method, r.URL.Path, body, attempt) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		bodies = append(bodies, string(body))
+		if attempt == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"transaction":{"id":"tx1"}}`))
+	}), WithRetries(2), WithRequestSigner(signer))
+	_, err := client.CaptureTransaction("tx1", TransactionTrailDTO{Amount: 100})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{`{"amount":100}`, `{"amount":100}`}, bodies)
+}
+
+func TestRequestSignerError(t *testing.T) {
+	var requests int
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}), WithRequestSigner(RequestSignerFunc(func(req *http.Request, body []byte) error {
+		assert.Nil(t, body)
+		return errors.New("no signing key")
+	})))
+	_, err := client.FindTransaction("tx1")
+	assert.EqualError(t, err, "paylike: signing request: no signing key")
+	assert.Equal(t, 0, requests)
+}
@@ -0,0 +1,127 @@
+package paylike
+
+import (
+	"encoding/csv"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Statement describes the balance of a merchant over a period
+// Balances are in the currency of the merchant, while the lines of
+// cross-border merchants may be in several currencies, totaled separately
+type Statement struct {
+	MerchantID     MerchantID
+	From           time.Time    // start of the period, inclusive
+	To             time.Time    // end of the period, exclusive
+	OpeningBalance int64        // balance before the period, in minor units of the merchant currency
+	ClosingBalance int64        // balance at the end of the period
+	Totals         []LineTotals // totals of the period per currency, ordered by currency
+	Lines          []*Line      // lines of the period, oldest first
+}
+
+// ByCurrency returns the lines of the statement grouped by currency
+func (s *Statement) ByCurrency() []CurrencyGroup {
+	return GroupLinesByCurrency(s.Lines)
+}
+
+// Total returns the totals of the statement in the given currency, zero if
+// no line is in that currency
+func (s *Statement) Total(currency string) LineTotals {
+	for _, totals := range s.Totals {
+		if totals.Currency == currency {
+			return totals
+		}
+	}
+	return LineTotals{Currency: currency}
+}
+
+// GenerateStatement fetches the lines of a given merchant created in the
+// given period and summarizes them, along with the balance before and after
+// The opening balance is the balance after the last line before the period,
+// or zero if the history of the merchant starts within it
+// Lines are walked newest first, so the maximum number of items of the given
+// pagination caps the lines scanned from the newest one, including those
+// after the period: statements of older periods of busy merchants require a
+// higher cap
+func (c Client) GenerateStatement(merchantID MerchantID, from, to time.Time, p Pagination, opts ...CallOption) (*Statement, error) {
+	statement := &Statement{MerchantID: merchantID, From: from, To: to}
+	filter := LineFilter{After: from, Before: to}
+	err := paginate(c.with(opts), OpFetchLinesToMerchant, url.Values{}, p, lineCursor, func(line *Line) error {
+		if filter.pastRange(line) {
+			statement.OpeningBalance = line.Balance
+			return ErrStopPagination
+		}
+		if filter.matches(line) {
+			statement.Lines = append(statement.Lines, line)
+		}
+		return nil
+	}, string(merchantID))
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(statement.Lines, func(i, j int) bool {
+		return createdBefore(statement.Lines[i], statement.Lines[j])
+	})
+	statement.ClosingBalance = statement.OpeningBalance
+	if len(statement.Lines) > 0 {
+		statement.ClosingBalance = ComputeBalance(statement.Lines)
+	}
+	statement.Totals = TotalsByCurrency(statement.Lines)
+	return statement, nil
+}
+
+// WriteCSV writes the lines of the statement as CSV, see LinesCSVWriter
+func (s *Statement) WriteCSV(w io.Writer) error {
+	lw := NewLinesCSVWriter(w)
+	for _, line := range s.Lines {
+		if err := lw.Write(line); err != nil {
+			return err
+		}
+	}
+	return lw.Flush()
+}
+
+// LinesCSVHeader is the header row written by LinesCSVWriter
+var LinesCSVHeader = []string{"id", "created", "transactionId", "amount", "currency", "balance", "fee", "refund", "test"}
+
+// LinesCSVWriter writes merchant lines as CSV rows, preceded by a header row,
+// e.g. to export them while paginating with EachLineToMerchant
+type LinesCSVWriter struct {
+	w      *csv.Writer
+	header bool
+}
+
+// NewLinesCSVWriter creates a writer writing CSV to the given writer
+func NewLinesCSVWriter(w io.Writer) *LinesCSVWriter {
+	return &LinesCSVWriter{w: csv.NewWriter(w)}
+}
+
+// Write writes the row of the given line, after the header for the first line
+func (lw *LinesCSVWriter) Write(line *Line) error {
+	if !lw.header {
+		lw.header = true
+		if err := lw.w.Write(LinesCSVHeader); err != nil {
+			return err
+		}
+	}
+	return lw.w.Write([]string{
+		line.ID, line.Created, string(line.TransactionID),
+		strconv.FormatInt(line.Amount.Amount, 10), line.Amount.Currency,
+		strconv.FormatInt(line.Balance, 10), strconv.FormatInt(line.Fee, 10),
+		strconv.FormatBool(line.Refund), strconv.FormatBool(line.Test),
+	})
+}
+
+// Flush writes the header if no line has been written, and any buffered
+// rows to the underlying writer
+func (lw *LinesCSVWriter) Flush() error {
+	if !lw.header {
+		lw.header = true
+		lw.w.Write(LinesCSVHeader)
+	}
+	lw.w.Flush()
+	return lw.w.Error()
+}
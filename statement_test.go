@@ -0,0 +1,67 @@
+package paylike
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newStatementTestClient(t *testing.T) *Client {
+	return newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"id":"l5","created":"2024-02-02T10:00:00Z","transactionId":"tx5","amount":{"currency":"EUR","amount":100},"balance":900},
+			{"id":"l4","created":"2024-01-20T10:00:00Z","transactionId":"tx4","amount":{"currency":"EUR","amount":300},"balance":800,"fee":10},
+			{"id":"l3","created":"2024-01-10T10:00:00Z","transactionId":"tx2","amount":{"currency":"EUR","amount":-100},"balance":500,"refund":true},
+			{"id":"l2","created":"2024-01-05T10:00:00Z","transactionId":"tx2","amount":{"currency":"EUR","amount":200},"balance":600,"fee":5},
+			{"id":"l1","created":"2023-12-30T10:00:00Z","transactionId":"tx1","amount":{"currency":"EUR","amount":400},"balance":400},
+			{"id":"l0","created":"2023-12-01T10:00:00Z","transactionId":"tx0","amount":{"currency":"EUR","amount":1},"balance":0}
+		]`))
+	}))
+}
+
+func TestGenerateStatement(t *testing.T) {
+	client := newStatementTestClient(t)
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	statement, err := client.GenerateStatement("m1", from, to, Pagination{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(400), statement.OpeningBalance)
+	assert.Equal(t, int64(800), statement.ClosingBalance)
+	assert.Equal(t, []LineTotals{{Currency: "EUR", Captures: 500, Refunds: 100, Fees: 15, Count: 3}}, statement.Totals)
+	var ids []string
+	for _, line := range statement.Lines {
+		ids = append(ids, line.ID)
+	}
+	assert.Equal(t, []string{"l2", "l3", "l4"}, ids)
+
+	var buf bytes.Buffer
+	assert.Nil(t, statement.WriteCSV(&buf))
+	assert.Equal(t, "id,created,transactionId,amount,currency,balance,fee,refund,test\n"+
+		"l2,2024-01-05T10:00:00Z,tx2,200,EUR,600,5,false,false\n"+
+		"l3,2024-01-10T10:00:00Z,tx2,-100,EUR,500,0,true,false\n"+
+		"l4,2024-01-20T10:00:00Z,tx4,300,EUR,800,10,false,false\n", buf.String())
+
+	_, err = client.GenerateStatement("m1", from, to, Pagination{MaxItems: 3})
+	assert.Equal(t, ErrPaginationLimit, err)
+	statement, err = client.GenerateStatement("m1", from, to, Pagination{MaxItems: 5})
+	assert.Nil(t, err)
+	assert.Len(t, statement.Lines, 3)
+}
+
+func TestGenerateStatementWithoutLines(t *testing.T) {
+	client := newStatementTestClient(t)
+	from := time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)
+	statement, err := client.GenerateStatement("m1", from, from.Add(24*time.Hour), Pagination{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(400), statement.OpeningBalance)
+	assert.Equal(t, int64(400), statement.ClosingBalance)
+	assert.Empty(t, statement.Lines)
+	assert.Empty(t, statement.Totals)
+
+	var buf bytes.Buffer
+	assert.Nil(t, statement.WriteCSV(&buf))
+	assert.Equal(t, "id,created,transactionId,amount,currency,balance,fee,refund,test\n", buf.String())
+}
@@ -0,0 +1,35 @@
+package paylike
+
+// TransactionStatus describes where a transaction is in its lifecycle
+type TransactionStatus string
+
+// Possible transaction statuses
+const (
+	TransactionAuthorized        TransactionStatus = "authorized"         // authorized, nothing captured or voided yet
+	TransactionPartiallyCaptured TransactionStatus = "partially_captured" // partly captured, the rest still pending
+	TransactionCaptured          TransactionStatus = "captured"           // captured, nothing pending anymore
+	TransactionRefunded          TransactionStatus = "refunded"           // fully or partly refunded
+	TransactionVoided            TransactionStatus = "voided"             // voided without any capture
+	TransactionDisputed          TransactionStatus = "disputed"           // disputed by the cardholder
+	TransactionFailed            TransactionStatus = "failed"             // the authorization failed
+)
+
+// Status derives the status of the transaction from its amounts and error
+// flag, a dispute or refund taking precedence over the capture state
+func (t Transaction) Status() TransactionStatus {
+	switch {
+	case t.Error:
+		return TransactionFailed
+	case t.DisputedAmount > 0:
+		return TransactionDisputed
+	case t.RefundedAmount > 0:
+		return TransactionRefunded
+	case t.CapturedAmount > 0 && t.PendingAmount > 0:
+		return TransactionPartiallyCaptured
+	case t.CapturedAmount > 0:
+		return TransactionCaptured
+	case t.VoidedAmount > 0 && t.PendingAmount == 0:
+		return TransactionVoided
+	}
+	return TransactionAuthorized
+}
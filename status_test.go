@@ -0,0 +1,22 @@
+package paylike
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionStatus(t *testing.T) {
+	for expected, transaction := range map[TransactionStatus]Transaction{
+		TransactionAuthorized:        {Amount: 100, PendingAmount: 100, Successful: true},
+		TransactionPartiallyCaptured: {Amount: 100, CapturedAmount: 40, PendingAmount: 60, Successful: true},
+		TransactionCaptured:          {Amount: 100, CapturedAmount: 40, VoidedAmount: 60, Successful: true},
+		TransactionRefunded:          {Amount: 100, CapturedAmount: 100, RefundedAmount: 10, Successful: true},
+		TransactionVoided:            {Amount: 100, VoidedAmount: 100, Successful: true},
+		TransactionDisputed:          {Amount: 100, CapturedAmount: 100, RefundedAmount: 10, DisputedAmount: 90, Successful: true},
+		TransactionFailed:            {Amount: 100, Error: true},
+	} {
+		assert.Equal(t, expected, transaction.Status())
+	}
+	assert.Equal(t, TransactionAuthorized, Transaction{Amount: 100, VoidedAmount: 40, PendingAmount: 60}.Status())
+}
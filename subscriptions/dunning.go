@@ -0,0 +1,35 @@
+package subscriptions
+
+import (
+	"time"
+
+	paylike "github.com/paylike/go-api"
+)
+
+// Day is a convenience duration for dunning ladders
+const Day = 24 * time.Hour
+
+// Ladder retries soft declines after each of the given delays, counted from
+// the due date of the failed period, and gives up once they are exhausted
+type Ladder struct {
+	Delays   []time.Duration
+	GiveUpOn []string // decline codes canceling right away, even if soft
+}
+
+// Retry implements Dunning, use it as WithDunning(ladder.Retry)
+func (l Ladder) Retry(subscription *Subscription, err error, kind paylike.DeclineKind) (time.Time, bool) {
+	if kind != paylike.DeclineSoft || subscription.Attempts > len(l.Delays) {
+		return time.Time{}, false
+	}
+	code := paylike.DeclineCode(err)
+	for _, giveUp := range l.GiveUpOn {
+		if code == giveUp {
+			return time.Time{}, false
+		}
+	}
+	return subscription.PeriodStart.Add(l.Delays[subscription.Attempts-1]), true
+}
+
+// DefaultDunning retries soft declines after 1, 3 and 7 days and gives up
+// on hard declines
+var DefaultDunning Dunning = Ladder{Delays: []time.Duration{Day, 3 * Day, 7 * Day}}.Retry
@@ -0,0 +1,68 @@
+package subscriptions
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	paylike "github.com/paylike/go-api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLadder(t *testing.T) {
+	due := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ladder := Ladder{Delays: []time.Duration{Day, 3 * Day}, GiveUpOn: []string{"61"}}
+	soft := &paylike.APIError{StatusCode: 400, Code: "51"}
+
+	retryAt, retry := ladder.Retry(&Subscription{PeriodStart: due, Attempts: 1}, soft, paylike.DeclineSoft)
+	assert.True(t, retry)
+	assert.Equal(t, due.Add(Day), retryAt)
+	retryAt, retry = ladder.Retry(&Subscription{PeriodStart: due, Attempts: 2}, soft, paylike.DeclineSoft)
+	assert.True(t, retry)
+	assert.Equal(t, due.Add(3*Day), retryAt)
+	_, retry = ladder.Retry(&Subscription{PeriodStart: due, Attempts: 3}, soft, paylike.DeclineSoft)
+	assert.False(t, retry)
+
+	_, retry = ladder.Retry(&Subscription{PeriodStart: due, Attempts: 1}, &paylike.APIError{StatusCode: 400, Code: "61"}, paylike.DeclineSoft)
+	assert.False(t, retry)
+	_, retry = ladder.Retry(&Subscription{PeriodStart: due, Attempts: 1}, soft, paylike.DeclineHard)
+	assert.False(t, retry)
+}
+
+func TestDefaultDunningLadder(t *testing.T) {
+	due := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	storage := NewMemoryStorage()
+	storage.Save(context.Background(), &Subscription{ID: "s1", MerchantID: "m1", CardID: "c1", Plan: monthly, Status: Active, NextCharge: due})
+	soft := &paylike.APIError{StatusCode: 400, Code: "51"}
+	charger := &fakeCharger{errs: []error{soft, soft, soft, soft}}
+	m := newTestManager(charger, storage, due)
+
+	for _, retryAt := range []time.Time{due.Add(Day), due.Add(3 * Day), due.Add(7 * Day)} {
+		assert.Nil(t, m.ChargeDue(context.Background()))
+		s1, _ := storage.Get("s1")
+		assert.Equal(t, PastDue, s1.Status)
+		assert.Equal(t, retryAt, s1.NextCharge)
+		m.now = func() time.Time { return retryAt }
+	}
+	assert.Nil(t, m.ChargeDue(context.Background()))
+	s1, _ := storage.Get("s1")
+	assert.Equal(t, Canceled, s1.Status)
+	assert.Equal(t, 4, s1.Attempts)
+}
+
+func TestOnCardExpired(t *testing.T) {
+	due := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	storage := NewMemoryStorage()
+	storage.Save(context.Background(), &Subscription{ID: "s1", MerchantID: "m1", CardID: "c1", Plan: monthly, Status: Active, NextCharge: due})
+	storage.Save(context.Background(), &Subscription{ID: "s2", MerchantID: "m1", CardID: "c2", Plan: monthly, Status: Active, NextCharge: due.Add(time.Second)})
+	charger := &fakeCharger{errs: []error{
+		&paylike.APIError{StatusCode: 400, Code: "54"},
+		&paylike.APIError{StatusCode: 400, Code: "43"},
+	}}
+	var expired []string
+	m := newTestManager(charger, storage, due.Add(time.Minute), OnCardExpired(func(ctx context.Context, s *Subscription) {
+		expired = append(expired, s.ID)
+	}))
+	assert.Nil(t, m.ChargeDue(context.Background()))
+	assert.Equal(t, []string{"s1"}, expired)
+}
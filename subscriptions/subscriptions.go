@@ -0,0 +1,424 @@
+// Package subscriptions charges recurring Paylike subscriptions when they are
+// due, keeping track of next-charge dates through a pluggable storage
+package subscriptions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	paylike "github.com/paylike/go-api"
+)
+
+// Plan describes what a subscription charges and how often
+type Plan struct {
+	ID         string `json:"id"`
+	Currency   string `json:"currency"`   // three letter ISO
+	Amount     int64  `json:"amount"`     // amount in minor units
+	Descriptor string `json:"descriptor"` // optional, text on client bank statements
+	Months     int    `json:"months"`     // months between charges
+	Days       int    `json:"days"`       // days between charges, added to months
+}
+
+// ErrInvalidPlan is returned when charging a subscription whose plan does
+// not move the next charge forward, e.g. with neither months nor days
+var ErrInvalidPlan = errors.New("subscriptions: plan must have a positive interval")
+
+// Validate returns ErrInvalidPlan unless the plan has a positive interval
+func (p Plan) Validate() error {
+	if p.Months < 0 || p.Days < 0 || p.Months == 0 && p.Days == 0 {
+		return ErrInvalidPlan
+	}
+	return nil
+}
+
+// Next returns the next charge date following the given one, clamped to
+// the last day of the month, e.g. January 31 is followed by February 29
+// (in a leap year) for a monthly plan
+func (p Plan) Next(from time.Time) time.Time {
+	return p.NextAnchored(from, from.Day())
+}
+
+// NextAnchored returns the next charge date following the given one for a
+// subscription started on the given day of the month, so plans of whole
+// months keep charging on that day whenever the month has it, e.g. January
+// 31, February 29, March 31
+// Plans with days are not anchored, the days being added to the given date
+func (p Plan) NextAnchored(from time.Time, day int) time.Time {
+	if p.Months == 0 || p.Days != 0 || day < 1 {
+		day = from.Day()
+	}
+	year, month, _ := from.Date()
+	first := time.Date(year, month+time.Month(p.Months), 1, from.Hour(), from.Minute(), from.Second(), from.Nanosecond(), from.Location())
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1+p.Days)
+}
+
+// Status describes the state of a subscription
+type Status string
+
+// Possible subscription statuses
+const (
+	Active   Status = "active"   // charged when due
+	PastDue  Status = "past_due" // the last charge failed and is retried when due
+	Canceled Status = "canceled" // no longer charged
+)
+
+// Subscription describes a customer subscribed to a plan
+type Subscription struct {
+	ID            string                 `json:"id"`
+	MerchantID    paylike.MerchantID     `json:"merchantId"`
+	CardID        paylike.CardToken      `json:"cardId,omitempty"`        // required if no TransactionID is present
+	TransactionID paylike.TxID           `json:"transactionId,omitempty"` // required if no CardID is present
+	Plan          Plan                   `json:"plan"`
+	Status        Status                 `json:"status"`
+	NextCharge    time.Time              `json:"nextCharge"`
+	PeriodStart   time.Time              `json:"periodStart"`          // due date of the period being charged
+	AnchorDay     int                    `json:"anchorDay,omitempty"`  // day of the month of the first charge, see Plan.NextAnchored
+	Key           string                 `json:"key,omitempty"`        // idempotency key of the charge of the current period
+	Attempts      int                    `json:"attempts"`             // failed attempts in the current period
+	Unresolved    bool                   `json:"unresolved,omitempty"` // the outcome of the charge with Key is unknown, reconciled before charging again
+	LastError     string                 `json:"lastError,omitempty"`
+	Custom        map[string]interface{} `json:"custom,omitempty"`
+}
+
+// Storage persists subscriptions and their next-charge dates
+type Storage interface {
+	// Due returns the active and past due subscriptions to be charged at the given time
+	Due(ctx context.Context, at time.Time) ([]*Subscription, error)
+	// Save stores the given subscription
+	Save(ctx context.Context, subscription *Subscription) error
+}
+
+// Charger creates recurring charges, implemented by paylike.Client
+type Charger interface {
+	ChargeRecurring(merchantID paylike.MerchantID, charge paylike.RecurringCharge, opts ...paylike.CallOption) (*paylike.TransactionID, error)
+}
+
+// Finder looks up transactions by their custom fields, implemented by
+// paylike.Client
+type Finder interface {
+	FindTransactionsByCustom(merchantID paylike.MerchantID, key string, value interface{}, search paylike.CustomSearch, opts ...paylike.CallOption) ([]*paylike.Transaction, error)
+}
+
+// ChargeKeyCustom is the custom field every charge carries the idempotency
+// key of the subscription in, so its transaction can be found when the
+// outcome of the charge is unknown
+const ChargeKeyCustom = "chargeKey"
+
+// Dunning decides when to retry a failed charge of the given subscription,
+// returning false to give up and cancel the subscription
+type Dunning func(subscription *Subscription, err error, kind paylike.DeclineKind) (retryAt time.Time, retry bool)
+
+// Manager charges due subscriptions
+type Manager struct {
+	charger   Charger
+	finder    Finder
+	storage   Storage
+	interval  time.Duration
+	dunning   Dunning
+	onCharged func(ctx context.Context, subscription *Subscription, transaction *paylike.TransactionID)
+	onFailed  func(ctx context.Context, subscription *Subscription, err error, kind paylike.DeclineKind)
+	onExpired func(ctx context.Context, subscription *Subscription)
+	now       func() time.Time
+	ids       paylike.IDGenerator
+}
+
+// Option configures a manager
+type Option func(*Manager)
+
+// WithPollInterval changes how often Run looks for due subscriptions,
+// defaults to one minute
+func WithPollInterval(interval time.Duration) Option {
+	return func(m *Manager) {
+		m.interval = interval
+	}
+}
+
+// WithDunning changes how failed charges are retried, defaults to DefaultDunning
+func WithDunning(dunning Dunning) Option {
+	return func(m *Manager) {
+		m.dunning = dunning
+	}
+}
+
+// OnCharged registers a hook called after a subscription has been charged
+func OnCharged(hook func(ctx context.Context, subscription *Subscription, transaction *paylike.TransactionID)) Option {
+	return func(m *Manager) {
+		m.onCharged = hook
+	}
+}
+
+// OnFailed registers a hook called after charging a subscription failed,
+// once the dunning has decided how to proceed
+func OnFailed(hook func(ctx context.Context, subscription *Subscription, err error, kind paylike.DeclineKind)) Option {
+	return func(m *Manager) {
+		m.onFailed = hook
+	}
+}
+
+// OnCardExpired registers a hook called when a charge failed because the
+// card has expired, so the customer can be asked for a new one
+func OnCardExpired(hook func(ctx context.Context, subscription *Subscription)) Option {
+	return func(m *Manager) {
+		m.onExpired = hook
+	}
+}
+
+// WithClock makes the manager tell the time with the given clock, e.g. to
+// charge subscriptions deterministically in tests
+func WithClock(clock paylike.Clock) Option {
+	return func(m *Manager) {
+		m.now = clock.Now
+	}
+}
+
+// WithFinder changes how the transactions of charges with an unknown
+// outcome are looked up, defaults to the charger if it implements Finder
+func WithFinder(finder Finder) Option {
+	return func(m *Manager) {
+		m.finder = finder
+	}
+}
+
+// WithIDGenerator changes how the idempotency keys of charges are
+// generated, defaults to paylike.RandomIDs
+func WithIDGenerator(ids paylike.IDGenerator) Option {
+	return func(m *Manager) {
+		m.ids = ids
+	}
+}
+
+// New creates a manager charging subscriptions through the given charger
+func New(charger Charger, storage Storage, opts ...Option) *Manager {
+	m := &Manager{
+		charger:  charger,
+		storage:  storage,
+		interval: time.Minute,
+		dunning:  DefaultDunning,
+		now:      time.Now,
+		ids:      paylike.RandomIDs,
+	}
+	m.finder, _ = charger.(Finder)
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Run charges due subscriptions every poll interval until the context is done
+func (m *Manager) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		if err := m.ChargeDue(ctx); err != nil && ctx.Err() == nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// ChargeDue charges all subscriptions that are due
+func (m *Manager) ChargeDue(ctx context.Context) error {
+	due, err := m.storage.Due(ctx, m.now())
+	if err != nil {
+		return err
+	}
+	for _, subscription := range due {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := m.charge(ctx, subscription); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// charge charges the given subscription and schedules its next charge
+// The idempotency key of the charge is saved before charging and sent along
+// in the custom fields, and replaced once the charge is known to have failed
+// A charge whose outcome is unknown, because it failed without being
+// rejected or the manager crashed before saving the outcome, is reconciled by
+// looking up its transaction (see Finder) before dunning or charging again
+// If the lookup is not possible, the subscription is marked as unresolved
+// and retried with the same key at the next poll
+func (m *Manager) charge(ctx context.Context, subscription *Subscription) error {
+	if err := subscription.Plan.Validate(); err != nil {
+		return fmt.Errorf("%w: subscription %s", err, subscription.ID)
+	}
+	if subscription.PeriodStart.IsZero() || subscription.Attempts == 0 && !subscription.Unresolved {
+		subscription.PeriodStart = subscription.NextCharge
+	}
+	if subscription.AnchorDay == 0 {
+		subscription.AnchorDay = subscription.PeriodStart.Day()
+	}
+	if subscription.Key != "" && m.finder != nil {
+		transaction, err := m.find(ctx, subscription)
+		if err != nil {
+			return m.unresolved(ctx, subscription, err)
+		}
+		if transaction != nil && transaction.Successful {
+			return m.charged(ctx, subscription, &transaction.TransactionID)
+		}
+		if transaction != nil {
+			subscription.Key = ""
+		}
+	}
+	if subscription.Key == "" {
+		subscription.Key = m.ids.NewID()
+		if err := m.storage.Save(ctx, subscription); err != nil {
+			return err
+		}
+	}
+	custom := make(map[string]interface{}, len(subscription.Custom)+1)
+	for key, value := range subscription.Custom {
+		custom[key] = value
+	}
+	custom[ChargeKeyCustom] = subscription.Key
+	transaction, err := m.charger.ChargeRecurring(subscription.MerchantID, paylike.RecurringCharge{
+		CardID:        subscription.CardID,
+		TransactionID: subscription.TransactionID,
+		Currency:      subscription.Plan.Currency,
+		Amount:        subscription.Plan.Amount,
+		Descriptor:    subscription.Plan.Descriptor,
+		Custom:        custom,
+	}, paylike.WithContext(ctx), paylike.WithCallHeader(paylike.IdempotencyKeyHeader, subscription.Key))
+	if err == nil {
+		return m.charged(ctx, subscription, transaction)
+	}
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+	if paylike.IsClientError(err) || paylike.IsNotProcessed(err) {
+		subscription.Key = ""
+	} else {
+		if m.finder == nil {
+			return m.unresolved(ctx, subscription, err)
+		}
+		found, findErr := m.find(ctx, subscription)
+		if findErr != nil {
+			return m.unresolved(ctx, subscription, err)
+		}
+		if found != nil && found.Successful {
+			return m.charged(ctx, subscription, &found.TransactionID)
+		}
+		if found != nil {
+			subscription.Key = ""
+		}
+	}
+	kind := paylike.ClassifyDecline(err)
+	subscription.Attempts++
+	subscription.Unresolved = false
+	subscription.LastError = err.Error()
+	if retryAt, retry := m.dunning(subscription, err, kind); retry {
+		subscription.Status = PastDue
+		subscription.NextCharge = retryAt
+	} else {
+		subscription.Status = Canceled
+	}
+	if err := m.storage.Save(ctx, subscription); err != nil {
+		return err
+	}
+	if m.onFailed != nil {
+		m.onFailed(ctx, subscription, err, kind)
+	}
+	if m.onExpired != nil && paylike.IsCardExpired(err) {
+		m.onExpired(ctx, subscription)
+	}
+	return nil
+}
+
+// charged schedules the next charge of the given subscription once the
+// current one has succeeded
+func (m *Manager) charged(ctx context.Context, subscription *Subscription, transaction *paylike.TransactionID) error {
+	subscription.Status = Active
+	subscription.Attempts = 0
+	subscription.Unresolved = false
+	subscription.LastError = ""
+	subscription.NextCharge = subscription.Plan.NextAnchored(subscription.PeriodStart, subscription.AnchorDay)
+	subscription.PeriodStart = time.Time{}
+	subscription.Key = ""
+	if err := m.storage.Save(ctx, subscription); err != nil {
+		return err
+	}
+	if m.onCharged != nil {
+		m.onCharged(ctx, subscription, transaction)
+	}
+	return nil
+}
+
+// unresolved saves the given subscription as charged with an unknown
+// outcome, keeping its key and next charge so it is reconciled at the next poll
+func (m *Manager) unresolved(ctx context.Context, subscription *Subscription, err error) error {
+	subscription.Unresolved = true
+	subscription.LastError = err.Error()
+	return m.storage.Save(ctx, subscription)
+}
+
+// find returns the transaction of the charge with the current key of the
+// given subscription, nil if there is none among the newest transactions of
+// the merchant
+func (m *Manager) find(ctx context.Context, subscription *Subscription) (*paylike.Transaction, error) {
+	found, err := m.finder.FindTransactionsByCustom(subscription.MerchantID, ChargeKeyCustom, subscription.Key, paylike.CustomSearch{FirstOnly: true}, paylike.WithContext(ctx))
+	if err != nil && !errors.Is(err, paylike.ErrPaginationLimit) {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, nil
+	}
+	return found[0], nil
+}
+
+// MemoryStorage is an in-memory Storage, mostly useful for testing
+type MemoryStorage struct {
+	mu            sync.Mutex
+	subscriptions map[string]Subscription
+}
+
+// NewMemoryStorage creates a new empty in-memory storage
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{subscriptions: map[string]Subscription{}}
+}
+
+// Due returns copies of the subscriptions due at the given time, ordered by due date
+func (s *MemoryStorage) Due(ctx context.Context, at time.Time) ([]*Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []*Subscription
+	for _, subscription := range s.subscriptions {
+		if subscription.Status != Canceled && !subscription.NextCharge.After(at) {
+			subscription := subscription
+			due = append(due, &subscription)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].NextCharge.Before(due[j].NextCharge)
+	})
+	return due, nil
+}
+
+// Save stores a copy of the given subscription
+func (s *MemoryStorage) Save(ctx context.Context, subscription *Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscriptions[subscription.ID] = *subscription
+	return nil
+}
+
+// Get returns a copy of the subscription with the given ID
+func (s *MemoryStorage) Get(id string) (*Subscription, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	subscription, ok := s.subscriptions[id]
+	return &subscription, ok
+}
@@ -0,0 +1,299 @@
+package subscriptions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	paylike "github.com/paylike/go-api"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeCharger records charges and fails them with the queued errors
+type fakeCharger struct {
+	charges []paylike.RecurringCharge
+	errs    []error
+}
+
+func (f *fakeCharger) ChargeRecurring(merchantID paylike.MerchantID, charge paylike.RecurringCharge, opts ...paylike.CallOption) (*paylike.TransactionID, error) {
+	f.charges = append(f.charges, charge)
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &paylike.TransactionID{ID: "tx"}, nil
+}
+
+var monthly = Plan{ID: "monthly", Currency: "EUR", Amount: 999, Months: 1}
+
+func newTestManager(charger *fakeCharger, storage Storage, now time.Time, opts ...Option) *Manager {
+	m := New(charger, storage, opts...)
+	m.now = func() time.Time { return now }
+	return m
+}
+
+func TestChargeDue(t *testing.T) {
+	start := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	storage := NewMemoryStorage()
+	storage.Save(context.Background(), &Subscription{ID: "s1", MerchantID: "m1", CardID: "c1", Plan: monthly, Status: Active, NextCharge: start})
+	storage.Save(context.Background(), &Subscription{ID: "s2", MerchantID: "m1", CardID: "c2", Plan: monthly, Status: Active, NextCharge: start.AddDate(0, 0, 1)})
+
+	charger := &fakeCharger{}
+	var charged []string
+	m := newTestManager(charger, storage, start, OnCharged(func(ctx context.Context, s *Subscription, tx *paylike.TransactionID) {
+		charged = append(charged, s.ID)
+	}), WithIDGenerator(paylike.IDGeneratorFunc(func() string { return "k1" })))
+	assert.Nil(t, m.ChargeDue(context.Background()))
+	assert.Equal(t, []string{"s1"}, charged)
+	assert.Equal(t, []paylike.RecurringCharge{{CardID: "c1", Currency: "EUR", Amount: 999, Custom: map[string]interface{}{ChargeKeyCustom: "k1"}}}, charger.charges)
+
+	s1, _ := storage.Get("s1")
+	assert.Equal(t, Active, s1.Status)
+	assert.Equal(t, time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC), s1.NextCharge)
+	assert.Equal(t, 31, s1.AnchorDay)
+	assert.Empty(t, s1.Key)
+}
+
+func TestPlanNext(t *testing.T) {
+	start := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	var dates []string
+	for at, i := start, 0; i < 4; i++ {
+		at = monthly.NextAnchored(at, 31)
+		dates = append(dates, at.Format("2006-01-02"))
+	}
+	assert.Equal(t, []string{"2024-02-29", "2024-03-31", "2024-04-30", "2024-05-31"}, dates)
+	assert.Equal(t, time.Date(2024, 4, 29, 12, 0, 0, 0, time.UTC), monthly.Next(time.Date(2024, 3, 29, 12, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC), Plan{Months: 12}.Next(time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2024, 2, 14, 0, 0, 0, 0, time.UTC), Plan{Days: 14}.NextAnchored(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), 1))
+	assert.Equal(t, time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC), Plan{Months: 1, Days: 7}.Next(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestChargeDueInvalidPlan(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	storage := NewMemoryStorage()
+	storage.Save(context.Background(), &Subscription{ID: "s1", MerchantID: "m1", CardID: "c1", Plan: Plan{Currency: "EUR", Amount: 999}, Status: Active, NextCharge: start})
+	charger := &fakeCharger{}
+	err := newTestManager(charger, storage, start).ChargeDue(context.Background())
+	assert.True(t, errors.Is(err, ErrInvalidPlan))
+	assert.Equal(t, "subscriptions: plan must have a positive interval: subscription s1", err.Error())
+	assert.Empty(t, charger.charges)
+	assert.Equal(t, ErrInvalidPlan, Plan{Months: 1, Days: -1}.Validate())
+	assert.Nil(t, monthly.Validate())
+}
+
+func TestChargeDueIdempotencyKey(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var keys []string
+	var created []*paylike.Transaction
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			json.NewEncoder(w).Encode(created)
+			return
+		}
+		keys = append(keys, r.Header.Get(paylike.IdempotencyKeyHeader))
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			w.Write([]byte(`{"code":"CARD_DECLINED","message":"declined"}`))
+			return
+		}
+		var dto paylike.TransactionDTO
+		json.NewDecoder(r.Body).Decode(&dto)
+		created = append(created, &paylike.Transaction{TransactionID: paylike.TransactionID{ID: "tx1"}, Successful: true, Custom: dto.Custom})
+		w.Write([]byte(`{"transaction":{"id":"tx1"}}`))
+	}))
+	defer server.Close()
+	client := paylike.NewClient("key", paylike.WithBaseURL(server.URL))
+
+	storage := &crashingStorage{MemoryStorage: NewMemoryStorage()}
+	storage.Save(context.Background(), &Subscription{ID: "s1", MerchantID: "m1", CardID: "c1", Plan: monthly, Status: Active, NextCharge: start})
+	n := 0
+	ids := paylike.IDGeneratorFunc(func() string {
+		n++
+		return fmt.Sprintf("key-%d", n)
+	})
+	m := New(client, storage, WithIDGenerator(ids), WithClock(paylike.ClockFunc(func() time.Time { return start })))
+
+	storage.crash = true
+	assert.Equal(t, errCrash, m.ChargeDue(context.Background()))
+	s1, _ := storage.Get("s1")
+	assert.Equal(t, "key-1", s1.Key)
+	assert.Equal(t, start, s1.NextCharge)
+
+	storage.crash = false
+	assert.Nil(t, m.ChargeDue(context.Background()))
+	assert.Equal(t, []string{"key-1"}, keys)
+	s1, _ = storage.Get("s1")
+	assert.Empty(t, s1.Key)
+
+	status = http.StatusBadRequest
+	next := s1.NextCharge
+	m = New(client, storage, WithIDGenerator(ids), WithClock(paylike.ClockFunc(func() time.Time { return next })))
+	assert.Nil(t, m.ChargeDue(context.Background()))
+	s1, _ = storage.Get("s1")
+	assert.Equal(t, 1, s1.Attempts)
+	assert.Empty(t, s1.Key)
+	assert.Equal(t, []string{"key-1", "key-2"}, keys)
+}
+
+var errCrash = errors.New("crash")
+
+// crashingStorage fails to save subscriptions after they have been charged
+type crashingStorage struct {
+	*MemoryStorage
+	crash bool
+}
+
+func (s *crashingStorage) Save(ctx context.Context, subscription *Subscription) error {
+	if s.crash && subscription.Key == "" {
+		return errCrash
+	}
+	return s.MemoryStorage.Save(ctx, subscription)
+}
+
+func TestChargeDueDunning(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	storage := NewMemoryStorage()
+	storage.Save(context.Background(), &Subscription{ID: "s1", MerchantID: "m1", CardID: "c1", Plan: monthly, Status: Active, NextCharge: start})
+	charger := &fakeCharger{errs: []error{&paylike.APIError{StatusCode: 400, Code: "51"}}}
+	var failures []paylike.DeclineKind
+	retryAt := start.Add(time.Hour)
+	m := newTestManager(charger, storage, start,
+		WithDunning(func(s *Subscription, err error, kind paylike.DeclineKind) (time.Time, bool) {
+			return retryAt, kind == paylike.DeclineSoft
+		}),
+		OnFailed(func(ctx context.Context, s *Subscription, err error, kind paylike.DeclineKind) {
+			failures = append(failures, kind)
+		}),
+	)
+	assert.Nil(t, m.ChargeDue(context.Background()))
+	s1, _ := storage.Get("s1")
+	assert.Equal(t, PastDue, s1.Status)
+	assert.Equal(t, 1, s1.Attempts)
+	assert.Equal(t, retryAt, s1.NextCharge)
+	assert.Equal(t, []paylike.DeclineKind{paylike.DeclineSoft}, failures)
+
+	m.now = func() time.Time { return retryAt }
+	assert.Nil(t, m.ChargeDue(context.Background()))
+	s1, _ = storage.Get("s1")
+	assert.Equal(t, Active, s1.Status)
+	assert.Equal(t, 0, s1.Attempts)
+	assert.Equal(t, start.AddDate(0, 1, 0), s1.NextCharge)
+}
+
+func TestChargeDueHardDecline(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	storage := NewMemoryStorage()
+	storage.Save(context.Background(), &Subscription{ID: "s1", MerchantID: "m1", CardID: "c1", Plan: monthly, Status: Active, NextCharge: start})
+	charger := &fakeCharger{errs: []error{&paylike.APIError{StatusCode: 400, Code: "54"}}}
+	m := newTestManager(charger, storage, start)
+	assert.Nil(t, m.ChargeDue(context.Background()))
+	s1, _ := storage.Get("s1")
+	assert.Equal(t, Canceled, s1.Status)
+
+	assert.Nil(t, m.ChargeDue(context.Background()))
+	assert.Len(t, charger.charges, 1)
+}
+
+// findingCharger fails charges with an unknown outcome and finds the
+// transactions it has been given
+type findingCharger struct {
+	fakeCharger
+	found   []*paylike.Transaction
+	findErr error
+	finds   int
+}
+
+func (f *findingCharger) FindTransactionsByCustom(merchantID paylike.MerchantID, key string, value interface{}, search paylike.CustomSearch, opts ...paylike.CallOption) ([]*paylike.Transaction, error) {
+	f.finds++
+	var found []*paylike.Transaction
+	for _, transaction := range f.found {
+		if transaction.Custom[key] == value {
+			found = append(found, transaction)
+		}
+	}
+	return found, f.findErr
+}
+
+func TestChargeDueReconcilesUnknownOutcomes(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	unknown := &paylike.APIError{StatusCode: 502, Code: "BAD_GATEWAY"}
+	storage := NewMemoryStorage()
+	storage.Save(context.Background(), &Subscription{ID: "s1", MerchantID: "m1", CardID: "c1", Plan: monthly, Status: Active, NextCharge: start})
+	n := 0
+	ids := paylike.IDGeneratorFunc(func() string {
+		n++
+		return fmt.Sprintf("key-%d", n)
+	})
+	charger := &findingCharger{fakeCharger: fakeCharger{errs: []error{unknown}}, findErr: errors.New("offline")}
+	var failed int
+	m := New(charger, storage, WithIDGenerator(ids), WithClock(paylike.ClockFunc(func() time.Time { return start })),
+		OnFailed(func(ctx context.Context, s *Subscription, err error, kind paylike.DeclineKind) {
+			failed++
+		}))
+
+	// the charge failed and its transaction cannot be looked up: no dunning
+	assert.Nil(t, m.ChargeDue(context.Background()))
+	s1, _ := storage.Get("s1")
+	assert.True(t, s1.Unresolved)
+	assert.Equal(t, Active, s1.Status)
+	assert.Equal(t, 0, s1.Attempts)
+	assert.Equal(t, start, s1.NextCharge)
+	assert.Equal(t, "key-1", s1.Key)
+	assert.Equal(t, 0, failed)
+
+	// the transaction turns out to have been created
+	charger.findErr = nil
+	charger.found = []*paylike.Transaction{{TransactionID: paylike.TransactionID{ID: "tx1"}, Successful: true, Custom: map[string]interface{}{ChargeKeyCustom: "key-1"}}}
+	assert.Nil(t, m.ChargeDue(context.Background()))
+	s1, _ = storage.Get("s1")
+	assert.False(t, s1.Unresolved)
+	assert.Equal(t, Active, s1.Status)
+	assert.Equal(t, start.AddDate(0, 1, 0), s1.NextCharge)
+	assert.Empty(t, s1.Key)
+	assert.Len(t, charger.charges, 1)
+
+	// a charge failing without a transaction is dunned and keeps its key
+	charger.errs = []error{unknown}
+	m = New(charger, storage, WithIDGenerator(ids), WithClock(paylike.ClockFunc(func() time.Time { return s1.NextCharge })),
+		WithDunning(func(s *Subscription, err error, kind paylike.DeclineKind) (time.Time, bool) {
+			return s.PeriodStart.Add(Day), true
+		}))
+	assert.Nil(t, m.ChargeDue(context.Background()))
+	s1, _ = storage.Get("s1")
+	assert.Equal(t, PastDue, s1.Status)
+	assert.Equal(t, 1, s1.Attempts)
+	assert.Equal(t, "key-2", s1.Key)
+	assert.Len(t, charger.charges, 2)
+}
+
+func TestRun(t *testing.T) {
+	storage := NewMemoryStorage()
+	storage.Save(context.Background(), &Subscription{ID: "s1", MerchantID: "m1", CardID: "c1", Plan: monthly, Status: Active, NextCharge: time.Now()})
+	charger := &fakeCharger{}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	err := New(charger, storage, WithPollInterval(5*time.Millisecond)).Run(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Len(t, charger.charges, 1)
+}
+
+func TestWithClock(t *testing.T) {
+	due := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	storage := NewMemoryStorage()
+	storage.Save(context.Background(), &Subscription{ID: "s1", MerchantID: "m1", CardID: "c1", Plan: monthly, Status: Active, NextCharge: due})
+	charger := &fakeCharger{}
+	m := New(charger, storage, WithClock(paylike.ClockFunc(func() time.Time { return due })))
+	assert.Nil(t, m.ChargeDue(context.Background()))
+	assert.Len(t, charger.charges, 1)
+	s1, _ := storage.Get("s1")
+	assert.Equal(t, due.AddDate(0, 1, 0), s1.NextCharge)
+}
@@ -0,0 +1,59 @@
+package paylike
+
+import "errors"
+
+// 3-D Secure modes of a merchant
+const (
+	TDSModeAttempt = "attempt" // 3-D Secure is attempted, but not required
+	TDSModeFull    = "full"    // 3-D Secure is required for all payments
+)
+
+// TransactionTDS describes the result of a 3-D Secure authentication
+// completed by the cardholder, to be passed along when creating a transaction
+// Either the issuer's response (PARes for 3-D Secure 1, CRes for 3-D Secure 2)
+// or the authentication values extracted from it (ECI and CAVV) are required
+type TransactionTDS struct {
+	Version       string `json:"version,omitempty"`       // optional, protocol version, e.g. "2.1.0"
+	PARes         string `json:"pares,omitempty"`         // payer authentication response (3-D Secure 1)
+	CRes          string `json:"cres,omitempty"`          // challenge response (3-D Secure 2)
+	ECI           string `json:"eci,omitempty"`           // electronic commerce indicator
+	CAVV          string `json:"cavv,omitempty"`          // cardholder authentication verification value
+	XID           string `json:"xid,omitempty"`           // transaction identifier (3-D Secure 1)
+	DSTransID     string `json:"dsTransId,omitempty"`     // directory server transaction ID (3-D Secure 2)
+	TransStatus   string `json:"transStatus,omitempty"`   // authentication status, e.g. "Y" or "A"
+	ChallengeDone bool   `json:"challengeDone,omitempty"` // whether the cardholder completed a challenge
+}
+
+// ErrTDSIncomplete is returned when the 3-D Secure data holds neither the
+// issuer's response nor the authentication values
+var ErrTDSIncomplete = errors.New("paylike: 3-D Secure data requires either pares/cres or eci and cavv")
+
+// ErrTDSNotAuthenticated is returned when the 3-D Secure status reports a
+// failed or rejected authentication
+var ErrTDSNotAuthenticated = errors.New("paylike: 3-D Secure authentication did not succeed")
+
+// Validate checks whether the 3-D Secure data can complete a payment
+func (t TransactionTDS) Validate() error {
+	if t.PARes == "" && t.CRes == "" && (t.ECI == "" || t.CAVV == "") {
+		return ErrTDSIncomplete
+	}
+	if t.TransStatus != "" && !t.Authenticated() {
+		return ErrTDSNotAuthenticated
+	}
+	return nil
+}
+
+// Authenticated returns whether the status reports a successful (Y) or
+// attempted (A) authentication, or no status is known
+func (t TransactionTDS) Authenticated() bool {
+	switch t.TransStatus {
+	case "", "Y", "A":
+		return true
+	}
+	return false
+}
+
+// Required returns whether the merchant requires 3-D Secure for all payments
+func (m MerchantTDS) Required() bool {
+	return m.Mode == TDSModeFull
+}
@@ -0,0 +1,63 @@
+package paylike
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionTDSValidate(t *testing.T) {
+	assert.Equal(t, ErrTDSIncomplete, TransactionTDS{}.Validate())
+	assert.Equal(t, ErrTDSIncomplete, TransactionTDS{ECI: "05"}.Validate())
+	assert.Nil(t, TransactionTDS{ECI: "05", CAVV: "AAABBEg0VhI0VniQEjRWAAAAAAA="}.Validate())
+	assert.Nil(t, TransactionTDS{PARes: "eJzVWNmu"}.Validate())
+	assert.Nil(t, TransactionTDS{CRes: "eyJhY3N", TransStatus: "Y"}.Validate())
+	assert.Equal(t, ErrTDSNotAuthenticated, TransactionTDS{CRes: "eyJhY3N", TransStatus: "N"}.Validate())
+}
+
+func TestCreateTransactionWithTDS(t *testing.T) {
+	var body string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{"transaction":{"id":"tx1"}}`))
+	}))
+	dto := TransactionDTO{
+		CardID:   "c1",
+		Currency: "EUR",
+		Amount:   100,
+		TDS:      &TransactionTDS{Version: "2.1.0", CRes: "eyJhY3N", TransStatus: "Y"},
+	}
+	transaction, err := client.CreateTransaction(TestMerchant, dto)
+	assert.Nil(t, err)
+	assert.Equal(t, TxID("tx1"), transaction.ID)
+	assert.Equal(t, `{"cardId":"c1","currency":"EUR","amount":100,"tds":{"version":"2.1.0","cres":"eyJhY3N","transStatus":"Y"}}`, body)
+
+	body = ""
+	dto.TDS = &TransactionTDS{}
+	_, err = client.CreateTransaction(TestMerchant, dto)
+	assert.Equal(t, ErrTDSIncomplete, err)
+	assert.Empty(t, body)
+}
+
+func TestMerchantTDSRequired(t *testing.T) {
+	assert.True(t, MerchantTDS{Mode: TDSModeFull}.Required())
+	assert.False(t, MerchantTDS{Mode: TDSModeAttempt}.Required())
+}
+
+func TestUpdateMerchantTDS(t *testing.T) {
+	var requests []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+string(b))
+	}))
+	assert.Nil(t, client.UpdateMerchantTDS(TestMerchant, TDSModeFull))
+	assert.NotNil(t, client.UpdateMerchantTDS(TestMerchant, "never"))
+	assert.Nil(t, client.UpdateMerchant(TestMerchant, MerchantUpdateDTO{Name: "Shop", TDS: &MerchantTDS{Mode: TDSModeAttempt}}))
+	assert.Equal(t, []string{
+		`PUT /merchants/` + TestMerchant + ` {"tds":{"mode":"full"}}`,
+		`PUT /merchants/` + TestMerchant + ` {"name":"Shop","tds":{"mode":"attempt"}}`,
+	}, requests)
+}
@@ -0,0 +1,331 @@
+// Package testhelpers provides test card values and an in-memory fake of the
+// Paylike API, so test suites can exercise successful and declined payments
+// deterministically and without network access
+package testhelpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	paylike "github.com/paylike/go-api"
+)
+
+// Card values accepted by merchants in test mode
+const (
+	CardNumber = "4100000000000000"
+	CardCVC    = "111"
+)
+
+// CardExpiry returns an expiry date in the future, valid for test cards
+func CardExpiry() paylike.CardExpiry {
+	next := time.Now().AddDate(1, 0, 0)
+	return paylike.CardExpiry{Month: next.Month(), Year: next.Year()}
+}
+
+// Decline codes commonly used to exercise failure paths
+const (
+	DeclineInsufficientFunds = "51" // soft decline
+	DeclineExpiredCard       = "54" // hard decline
+	DeclineStolenCard        = "43" // hard decline
+)
+
+// Server is an in-memory fake of the Paylike API supporting merchants,
+// cards and the transaction lifecycle
+type Server struct {
+	*httptest.Server
+	mu           sync.Mutex
+	seq          int
+	merchants    map[paylike.MerchantID]*paylike.Merchant
+	cards        map[paylike.CardToken]*card
+	transactions map[paylike.TxID]*paylike.Transaction
+	charged      map[paylike.TxID]*card // card each transaction was created with
+}
+
+// card describes a card of the fake along with how charging it fails, if at all
+type card struct {
+	paylike.Card
+	decline string
+}
+
+// NewServer starts a new fake server which is closed when the test finishes
+func NewServer(t testing.TB) *Server {
+	s := &Server{
+		merchants:    map[paylike.MerchantID]*paylike.Merchant{},
+		cards:        map[paylike.CardToken]*card{},
+		transactions: map[paylike.TxID]*paylike.Transaction{},
+		charged:      map[paylike.TxID]*card{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Client creates a client sending its requests to the fake server
+func (s *Server) Client(opts ...paylike.Option) *paylike.Client {
+	return paylike.NewClient("test", append([]paylike.Option{paylike.WithBaseURL(s.URL)}, opts...)...)
+}
+
+// Merchant creates a new test merchant using the given currency
+func (s *Server) Merchant(currency string) paylike.MerchantID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.createMerchant(paylike.MerchantCreateDTO{Currency: currency, Test: true})
+}
+
+// Card saves a new test card for the given merchant which is always charged successfully
+func (s *Server) Card(merchantID paylike.MerchantID) paylike.CardToken {
+	return s.DecliningCard(merchantID, "")
+}
+
+// DecliningCard saves a new test card for the given merchant whose charges
+// are always declined with the given decline code
+func (s *Server) DecliningCard(merchantID paylike.MerchantID, code string) paylike.CardToken {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.createCard(merchantID, code)
+}
+
+// Transaction authorizes a new transaction of the given amount on a new test card
+func (s *Server) Transaction(merchantID paylike.MerchantID, currency string, amount int64) paylike.TxID {
+	cardID := s.Card(merchantID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.createTransaction(merchantID, s.cards[cardID], currency, amount)
+}
+
+// nextID returns a new unique ID formatted like the IDs of the API
+func (s *Server) nextID() string {
+	s.seq++
+	return fmt.Sprintf("%024x", s.seq)
+}
+
+func (s *Server) createMerchant(dto paylike.MerchantCreateDTO) paylike.MerchantID {
+	merchant := &paylike.Merchant{
+		ID:         paylike.MerchantID(s.nextID()),
+		Name:       dto.Name,
+		Currency:   dto.Currency,
+		Email:      dto.Email,
+		Test:       true,
+		Descriptor: dto.Descriptor,
+		Website:    dto.Website,
+	}
+	s.merchants[merchant.ID] = merchant
+	return merchant.ID
+}
+
+func (s *Server) createCard(merchantID paylike.MerchantID, decline string) paylike.CardToken {
+	c := &card{decline: decline}
+	c.ID = paylike.CardToken(s.nextID())
+	c.MerchantID = merchantID
+	c.Created = time.Now().UTC().Format(time.RFC3339)
+	c.Bin = CardNumber[:6]
+	c.Last4 = CardNumber[len(CardNumber)-4:]
+	c.Scheme = paylike.SchemeFromBin(CardNumber)
+	c.Expiry = CardExpiry().End().Add(-time.Millisecond).Format(time.RFC3339Nano)
+	s.cards[c.ID] = c
+	return c.ID
+}
+
+func (s *Server) createTransaction(merchantID paylike.MerchantID, c *card, currency string, amount int64) paylike.TxID {
+	transaction := &paylike.Transaction{
+		MerchantID:    merchantID,
+		Test:          true,
+		Created:       time.Now().UTC().Format(time.RFC3339),
+		Amount:        amount,
+		PendingAmount: amount,
+		Currency:      currency,
+		Card:          c.TransactionCard,
+		Successful:    true,
+	}
+	transaction.ID = paylike.TxID(s.nextID())
+	s.transactions[transaction.ID] = transaction
+	s.charged[transaction.ID] = c
+	return transaction.ID
+}
+
+// fail writes an error response in the format of the API
+func fail(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"code": code, "message": message})
+}
+
+// respond writes the given value wrapped in the given key, if any
+func respond(w http.ResponseWriter, key string, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if key != "" {
+		value = map[string]interface{}{key: value}
+	}
+	json.NewEncoder(w).Encode(value)
+}
+
+// serve handles the requests of the client
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	op, params, ok := paylike.MatchOperation(r.Method, r.URL.Path)
+	if !ok {
+		fail(w, http.StatusNotFound, "NOT_FOUND", "unknown endpoint")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch op {
+	case paylike.OpCreateMerchant:
+		var dto paylike.MerchantCreateDTO
+		if json.NewDecoder(r.Body).Decode(&dto) != nil || dto.Currency == "" {
+			fail(w, http.StatusBadRequest, "INVALID", "currency is required")
+			return
+		}
+		respond(w, "merchant", s.merchants[s.createMerchant(dto)])
+	case paylike.OpGetMerchant:
+		merchant, ok := s.merchants[paylike.MerchantID(params[0])]
+		if !ok {
+			fail(w, http.StatusNotFound, "NOT_FOUND", "merchant not found")
+			return
+		}
+		respond(w, "merchant", merchant)
+	case paylike.OpCreateCard:
+		var dto paylike.CardDTO
+		json.NewDecoder(r.Body).Decode(&dto)
+		c, ok := s.charged[dto.TransactionID]
+		if !ok {
+			fail(w, http.StatusBadRequest, "INVALID", "transaction not found")
+			return
+		}
+		id := s.createCard(paylike.MerchantID(params[0]), c.decline)
+		s.cards[id].Notes = dto.Notes
+		s.cards[id].Custom = dto.Custom
+		respond(w, "card", paylike.CardID{ID: id})
+	case paylike.OpFetchCard:
+		c, ok := s.cards[paylike.CardToken(params[0])]
+		if !ok {
+			fail(w, http.StatusNotFound, "NOT_FOUND", "card not found")
+			return
+		}
+		respond(w, "card", c.Card)
+	case paylike.OpUpdateCard:
+		c, ok := s.cards[paylike.CardToken(params[0])]
+		if !ok {
+			fail(w, http.StatusNotFound, "NOT_FOUND", "card not found")
+			return
+		}
+		var dto paylike.CardUpdateDTO
+		json.NewDecoder(r.Body).Decode(&dto)
+		if dto.Notes != nil {
+			c.Notes = *dto.Notes
+		}
+		if dto.Custom != nil {
+			c.Custom = dto.Custom
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case paylike.OpCreateTransaction:
+		s.serveCreateTransaction(w, r, paylike.MerchantID(params[0]))
+	case paylike.OpFindTransaction:
+		transaction, ok := s.transactions[paylike.TxID(params[0])]
+		if !ok {
+			fail(w, http.StatusNotFound, "NOT_FOUND", "transaction not found")
+			return
+		}
+		respond(w, "transaction", transaction)
+	case paylike.OpListTransactions:
+		s.serveListTransactions(w, r, paylike.MerchantID(params[0]))
+	case paylike.OpCaptureTransaction, paylike.OpRefundTransaction, paylike.OpVoidTransaction:
+		s.serveTrail(w, r, op, paylike.TxID(params[0]))
+	default:
+		fail(w, http.StatusNotImplemented, "NOT_IMPLEMENTED", op.Name+" is not supported by the fake")
+	}
+}
+
+func (s *Server) serveCreateTransaction(w http.ResponseWriter, r *http.Request, merchantID paylike.MerchantID) {
+	if _, ok := s.merchants[merchantID]; !ok {
+		fail(w, http.StatusNotFound, "NOT_FOUND", "merchant not found")
+		return
+	}
+	var dto paylike.TransactionDTO
+	if json.NewDecoder(r.Body).Decode(&dto) != nil || dto.Currency == "" || dto.Amount <= 0 {
+		fail(w, http.StatusBadRequest, "INVALID", "currency and a positive amount are required")
+		return
+	}
+	c := s.cards[dto.CardID]
+	if dto.CardID == "" {
+		c = s.charged[dto.TransactionID]
+	}
+	if c == nil {
+		fail(w, http.StatusBadRequest, "INVALID", "card or transaction not found")
+		return
+	}
+	if c.decline != "" {
+		fail(w, http.StatusBadRequest, c.decline, "card declined")
+		return
+	}
+	respond(w, "transaction", paylike.TransactionID{ID: s.createTransaction(merchantID, c, dto.Currency, dto.Amount)})
+}
+
+func (s *Server) serveListTransactions(w http.ResponseWriter, r *http.Request, merchantID paylike.MerchantID) {
+	var transactions []*paylike.Transaction
+	for _, transaction := range s.transactions {
+		if transaction.MerchantID == merchantID {
+			transactions = append(transactions, transaction)
+		}
+	}
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactions[i].ID > transactions[j].ID
+	})
+	before := paylike.TxID(r.URL.Query().Get("before"))
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = len(transactions)
+	}
+	page := []*paylike.Transaction{}
+	for _, transaction := range transactions {
+		if len(page) < limit && (before == "" || transaction.ID < before) {
+			page = append(page, transaction)
+		}
+	}
+	respond(w, "", page)
+}
+
+func (s *Server) serveTrail(w http.ResponseWriter, r *http.Request, op paylike.Operation, id paylike.TxID) {
+	transaction, ok := s.transactions[id]
+	if !ok {
+		fail(w, http.StatusNotFound, "NOT_FOUND", "transaction not found")
+		return
+	}
+	var dto paylike.TransactionTrailDTO
+	json.NewDecoder(r.Body).Decode(&dto)
+	if dto.Currency != "" && dto.Currency != transaction.Currency {
+		fail(w, http.StatusBadRequest, "CURRENCY_MISMATCH", "currency does not match the transaction")
+		return
+	}
+	available := transaction.PendingAmount
+	if op == paylike.OpRefundTransaction {
+		available = transaction.CapturedAmount - transaction.RefundedAmount
+	}
+	if dto.Amount <= 0 || dto.Amount > available {
+		fail(w, http.StatusBadRequest, "AMOUNT_INVALID", fmt.Sprintf("amount must be between 1 and %d", available))
+		return
+	}
+	switch op {
+	case paylike.OpCaptureTransaction:
+		transaction.PendingAmount -= dto.Amount
+		transaction.CapturedAmount += dto.Amount
+	case paylike.OpRefundTransaction:
+		transaction.RefundedAmount += dto.Amount
+	case paylike.OpVoidTransaction:
+		transaction.PendingAmount -= dto.Amount
+		transaction.VoidedAmount += dto.Amount
+	}
+	transaction.Trail = append(transaction.Trail, &paylike.TransactionTrail{
+		Amount:     dto.Amount,
+		Created:    time.Now().UTC().Format(time.RFC3339),
+		Capture:    op == paylike.OpCaptureTransaction,
+		Descriptor: dto.Descriptor,
+	})
+	respond(w, "transaction", transaction)
+}
@@ -0,0 +1,103 @@
+package testhelpers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	paylike "github.com/paylike/go-api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerTransactionLifecycle(t *testing.T) {
+	server := NewServer(t)
+	client := server.Client()
+	merchantID := server.Merchant("EUR")
+	cardID := server.Card(merchantID)
+
+	created, err := client.CreateTransaction(merchantID, paylike.TransactionDTO{CardID: cardID, Currency: "EUR", Amount: 1000})
+	assert.Nil(t, err)
+	transaction, err := client.CaptureTransaction(created.ID, paylike.TransactionTrailDTO{Amount: 600})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(600), transaction.CapturedAmount)
+	assert.Equal(t, int64(400), transaction.PendingAmount)
+
+	transaction, err = client.RefundTransaction(created.ID, paylike.TransactionTrailDTO{Amount: 100})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(100), transaction.RefundedAmount)
+	transaction, err = client.VoidTransaction(created.ID, paylike.TransactionTrailDTO{Amount: 400})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), transaction.PendingAmount)
+	assert.Len(t, transaction.Trail, 3)
+
+	_, err = client.CaptureTransaction(created.ID, paylike.TransactionTrailDTO{Amount: 1})
+	assert.True(t, paylike.IsClientError(err))
+	assert.True(t, errors.Is(err, paylike.ErrAmountExceeded))
+
+	found, err := client.FindTransaction(created.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(400), found.VoidedAmount)
+	assert.False(t, found.Card.IsExpired(time.Now()))
+
+	transactions, err := client.ListTransactions(merchantID, 10)
+	assert.Nil(t, err)
+	assert.Len(t, transactions, 1)
+}
+
+func TestServerDeclines(t *testing.T) {
+	server := NewServer(t)
+	client := server.Client()
+	merchantID := server.Merchant("EUR")
+
+	_, err := client.ChargeRecurring(merchantID, paylike.RecurringCharge{
+		CardID:   server.DecliningCard(merchantID, DeclineInsufficientFunds),
+		Currency: "EUR",
+		Amount:   100,
+	})
+	assert.Equal(t, paylike.DeclineSoft, paylike.ClassifyDecline(err))
+
+	card, err := client.CreateCard(merchantID, paylike.CardDTO{TransactionID: server.Transaction(merchantID, "EUR", 100)})
+	assert.Nil(t, err)
+	_, err = client.ChargeRecurring(merchantID, paylike.RecurringCharge{CardID: card.ID, Currency: "EUR", Amount: 100})
+	assert.Nil(t, err)
+
+	_, err = client.ChargeRecurring(merchantID, paylike.RecurringCharge{
+		CardID:   server.DecliningCard(merchantID, DeclineExpiredCard),
+		Currency: "EUR",
+		Amount:   100,
+	})
+	assert.Equal(t, paylike.DeclineHard, paylike.ClassifyDecline(err))
+	assert.True(t, paylike.IsCardExpired(err))
+}
+
+func TestServerMerchants(t *testing.T) {
+	server := NewServer(t)
+	client := server.Client()
+	merchant, err := client.CreateMerchant(paylike.MerchantCreateDTO{Name: "Shop", Currency: "DKK"})
+	assert.Nil(t, err)
+	fetched, err := client.GetMerchant(merchant.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "Shop", fetched.Name)
+	assert.True(t, fetched.Test)
+
+	_, err = client.InviteUserToMerchant(merchant.ID, "john@example.com")
+	assert.True(t, paylike.IsServerError(err))
+}
+
+func TestServerCards(t *testing.T) {
+	server := NewServer(t)
+	client := server.Client()
+	merchantID := server.Merchant("EUR")
+
+	created, err := client.CreateCard(merchantID, paylike.CardDTO{TransactionID: server.Transaction(merchantID, "EUR", 100), Notes: "first card"})
+	assert.Nil(t, err)
+	notes := ""
+	err = client.UpdateCard(created.ID, paylike.CardUpdateDTO{Notes: &notes, Custom: map[string]interface{}{"customer": "42"}})
+	assert.Nil(t, err)
+
+	card, err := client.FetchCard(created.ID)
+	assert.Nil(t, err)
+	assert.Empty(t, card.Notes)
+	assert.Equal(t, "42", card.Custom["customer"])
+	assert.True(t, paylike.IsClientError(client.UpdateCard("unknown", paylike.CardUpdateDTO{})))
+}
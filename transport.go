@@ -0,0 +1,95 @@
+package paylike
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// WithMaxIdleConnsPerHost changes how many idle connections to the API
+// are kept for reuse, http.DefaultMaxIdleConnsPerHost by default
+func WithMaxIdleConnsPerHost(n int) Option {
+	return withTransport(func(t *http.Transport) {
+		t.MaxIdleConnsPerHost = n
+	})
+}
+
+// WithIdleConnTimeout changes how long idle connections are kept open,
+// zero keeps them open until the API closes them
+func WithIdleConnTimeout(timeout time.Duration) Option {
+	return withTransport(func(t *http.Transport) {
+		t.IdleConnTimeout = timeout
+	})
+}
+
+// WithTLSConfig establishes connections with the given TLS configuration,
+// e.g. one trusting the custom roots of a corporate proxy
+func WithTLSConfig(config *tls.Config) Option {
+	return withTransport(func(t *http.Transport) {
+		t.TLSClientConfig = config
+	})
+}
+
+// WithProxyURL sends the requests through the given proxy, regardless of
+// the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables honored
+// by default
+func WithProxyURL(proxy *url.URL) Option {
+	return withTransport(func(t *http.Transport) {
+		t.Proxy = http.ProxyURL(proxy)
+	})
+}
+
+// RoundTripperFunc is an adapter to allow the use of ordinary functions as
+// http.RoundTripper, e.g. to respond to requests in-process in tests
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req)
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// WithTransport sends the requests through the given round tripper instead
+// of the transport of the HTTP client, e.g. to intercept them in tests
+// without opening sockets; the HTTP client given with WithHTTPClient, if any,
+// is left untouched
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) {
+		client := *c.client
+		client.Transport = transport
+		c.client = &client
+	}
+}
+
+// withTransport registers a change of the client's HTTP transport, applied
+// once all options have been applied
+// Changes have no effect if the HTTP client given with WithHTTPClient has a
+// transport other than *http.Transport
+func withTransport(change func(*http.Transport)) Option {
+	return func(c *Client) {
+		c.transportChanges = append(c.transportChanges, change)
+	}
+}
+
+// applyTransportChanges applies the registered transport changes to a copy
+// of the client's HTTP client and transport, leaving the originals untouched
+func (c *Client) applyTransportChanges() {
+	if len(c.transportChanges) == 0 {
+		return
+	}
+	base := c.client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	transport, ok := base.(*http.Transport)
+	if !ok {
+		return
+	}
+	transport = transport.Clone()
+	for _, change := range c.transportChanges {
+		change(transport)
+	}
+	client := *c.client
+	client.Transport = transport
+	c.client = &client
+}
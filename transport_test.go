@@ -0,0 +1,115 @@
+package paylike
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"identity":{"id":"app1"}}`))
+	}))
+	defer server.Close()
+
+	_, err := NewClient(TestKey, WithBaseURL(server.URL)).FetchApp()
+	assert.NotNil(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	identity, err := NewClient(TestKey, WithBaseURL(server.URL), WithTLSConfig(&tls.Config{RootCAs: roots})).FetchApp()
+	assert.Nil(t, err)
+	assert.Equal(t, AppID("app1"), identity.ID)
+}
+
+func TestTransportOptions(t *testing.T) {
+	httpClient := &http.Client{Timeout: time.Minute}
+	client := NewClient(TestKey, WithHTTPClient(httpClient), WithMaxIdleConnsPerHost(32), WithIdleConnTimeout(time.Minute))
+	transport := client.client.Transport.(*http.Transport)
+	assert.Equal(t, 32, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, time.Minute, transport.IdleConnTimeout)
+	assert.NotNil(t, transport.Proxy, "the proxy environment variables are honored")
+	assert.Equal(t, time.Minute, client.client.Timeout)
+	assert.Nil(t, httpClient.Transport)
+	assert.NotEqual(t, 32, http.DefaultTransport.(*http.Transport).MaxIdleConnsPerHost)
+}
+
+func TestWithProxyURL(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		w.Write([]byte(`{"identity":{"id":"app1"}}`))
+	}))
+	defer proxy.Close()
+
+	proxyURL, _ := url.Parse(proxy.URL)
+	client := NewClient(TestKey, WithBaseURL("http://api.paylike.invalid"), WithProxyURL(proxyURL))
+	identity, err := client.FetchApp()
+	assert.Nil(t, err)
+	assert.Equal(t, AppID("app1"), identity.ID)
+	assert.Equal(t, []string{"http://api.paylike.invalid/me"}, proxied)
+}
+
+func TestWithTransport(t *testing.T) {
+	respond := func(status int, body string) RoundTripperFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: status,
+				Header:     http.Header{"Content-Type": {"application/json"}},
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+				Request:    req,
+			}, nil
+		}
+	}
+	failure := errors.New("connection reset")
+	tests := []struct {
+		name      string
+		transport RoundTripperFunc
+		check     func(t *testing.T, transaction *Transaction, err error)
+	}{
+		{"success", respond(200, `{"transaction":{"id":"tx1","amount":100}}`), func(t *testing.T, transaction *Transaction, err error) {
+			assert.Nil(t, err)
+			assert.Equal(t, int64(100), transaction.Amount)
+		}},
+		{"client error", respond(400, `{"code":"INVALID","message":"invalid transaction"}`), func(t *testing.T, transaction *Transaction, err error) {
+			assert.True(t, IsClientError(err))
+			assert.False(t, IsRetryable(err))
+		}},
+		{"not found", respond(404, ``), func(t *testing.T, transaction *Transaction, err error) {
+			var apiErr *APIError
+			assert.True(t, errors.As(err, &apiErr))
+			assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+		}},
+		{"server error", respond(503, ``), func(t *testing.T, transaction *Transaction, err error) {
+			assert.True(t, IsRetryable(err))
+		}},
+		{"malformed JSON", respond(200, `<html>`), func(t *testing.T, transaction *Transaction, err error) {
+			assert.NotNil(t, err)
+			assert.False(t, IsClientError(err))
+		}},
+		{"transport error", func(req *http.Request) (*http.Response, error) {
+			return nil, failure
+		}, func(t *testing.T, transaction *Transaction, err error) {
+			assert.True(t, errors.Is(err, failure))
+		}},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			httpClient := &http.Client{}
+			client := NewClient(TestKey, WithHTTPClient(httpClient), WithTransport(test.transport))
+			transaction, err := client.FindTransaction("tx1")
+			test.check(t, transaction, err)
+			assert.Nil(t, httpClient.Transport)
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package paylike
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ValidationError describes the fields of a request rejected before sending it
+type ValidationError struct {
+	Details []ErrorDetail
+}
+
+// Error returns the rejected fields along with the reasons
+func (e *ValidationError) Error() string {
+	msg := "paylike: invalid request"
+	for i, detail := range e.Details {
+		sep := "; "
+		if i == 0 {
+			sep = ": "
+		}
+		msg += sep + detail.String()
+	}
+	return msg
+}
+
+// Field returns the reason the given field has been rejected for, if any
+func (e *ValidationError) Field(name string) (string, bool) {
+	for _, detail := range e.Details {
+		if detail.Field == name {
+			return detail.Message, true
+		}
+	}
+	return "", false
+}
+
+// ibanLengths lists the length of the IBANs of the countries in the SEPA area
+var ibanLengths = map[string]int{
+	"AD": 24, "AT": 20, "BE": 16, "BG": 22, "CH": 21, "CY": 28, "CZ": 24,
+	"DE": 22, "DK": 18, "EE": 20, "ES": 24, "FI": 18, "FO": 18, "FR": 27,
+	"GB": 22, "GI": 23, "GL": 18, "GR": 27, "HR": 21, "HU": 28, "IE": 22,
+	"IS": 26, "IT": 27, "LI": 21, "LT": 20, "LU": 20, "LV": 21, "MC": 27,
+	"MT": 31, "NL": 18, "NO": 15, "PL": 28, "PT": 25, "RO": 24, "SE": 24,
+	"SI": 19, "SK": 24, "SM": 27, "VA": 22,
+}
+
+// ErrInvalidIBAN is returned when an IBAN is malformed or its check digits
+// don't match
+var ErrInvalidIBAN = errors.New("paylike: invalid IBAN")
+
+// ValidateIBAN checks the format, length and check digits of the given IBAN,
+// which may contain spaces
+func ValidateIBAN(iban string) error {
+	iban = strings.ToUpper(strings.ReplaceAll(iban, " ", ""))
+	if len(iban) < 15 || len(iban) > 34 {
+		return ErrInvalidIBAN
+	}
+	if length, ok := ibanLengths[iban[:2]]; ok && len(iban) != length {
+		return ErrInvalidIBAN
+	}
+	for i, r := range iban {
+		letter, digit := r >= 'A' && r <= 'Z', r >= '0' && r <= '9'
+		if i < 2 && !letter || i >= 2 && i < 4 && !digit || !letter && !digit {
+			return ErrInvalidIBAN
+		}
+	}
+	remainder := 0
+	for _, r := range iban[4:] + iban[:4] {
+		if r >= 'A' {
+			remainder = (remainder*100 + int(r-'A') + 10) % 97
+		} else {
+			remainder = (remainder*10 + int(r-'0')) % 97
+		}
+	}
+	if remainder != 1 {
+		return ErrInvalidIBAN
+	}
+	return nil
+}
+
+// MerchantValidator checks a merchant before it is created, returning the
+// rejected fields
+type MerchantValidator func(dto MerchantCreateDTO) []ErrorDetail
+
+// ValidateMerchant checks the fields the API requires to create the given
+// merchant, along with the IBAN if any
+func ValidateMerchant(dto MerchantCreateDTO) []ErrorDetail {
+	var details []ErrorDetail
+	reject := func(field, message string) {
+		details = append(details, ErrorDetail{Field: field, Message: message})
+	}
+	if len(dto.Currency) != 3 {
+		reject("currency", "must be a three letter ISO code")
+	}
+	if _, err := NormalizeEmail(dto.Email); err != nil {
+		reject("email", "must be an email address")
+	}
+	if err := ValidateWebsite(dto.Website); err != nil {
+		reject("website", strings.TrimPrefix(err.Error(), ErrInvalidWebsite.Error()+": "))
+	}
+	if err := ValidateDescriptor(dto.Descriptor); err != nil {
+		reject("descriptor", strings.TrimPrefix(err.Error(), "paylike: descriptor "))
+	}
+	if dto.Company == nil {
+		reject("company", "is required")
+	} else if len(dto.Company.Country) != 2 || strings.ToUpper(dto.Company.Country) != dto.Company.Country {
+		reject("company.country", fmt.Sprintf("must be an ISO 3166 code, e.g. DK, not %q", dto.Company.Country))
+	}
+	if dto.Bank != nil && dto.Bank.Iban != "" && ValidateIBAN(dto.Bank.Iban) != nil {
+		reject("bank.iban", "must be a valid IBAN")
+	}
+	return details
+}
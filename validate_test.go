@@ -0,0 +1,44 @@
+package paylike
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateIBAN(t *testing.T) {
+	for _, iban := range []string{"DK5000400440116243", "dk50 0040 0440 1162 43", "GB82WEST12345698765432", "DE89370400440532013000"} {
+		assert.Nil(t, ValidateIBAN(iban), iban)
+	}
+	for _, iban := range []string{"", "DK5000400440116244", "DK500040044011624", "5K5000400440116243", "DKX000400440116243", "GB82WEST1234569876543!"} {
+		assert.Equal(t, ErrInvalidIBAN, ValidateIBAN(iban), iban)
+	}
+}
+
+func TestValidateMerchant(t *testing.T) {
+	dto := MerchantCreateDTO{
+		Currency:   "DKK",
+		Email:      "shop@example.com",
+		Website:    "https://shop.example.com",
+		Descriptor: "SHOP",
+		Company:    &MerchantCompany{Country: "DK"},
+		Bank:       &MerchantBank{Iban: "DK5000400440116243"},
+	}
+	assert.Empty(t, ValidateMerchant(dto))
+
+	assert.Equal(t, []ErrorDetail{
+		{Field: "currency", Message: "must be a three letter ISO code"},
+		{Field: "email", Message: "must be an email address"},
+		{Field: "website", Message: "scheme must be http or https, e.g. https://shop.example.com"},
+		{Field: "descriptor", Message: "is empty"},
+		{Field: "company", Message: "is required"},
+	}, ValidateMerchant(MerchantCreateDTO{Website: "shop.example.com"}))
+
+	dto.Company.Country = "dk"
+	dto.Bank.Iban = "DK5000400440116244"
+	err := &ValidationError{Details: ValidateMerchant(dto)}
+	assert.Equal(t, `paylike: invalid request: company.country: must be an ISO 3166 code, e.g. DK, not "dk"; bank.iban: must be a valid IBAN`, err.Error())
+	reason, ok := err.Field("bank.iban")
+	assert.True(t, ok)
+	assert.Equal(t, "must be a valid IBAN", reason)
+}
@@ -0,0 +1,250 @@
+// Package vcr records HTTP interactions with the Paylike API into fixture
+// files (cassettes) and replays them, so integration tests can run offline
+// Credentials are redacted before anything is written to disk
+package vcr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces credentials in recorded interactions
+const Redacted = "REDACTED"
+
+// Mode describes whether a recorder talks to the API or replays a cassette
+type Mode int
+
+// Possible recorder modes
+const (
+	ModeAuto   Mode = iota // replay if the cassette exists, record otherwise
+	ModeReplay             // only replay, failing requests missing from the cassette
+	ModeRecord             // always send requests to the API and record them
+)
+
+// ErrNoInteraction is returned when replaying a request missing from the cassette
+var ErrNoInteraction = errors.New("vcr: no recorded interaction matches the request")
+
+// Request describes a recorded request
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response describes a recorded response
+type Response struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Interaction describes a recorded request along with its response
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette describes the interactions recorded in a fixture file
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is a http.RoundTripper recording or replaying interactions
+type Recorder struct {
+	mode      Mode
+	path      string
+	transport http.RoundTripper
+	secrets   []string
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// Option configures a recorder
+type Option func(*Recorder)
+
+// WithTransport changes the transport used to record, defaults to http.DefaultTransport
+func WithTransport(transport http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.transport = transport
+	}
+}
+
+// WithRedacted redacts the given secrets (e.g. API keys returned when
+// creating apps) from recorded URLs and bodies
+func WithRedacted(secrets ...string) Option {
+	return func(r *Recorder) {
+		r.secrets = append(r.secrets, secrets...)
+	}
+}
+
+// New creates a recorder using the cassette at the given path
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	r := &Recorder{path: path, mode: mode, transport: http.DefaultTransport}
+	for _, opt := range opts {
+		opt(r)
+	}
+	data, err := ioutil.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("vcr: invalid cassette %s: %w", path, err)
+		}
+		if r.mode == ModeAuto {
+			r.mode = ModeReplay
+		}
+	case os.IsNotExist(err) && r.mode != ModeReplay:
+		r.mode = ModeRecord
+	default:
+		return nil, err
+	}
+	if r.mode == ModeRecord {
+		r.cassette = Cassette{}
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// Recording reports whether requests are sent to the API and recorded
+func (r *Recorder) Recording() bool {
+	return r.mode == ModeRecord
+}
+
+// Client returns a HTTP client using the recorder, to be passed to
+// paylike.WithHTTPClient
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip records or replays the given request
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	recorded := Request{
+		Method: req.Method,
+		URL:    r.redact(req.URL.String()),
+		Body:   r.redact(body),
+	}
+	if r.mode != ModeRecord {
+		return r.replay(req, recorded)
+	}
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	recorded.Header = redactHeader(req.Header)
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: recorded,
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+			Body:       r.redact(string(respBody)),
+		},
+	})
+	r.mu.Unlock()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
+
+// replay answers the given request with the first unused interaction
+// matching its method, URL and body
+func (r *Recorder) replay(req *http.Request, recorded Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, interaction := range r.cassette.Interactions {
+		candidate := interaction.Request
+		if r.used[i] || candidate.Method != recorded.Method || candidate.URL != recorded.URL || candidate.Body != recorded.Body {
+			continue
+		}
+		r.used[i] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Header.Clone(),
+			Body:          ioutil.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, recorded.Method, recorded.URL)
+}
+
+// Save writes the recorded interactions to the cassette, if recording
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, append(data, '\n'), 0644)
+}
+
+// redact replaces the secrets in the given string
+func (r *Recorder) redact(s string) string {
+	for _, secret := range r.secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, Redacted)
+		}
+	}
+	return s
+}
+
+// redactHeader copies the given header with its credentials redacted
+func redactHeader(header http.Header) http.Header {
+	redacted := header.Clone()
+	if redacted.Get("Authorization") != "" {
+		redacted.Set("Authorization", Redacted)
+	}
+	return redacted
+}
+
+// readBody reads the body of the given request, leaving it readable
+func readBody(req *http.Request) (string, error) {
+	if req.Body == nil {
+		return "", nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return "", err
+		}
+		defer body.Close()
+		b, err := ioutil.ReadAll(body)
+		return string(b), err
+	}
+	b, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return "", err
+	}
+	req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(b))
+	return string(b), nil
+}
@@ -0,0 +1,53 @@
+package vcr
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	paylike "github.com/paylike/go-api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"app":{"id":"a1","name":"shop","key":"secret-app-key"}}`))
+	}))
+	path := filepath.Join(t.TempDir(), "fixtures", "create_app.json")
+
+	recorder, err := New(path, ModeAuto, WithRedacted("secret-app-key"))
+	assert.Nil(t, err)
+	assert.True(t, recorder.Recording())
+	client := paylike.NewClient("secret-key", paylike.WithBaseURL(server.URL), paylike.WithHTTPClient(recorder.Client()))
+	app, err := client.CreateAppWithName("shop")
+	assert.Nil(t, err)
+	assert.Equal(t, "secret-app-key", app.Key)
+	assert.Nil(t, recorder.Save())
+	server.Close()
+
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.False(t, strings.Contains(string(data), "secret"))
+	assert.True(t, strings.Contains(string(data), `"Authorization": [`))
+
+	recorder, err = New(path, ModeAuto)
+	assert.Nil(t, err)
+	assert.False(t, recorder.Recording())
+	client = paylike.NewClient("other-key", paylike.WithBaseURL(server.URL), paylike.WithHTTPClient(recorder.Client()))
+	app, err = client.CreateAppWithName("shop")
+	assert.Nil(t, err)
+	assert.Equal(t, paylike.AppID("a1"), app.ID)
+	assert.Equal(t, Redacted, app.Key)
+
+	_, err = client.CreateAppWithName("shop")
+	assert.True(t, errors.Is(err, ErrNoInteraction))
+}
+
+func TestReplayMissingCassette(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "missing.json"), ModeReplay)
+	assert.NotNil(t, err)
+}
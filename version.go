@@ -0,0 +1,14 @@
+package paylike
+
+// APIVersionHeader is the header pinning the version of the API a request is
+// made against, see WithAPIVersion
+const APIVersionHeader = "Accept-Version"
+
+// WithAPIVersion pins the version of the API every request is made against,
+// so the behavior of the API doesn't shift when it evolves
+// Without it, requests are made against the version the API defaults to
+func WithAPIVersion(version string) Option {
+	return func(c *Client) {
+		c.apiVersion = version
+	}
+}
@@ -0,0 +1,23 @@
+package paylike
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithAPIVersion(t *testing.T) {
+	var versions []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		versions = append(versions, r.Header.Get(APIVersionHeader))
+		w.Write([]byte(`{"transaction":{"id":"tx1"}}`))
+	})
+	_, err := newTestClient(t, handler).FindTransaction("tx1")
+	assert.Nil(t, err)
+	_, err = newTestClient(t, handler, WithAPIVersion("2")).FindTransaction("tx1")
+	assert.Nil(t, err)
+	_, err = newTestClient(t, handler, WithAPIVersion("2")).FindTransaction("tx1", WithCallHeader(APIVersionHeader, "3"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"", "2", "3"}, versions)
+}
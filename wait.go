@@ -0,0 +1,50 @@
+package paylike
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrStateNotReached is returned by WaitForTransactionState when the
+// transaction does not reach the awaited state within the allowed attempts
+var ErrStateNotReached = errors.New("paylike: transaction did not reach the awaited state")
+
+// WaitForTransactionState fetches the given transaction until the given
+// predicate holds, e.g. until a capture is reflected by CapturedAmount,
+// smoothing over delays between writes and reads; responses without a
+// transaction count as the state not being reached yet
+// Fetches bypass any cache and are spaced by the delays of the given backoff,
+// up to its maximum number of attempts (unbounded if 0) or until the context
+// is done; the last fetched transaction is returned along with any error
+func (c Client) WaitForTransactionState(ctx context.Context, transactionID TxID, predicate func(*Transaction) bool, backoff BackoffPolicy) (*Transaction, error) {
+	c = c.with([]CallOption{WithContext(ctx)})
+	var transaction *Transaction
+	for attempt := 1; ; attempt++ {
+		fetched, err := c.fetchFreshTransaction(transactionID)
+		if err != nil && !IsRetryable(err) {
+			return transaction, err
+		}
+		if err == nil && fetched != nil {
+			transaction = fetched
+			if predicate(transaction) {
+				return transaction, nil
+			}
+		}
+		if backoff.MaxAttempts > 0 && attempt >= backoff.MaxAttempts {
+			if err != nil {
+				return transaction, err
+			}
+			return transaction, ErrStateNotReached
+		}
+		if err := sleep(ctx, backoff.NextDelay(attempt)); err != nil {
+			return transaction, err
+		}
+	}
+}
+
+// fetchFreshTransaction fetches the given transaction bypassing the cache
+// and any last known good value
+func (c Client) fetchFreshTransaction(transactionID TxID) (*Transaction, error) {
+	c = c.with([]CallOption{SkipCache()})
+	return getWrapped[Transaction](c, OpFindTransaction, nil, "transaction", string(transactionID))
+}
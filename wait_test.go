@@ -0,0 +1,73 @@
+package paylike
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitForTransactionState(t *testing.T) {
+	fetches := 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		if fetches == 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		captured := 0
+		if fetches >= 3 {
+			captured = 100
+		}
+		fmt.Fprintf(w, `{"transaction":{"id":"tx1","amount":100,"capturedAmount":%d}}`, captured)
+	}), WithCache(NewMemoryCache(10), time.Minute))
+	captured := func(transaction *Transaction) bool { return transaction.CapturedAmount == 100 }
+	backoff := BackoffPolicy{MaxAttempts: 5, BaseDelay: time.Millisecond}
+
+	transaction, err := client.WaitForTransactionState(context.Background(), "tx1", captured, backoff)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(100), transaction.CapturedAmount)
+	assert.Equal(t, 3, fetches)
+
+	fetches = 0
+	transaction, err = client.WaitForTransactionState(context.Background(), "tx1", func(transaction *Transaction) bool {
+		return transaction.RefundedAmount > 0
+	}, backoff)
+	assert.Equal(t, ErrStateNotReached, err)
+	assert.Equal(t, TxID("tx1"), transaction.ID)
+	assert.Equal(t, 5, fetches)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = client.WaitForTransactionState(ctx, "tx1", func(*Transaction) bool { return false }, BackoffPolicy{BaseDelay: time.Millisecond})
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestWaitForTransactionStateMissing(t *testing.T) {
+	fetches := 0
+	missing := 1
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		if fetches <= missing {
+			w.Write([]byte(`{}`))
+			return
+		}
+		w.Write([]byte(`{"transaction":{"id":"tx1","capturedAmount":100}}`))
+	}))
+	captured := func(transaction *Transaction) bool { return transaction.CapturedAmount == 100 }
+	backoff := BackoffPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+
+	transaction, err := client.WaitForTransactionState(context.Background(), "tx1", captured, backoff)
+	assert.Nil(t, err)
+	assert.Equal(t, TxID("tx1"), transaction.ID)
+	assert.Equal(t, 2, fetches)
+
+	fetches, missing = 0, 3
+	transaction, err = client.WaitForTransactionState(context.Background(), "tx1", captured, backoff)
+	assert.Equal(t, ErrStateNotReached, err)
+	assert.Nil(t, transaction)
+}
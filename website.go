@@ -0,0 +1,119 @@
+package paylike
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrInvalidWebsite is wrapped by the errors returned by ValidateWebsite,
+// telling why the website has been rejected
+var ErrInvalidWebsite = errors.New("paylike: invalid website")
+
+// ErrWebsiteUnreachable is wrapped by the errors returned by CheckWebsite
+var ErrWebsiteUnreachable = errors.New("paylike: website unreachable")
+
+// websiteCheckTimeout bounds the reachability check of a website
+const websiteCheckTimeout = 10 * time.Second
+
+// ValidateWebsite checks the given website is an absolute http or https URL
+// with a domain (neither localhost nor an IP address) and without credentials
+func ValidateWebsite(website string) error {
+	if website == "" {
+		return fmt.Errorf("%w: empty", ErrInvalidWebsite)
+	}
+	if strings.ContainsAny(website, " \t\r\n") {
+		return fmt.Errorf("%w: contains whitespace", ErrInvalidWebsite)
+	}
+	u, err := url.Parse(website)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWebsite, err)
+	}
+	switch {
+	case u.Scheme != "http" && u.Scheme != "https":
+		return fmt.Errorf("%w: scheme must be http or https, e.g. https://%s", ErrInvalidWebsite, strings.TrimPrefix(website, u.Scheme+"://"))
+	case u.User != nil:
+		return fmt.Errorf("%w: must not contain credentials", ErrInvalidWebsite)
+	case u.Hostname() == "":
+		return fmt.Errorf("%w: host is missing", ErrInvalidWebsite)
+	case net.ParseIP(u.Hostname()) != nil:
+		return fmt.Errorf("%w: host %q is an IP address, not a domain", ErrInvalidWebsite, u.Hostname())
+	case !strings.Contains(u.Hostname(), ".") || strings.HasSuffix(u.Hostname(), ".localhost"):
+		return fmt.Errorf("%w: host %q is not a domain", ErrInvalidWebsite, u.Hostname())
+	}
+	return nil
+}
+
+// CheckWebsite verifies the given website answers a HEAD request (or a GET
+// request, for servers not allowing HEAD) without an error status, using
+// the given HTTP client or a default one if nil
+// The default client refuses to connect to loopback, private and link-local
+// addresses, including through redirects, so websites given by users cannot
+// probe the internal network; a given client connects wherever it is told
+func CheckWebsite(ctx context.Context, client *http.Client, website string) error {
+	if err := ValidateWebsite(website); err != nil {
+		return err
+	}
+	if client == nil {
+		dialer := &net.Dialer{Timeout: websiteCheckTimeout, Control: refuseInternal}
+		client = &http.Client{Timeout: websiteCheckTimeout, Transport: &http.Transport{DialContext: dialer.DialContext}}
+	}
+	status, err := requestWebsite(ctx, client, http.MethodHead, website)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = requestWebsite(ctx, client, http.MethodGet, website)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrWebsiteUnreachable, err)
+	}
+	if status >= 400 {
+		return fmt.Errorf("%w: %d %s", ErrWebsiteUnreachable, status, http.StatusText(status))
+	}
+	return nil
+}
+
+// refuseInternal is a dialer control refusing connections to addresses not
+// reachable from the internet, checked once the host has been resolved
+func refuseInternal(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return fmt.Errorf("refusing to connect to internal address %s", host)
+	}
+	return nil
+}
+
+// requestWebsite requests the website with the given method, returning the
+// status of the response
+func requestWebsite(ctx context.Context, client *http.Client, method, website string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, website, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxErrorBodySize))
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// WithWebsiteCheck makes CreateMerchant verify the website of the merchant
+// is reachable (see CheckWebsite) before creating it, since a website with a
+// typo delays the approval of the merchant
+func WithWebsiteCheck() Option {
+	return func(c *Client) {
+		c.websiteCheck = true
+	}
+}